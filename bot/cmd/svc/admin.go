@@ -0,0 +1,285 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+// deadLetterView is the JSON representation of a dead-lettered document
+type deadLetterView struct {
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// summaryReviewView is the JSON representation of a summary held for review
+type summaryReviewView struct {
+	Title         string    `json:"title"`
+	URL           string    `json:"url"`
+	PostID        string    `json:"post_id"`
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"prompt_version"`
+	Summary       string    `json:"summary"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// summaryUsageView is the JSON representation of a day's usage by a model
+type summaryUsageView struct {
+	Day            string  `json:"day"`
+	Model          string  `json:"model"`
+	Calls          int     `json:"calls"`
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	ThinkingTokens int     `json:"thinking_tokens"`
+	Cost           float64 `json:"cost_usd"`
+}
+
+// shadowDocumentView is a document's production and shadow summaries side
+// by side
+type shadowDocumentView struct {
+	Title     string              `json:"title"`
+	URL       string              `json:"url"`
+	CreatedAt time.Time           `json:"created_at"`
+	Summaries []shadowSummaryView `json:"summaries"`
+}
+
+// shadowSummaryView is the JSON representation of one candidate's summary
+type shadowSummaryView struct {
+	Candidate      string  `json:"candidate"`
+	Model          string  `json:"model"`
+	PromptVersion  string  `json:"prompt_version"`
+	Summary        string  `json:"summary"`
+	Error          string  `json:"error,omitempty"`
+	LatencyMS      int64   `json:"latency_ms"`
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	ThinkingTokens int     `json:"thinking_tokens"`
+	Cost           float64 `json:"cost_usd"`
+}
+
+// maxUsageDays is the most days /admin/summary-usage reports
+const maxUsageDays = 90
+
+// maxShadowDocuments is the most documents /admin/shadow-summaries reports
+const maxShadowDocuments = 200
+
+// replayRequest identifies the document to replay or whose held summary to
+// resolve
+type replayRequest struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// registerAdminHandlers adds the operator endpoints to mux. They are only
+// enabled when an admin token is configured, since they can change what
+// gets posted.
+func registerAdminHandlers(mux *http.ServeMux, token string, store storage.StorageInterface) {
+	adminLog := log.WithContext("component", "admin")
+
+	if token == "" {
+		adminLog.Info("ADMIN_TOKEN not set, admin endpoints disabled")
+		return
+	}
+
+	mux.HandleFunc("GET /admin/dead-letters", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		failures, err := store.ListDeadLetters(r.Context())
+		if err != nil {
+			adminLog.Error("Error listing dead letters", "error", err)
+			http.Error(w, "error listing dead letters", http.StatusInternalServerError)
+			return
+		}
+
+		views := make([]deadLetterView, 0, len(failures))
+		for _, f := range failures {
+			views = append(views, deadLetterView{
+				Title:     f.Title,
+				URL:       f.URL,
+				Published: f.Published,
+				Attempts:  f.Attempts,
+				LastError: f.LastError,
+				UpdatedAt: f.UpdatedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, views)
+	}))
+
+	mux.HandleFunc("POST /admin/dead-letters/replay", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		var req replayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" || req.URL == "" {
+			http.Error(w, "body must be JSON with title and url", http.StatusBadRequest)
+			return
+		}
+
+		replayed, err := store.ReplayDeadLetter(r.Context(), req.Title, req.URL)
+		if err != nil {
+			adminLog.Error("Error replaying dead letter", "error", err)
+			http.Error(w, "error replaying dead letter", http.StatusInternalServerError)
+			return
+		}
+		if !replayed {
+			http.Error(w, "dead letter not found", http.StatusNotFound)
+			return
+		}
+
+		adminLog.Info("Dead letter replayed", "title", req.Title, "url", req.URL)
+		writeJSON(w, http.StatusOK, map[string]bool{"replayed": true})
+	}))
+
+	mux.HandleFunc("GET /admin/summary-reviews", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		reviews, err := store.ListSummaryReviews(r.Context())
+		if err != nil {
+			adminLog.Error("Error listing summary reviews", "error", err)
+			http.Error(w, "error listing summary reviews", http.StatusInternalServerError)
+			return
+		}
+
+		views := make([]summaryReviewView, 0, len(reviews))
+		for _, rv := range reviews {
+			views = append(views, summaryReviewView{
+				Title:         rv.Title,
+				URL:           rv.URL,
+				PostID:        rv.PostID,
+				Model:         rv.Model,
+				PromptVersion: rv.PromptVersion,
+				Summary:       rv.Summary,
+				Reason:        rv.Reason,
+				CreatedAt:     rv.CreatedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, views)
+	}))
+
+	mux.HandleFunc("POST /admin/summary-reviews/resolve", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		var req replayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" || req.URL == "" {
+			http.Error(w, "body must be JSON with title and url", http.StatusBadRequest)
+			return
+		}
+
+		resolved, err := store.ResolveSummaryReview(r.Context(), req.Title, req.URL)
+		if err != nil {
+			adminLog.Error("Error resolving summary review", "error", err)
+			http.Error(w, "error resolving summary review", http.StatusInternalServerError)
+			return
+		}
+		if !resolved {
+			http.Error(w, "summary review not found", http.StatusNotFound)
+			return
+		}
+
+		adminLog.Info("Summary review resolved", "title", req.Title, "url", req.URL)
+		writeJSON(w, http.StatusOK, map[string]bool{"resolved": true})
+	}))
+
+	mux.HandleFunc("GET /admin/summary-usage", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		days := 7
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxUsageDays {
+				http.Error(w, fmt.Sprintf("days must be between 1 and %d", maxUsageDays), http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+
+		since := summary.Day(time.Now().AddDate(0, 0, 1-days))
+		usage, err := store.DailySummaryUsage(r.Context(), since)
+		if err != nil {
+			adminLog.Error("Error listing summary usage", "error", err)
+			http.Error(w, "error listing summary usage", http.StatusInternalServerError)
+			return
+		}
+
+		views := make([]summaryUsageView, 0, len(usage))
+		for _, u := range usage {
+			views = append(views, summaryUsageView{
+				Day:            u.Day,
+				Model:          u.Model,
+				Calls:          u.Calls,
+				InputTokens:    u.InputTokens,
+				OutputTokens:   u.OutputTokens,
+				ThinkingTokens: u.ThinkingTokens,
+				Cost:           u.Cost,
+			})
+		}
+		writeJSON(w, http.StatusOK, views)
+	}))
+
+	mux.HandleFunc("GET /admin/shadow-summaries", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		limit := 20
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxShadowDocuments {
+				http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxShadowDocuments), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		summaries, err := store.ListShadowSummaries(r.Context(), limit)
+		if err != nil {
+			adminLog.Error("Error listing shadow summaries", "error", err)
+			http.Error(w, "error listing shadow summaries", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, shadowDocuments(summaries))
+	}))
+
+	adminLog.Info("Admin endpoints enabled")
+}
+
+// shadowDocuments groups shadow summaries, listed document by document, into
+// one view per document
+func shadowDocuments(summaries []storage.ShadowSummary) []shadowDocumentView {
+	views := []shadowDocumentView{}
+	for _, sh := range summaries {
+		if n := len(views); n == 0 || views[n-1].Title != sh.Title || views[n-1].URL != sh.URL {
+			views = append(views, shadowDocumentView{Title: sh.Title, URL: sh.URL, CreatedAt: sh.CreatedAt})
+		}
+		doc := &views[len(views)-1]
+		doc.Summaries = append(doc.Summaries, shadowSummaryView{
+			Candidate:      sh.Candidate,
+			Model:          sh.Model,
+			PromptVersion:  sh.PromptVersion,
+			Summary:        sh.Summary,
+			Error:          sh.Error,
+			LatencyMS:      sh.LatencyMS,
+			InputTokens:    sh.InputTokens,
+			OutputTokens:   sh.OutputTokens,
+			ThinkingTokens: sh.ThinkingTokens,
+			Cost:           sh.Cost,
+		})
+	}
+	return views
+}
+
+// requireToken rejects requests without the admin bearer token
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
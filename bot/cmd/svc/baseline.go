@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+)
+
+// Baseline modes (BASELINE)
+const (
+	baselineOff   = "off"   // post everything found, even on an empty database
+	baselineAuto  = "auto"  // baseline the first cycle when the database is empty
+	baselineForce = "force" // baseline the first cycle regardless of the database, once
+)
+
+// shouldBaseline reports whether the first discovery cycle should record the
+// listing as seen instead of posting it. In auto mode that is the case when
+// nothing has ever been processed or queued; in force mode when no forced
+// baseline was recorded yet, so a restart with BASELINE=force still set does
+// not swallow the documents published while the bot was down.
+func shouldBaseline(ctx context.Context, store storage.StorageInterface, mode string) (bool, error) {
+	switch mode {
+	case baselineForce:
+		last, err := store.LastBaseline(ctx, baselineForce)
+		if err != nil {
+			return false, err
+		}
+		if last != nil {
+			log.WithRequestContext(ctx).WithContext("component", "baseline").
+				Warn("BASELINE=force already applied, posting new documents; set BASELINE=auto",
+					"baselined_at", last.CreatedAt,
+					"seen", last.Seen)
+			return false, nil
+		}
+		return true, nil
+	case baselineAuto:
+	default:
+		return false, nil
+	}
+
+	processed, err := store.CountProcessed(ctx)
+	if err != nil {
+		return false, err
+	}
+	if processed > 0 {
+		return false, nil
+	}
+
+	jobs, err := store.CountJobs(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, n := range jobs {
+		if n > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// applyBaseline marks docs as processed without posting them, except for the
+// postNewest most recently published ones, which are returned for queueing,
+// and records the baseline. Marks and record are written in one transaction:
+// after a failure the database is unchanged and the next cycle baselines
+// again.
+func applyBaseline(ctx context.Context, store storage.StorageInterface, mode string, docs []*scraper.Document, postNewest int) ([]*scraper.Document, error) {
+	sorted := make([]*scraper.Document, len(docs))
+	copy(sorted, docs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Published.After(sorted[j].Published)
+	})
+
+	keep := min(postNewest, len(sorted))
+	err := store.ApplyBaseline(ctx, storage.Baseline{
+		Mode:      mode,
+		Seen:      len(sorted) - keep,
+		Posted:    keep,
+		CreatedAt: time.Now(),
+	}, sorted[keep:])
+	if err != nil {
+		return nil, fmt.Errorf("error recording baseline: %w", err)
+	}
+	return sorted[:keep], nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+)
+
+func TestShouldBaseline(t *testing.T) {
+	ctx := context.Background()
+	doc := &scraper.Document{Title: "Doc 1", URL: "u1", Published: time.Now()}
+
+	emptyStore := storage.NewMemory()
+	processedStore := storage.NewMemory()
+	if err := processedStore.AddProcessedDocument(ctx, storage.ProcessedDocument{Title: doc.Title, URL: doc.URL}); err != nil {
+		t.Fatal(err)
+	}
+	queuedStore := storage.NewMemory()
+	if _, err := queuedStore.EnqueueJobs(ctx, []*scraper.Document{doc}); err != nil {
+		t.Fatal(err)
+	}
+	forcedStore := storage.NewMemory()
+	if err := forcedStore.AddBaseline(ctx, storage.Baseline{Mode: baselineForce, Seen: 12, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		store storage.StorageInterface
+		mode  string
+		want  bool
+	}{
+		{"off on empty database", emptyStore, baselineOff, false},
+		{"auto on empty database", emptyStore, baselineAuto, true},
+		{"auto with processed documents", processedStore, baselineAuto, false},
+		{"auto with queued jobs", queuedStore, baselineAuto, false},
+		{"force with processed documents", processedStore, baselineForce, true},
+		{"force already applied", forcedStore, baselineForce, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shouldBaseline(ctx, tt.store, tt.mode)
+			if err != nil {
+				t.Fatalf("shouldBaseline: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("shouldBaseline = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBaselineKeepsNewest(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 5, 24, 12, 0, 0, 0, time.UTC)
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: base},
+		{Title: "Doc 3", URL: "u3", Published: base.Add(2 * time.Hour)},
+		{Title: "Doc 2", URL: "u2", Published: base.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		postNewest int
+		want       []string
+	}{
+		{"none", 0, nil},
+		{"newest two", 2, []string{"Doc 3", "Doc 2"}},
+		{"more than found", 10, []string{"Doc 3", "Doc 2", "Doc 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemory()
+			toPost, err := applyBaseline(ctx, store, baselineForce, docs, tt.postNewest)
+			if err != nil {
+				t.Fatalf("applyBaseline: %v", err)
+			}
+
+			var got []string
+			for _, doc := range toPost {
+				got = append(got, doc.Title)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("applyBaseline kept %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("applyBaseline kept %v, want %v", got, tt.want)
+				}
+			}
+
+			processed, err := store.CountProcessed(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := len(docs) - len(tt.want); processed != want {
+				t.Errorf("CountProcessed = %d, want %d", processed, want)
+			}
+
+			// A forced baseline runs once
+			if again, err := shouldBaseline(ctx, store, baselineForce); err != nil || again {
+				t.Errorf("shouldBaseline after applyBaseline = %v, %v; want false", again, err)
+			}
+		})
+	}
+}
+
+// failingBaselineStore fails ApplyBaseline as a transaction aborted midway
+// would: nothing is written
+type failingBaselineStore struct {
+	storage.StorageInterface
+}
+
+func (s *failingBaselineStore) ApplyBaseline(ctx context.Context, b storage.Baseline, docs []*scraper.Document) error {
+	return errors.New("connection reset")
+}
+
+func TestApplyBaselineFailureBaselinesAgain(t *testing.T) {
+	ctx := context.Background()
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: time.Now()},
+		{Title: "Doc 2", URL: "u2", Published: time.Now()},
+	}
+	store := &failingBaselineStore{StorageInterface: storage.NewMemory()}
+
+	if _, err := applyBaseline(ctx, store, baselineAuto, docs, 0); err == nil {
+		t.Fatal("applyBaseline succeeded with a failing store")
+	}
+	if again, err := shouldBaseline(ctx, store, baselineAuto); err != nil || !again {
+		t.Errorf("shouldBaseline after a failed baseline = %v, %v; want true", again, err)
+	}
+}
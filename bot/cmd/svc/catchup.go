@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"bot/pkg/poster"
+	"bot/pkg/scraper"
+	"bot/pkg/sequencer"
+	"bot/pkg/storage"
+	"bot/pkg/utils"
+)
+
+// Catch-up policies (CATCHUP_POLICY), applied when a cycle finds more than
+// CATCHUP_THRESHOLD new documents, typically after downtime
+const (
+	catchupAll    = "all"    // queue every document, oldest first
+	catchupRecent = "recent" // queue documents younger than CATCHUP_MAX_AGE, mark the rest seen
+	catchupDigest = "digest" // queue one digest thread instead of individual posts
+)
+
+// sortOldestFirst sorts docs by publication time, oldest first
+func sortOldestFirst(docs []*scraper.Document) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Published.Before(docs[j].Published)
+	})
+}
+
+// splitByAge partitions docs into those published after cutoff and the rest
+func splitByAge(docs []*scraper.Document, cutoff time.Time) (recent, stale []*scraper.Document) {
+	for _, doc := range docs {
+		if doc.Published.After(cutoff) {
+			recent = append(recent, doc)
+		} else {
+			stale = append(stale, doc)
+		}
+	}
+	return recent, stale
+}
+
+// catchUp applies policy to a backlog of new documents and returns the ones
+// that should still be queued for individual posting. Documents that are
+// skipped or covered by a queued digest job are marked processed.
+func (p *processor) catchUp(ctx context.Context, docs []*scraper.Document, policy string, maxAge time.Duration) ([]*scraper.Document, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("component", "catch_up").
+		WithContext("policy", policy)
+
+	backlog := make([]*scraper.Document, len(docs))
+	copy(backlog, docs)
+	sortOldestFirst(backlog)
+
+	switch policy {
+	case catchupRecent:
+		recent, stale := splitByAge(backlog, time.Now().Add(-maxAge))
+		if err := p.markProcessed(ctx, stale); err != nil {
+			return nil, err
+		}
+		ctxLog.Info("Catching up with recent documents only", "queued", len(recent), "skipped", len(stale))
+		return recent, nil
+
+	case catchupDigest:
+		// Queueing the digest marks the backlog processed in the same
+		// transaction: a failure leaves both for the next cycle, and once
+		// queued the documents are never posted individually
+		added, err := p.store.EnqueueDigest(ctx, digestDocument(backlog), backlog)
+		if err != nil {
+			return nil, fmt.Errorf("error queueing digest: %w", err)
+		}
+		ctxLog.Info("Catching up with a digest thread", "documents", len(backlog), "queued", added)
+		return nil, nil
+
+	default:
+		ctxLog.Info("Catching up with all documents in publication order", "queued", len(backlog))
+		return backlog, nil
+	}
+}
+
+// markProcessed records docs as processed without posting them
+func (p *processor) markProcessed(ctx context.Context, docs []*scraper.Document) error {
+	for _, doc := range docs {
+		err := p.store.AddProcessedDocument(ctx, storage.ProcessedDocument{
+			Title:     doc.Title,
+			URL:       doc.URL,
+			Timestamp: doc.Published,
+		})
+		if err != nil {
+			return fmt.Errorf("error marking document as processed: %w", err)
+		}
+	}
+	return nil
+}
+
+// digestDocument names the digest job of a backlog. The URL is derived from
+// the documents, so the same backlog always maps to the same job.
+func digestDocument(backlog []*scraper.Document) *scraper.Document {
+	h := sha256.New()
+	for _, doc := range backlog {
+		fmt.Fprintln(h, storage.DocKey(doc.Title, doc.URL))
+	}
+	return &scraper.Document{
+		Title:     fmt.Sprintf("Catch-up digest of %d documents", len(backlog)),
+		URL:       "digest:" + hex.EncodeToString(h.Sum(nil))[:16],
+		Published: backlog[len(backlog)-1].Published,
+	}
+}
+
+// processDigest summarises the documents of a digest job and posts them as
+// one thread. Platforms that published the digest on an earlier attempt are
+// skipped, so a retry only posts where it failed.
+func (p *processor) processDigest(ctx context.Context, job *storage.Job, ticket *sequencer.Ticket) error {
+	docLog := log.WithRequestContext(ctx).
+		WithContext("component", "catch_up")
+
+	digest := job.Document()
+	published, err := p.publishedPosts(ctx, digest)
+	if err != nil {
+		docLog.Error("Error listing platform posts", "error", err)
+		return err
+	}
+	if !slices.ContainsFunc(p.poster.Platforms(), func(platform string) bool {
+		_, ok := published[platform]
+		return !ok
+	}) {
+		docLog.Warn("Digest already posted on every platform")
+		return nil
+	}
+
+	entries := make([]poster.DigestEntry, 0, len(job.Digest))
+	titles := make([]string, 0, len(job.Digest))
+	for _, doc := range job.Digest {
+		entries = append(entries, poster.DigestEntry{
+			Title:       doc.Title,
+			Published:   doc.Published,
+			DocumentURL: utils.EncodeURL(doc.URL),
+			Summary:     p.digestSummary(ctx, doc),
+		})
+		titles = append(titles, "• "+doc.Title)
+	}
+
+	intro := fmt.Sprintf("📋 CATCH-UP DIGEST 📋\n\n%d documents were published while we were offline. Summaries and links are in the replies:\n\n%s",
+		len(job.Digest), strings.Join(titles, "\n"))
+
+	if err := waitTurn(ctx, ticket); err != nil {
+		return err
+	}
+
+	docLog.Info("Publishing digest", "documents", len(job.Digest), "platforms", strings.Join(p.poster.Platforms(), ","))
+	if _, err := p.recordPosts(ctx, digest, p.poster.PostDigest(ctx, intro, entries, published), published); err != nil {
+		docLog.Error("Error publishing digest", "error", err)
+		return err
+	}
+	docLog.Info("Digest posted")
+	return nil
+}
+
+// digestSummary downloads and summarises a document for its digest entry.
+// Failures only cost the entry its summary.
+func (p *processor) digestSummary(ctx context.Context, doc *scraper.Document) string {
+	docLog := log.WithRequestContext(ctx).
+		WithContext("component", "catch_up").
+		WithContext("title", doc.Title)
+
+	docDir := filepath.Join(tempDir, fmt.Sprintf("%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(docDir, 0755); err != nil {
+		docLog.Error("Error creating directory for document", "error", err)
+		return ""
+	}
+	defer func() {
+		if err := os.RemoveAll(docDir); err != nil {
+			docLog.Error("Error removing directory for document", "error", err)
+		}
+	}()
+
+	pdfPath, err := p.scraper.DownloadDocument(ctx, *doc, docDir)
+	if err != nil {
+		if p.scraper.IsRecalledDocument(*doc) || strings.Contains(err.Error(), "possibly recalled") {
+			return "This document has been recalled by the FIA."
+		}
+		docLog.Warn("Error downloading document for digest", "error", err)
+		return ""
+	}
+
+	aiSummary, err := p.summarizer.GenerateSummary(ctx, pdfPath, p.summaryDocument(doc))
+	if err != nil {
+		docLog.Warn("Error generating summary for digest", "error", err)
+		return ""
+	}
+	return aiSummary.Text
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bot/pkg/poster"
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+)
+
+func TestCatchUp(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	docs := []*scraper.Document{
+		{Title: "Doc 4", URL: "u4", Published: now.Add(-10 * time.Minute)},
+		{Title: "Doc 1", URL: "u1", Published: now.Add(-5 * time.Hour)},
+		{Title: "Doc 3", URL: "u3", Published: now.Add(-30 * time.Minute)},
+		{Title: "Doc 2", URL: "u2", Published: now.Add(-3 * time.Hour)},
+	}
+
+	tests := []struct {
+		name          string
+		policy        string
+		wantQueued    []string
+		wantProcessed int
+	}{
+		{"all in publication order", catchupAll, []string{"Doc 1", "Doc 2", "Doc 3", "Doc 4"}, 0},
+		{"recent only", catchupRecent, []string{"Doc 3", "Doc 4"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemory()
+			p := &processor{store: store}
+
+			queued, err := p.catchUp(ctx, docs, tt.policy, 2*time.Hour)
+			if err != nil {
+				t.Fatalf("catchUp: %v", err)
+			}
+
+			var got []string
+			for _, doc := range queued {
+				got = append(got, doc.Title)
+			}
+			if len(got) != len(tt.wantQueued) {
+				t.Fatalf("catchUp queued %v, want %v", got, tt.wantQueued)
+			}
+			for i := range got {
+				if got[i] != tt.wantQueued[i] {
+					t.Fatalf("catchUp queued %v, want %v", got, tt.wantQueued)
+				}
+			}
+
+			processed, err := store.CountProcessed(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if processed != tt.wantProcessed {
+				t.Errorf("CountProcessed = %d, want %d", processed, tt.wantProcessed)
+			}
+		})
+	}
+}
+
+func TestCatchUpDigest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	// Recalled titles keep the digest summaries off the network
+	docs := []*scraper.Document{
+		{Title: "Recalled - Doc 2", URL: "u2", Published: now.Add(-time.Hour)},
+		{Title: "Recalled - Doc 1", URL: "u1", Published: now.Add(-2 * time.Hour)},
+	}
+	first := &stubPublisher{platform: "first", err: errors.New("unavailable")}
+	second := &stubPublisher{platform: "second"}
+	store := storage.NewMemory()
+	p := &processor{
+		scraper: scraper.New(""),
+		poster:  poster.New("", "", "", "", first, second),
+		store:   store,
+	}
+
+	// A failed enqueue leaves neither the digest nor processed documents,
+	// so the next cycle catches up on the same backlog
+	store.SetConnectionError(errors.New("connection refused"))
+	if _, err := p.catchUp(ctx, docs, catchupDigest, time.Hour); err == nil {
+		t.Fatal("catchUp succeeded without storage")
+	}
+	store.SetConnectionError(nil)
+	if n, err := store.CountProcessed(ctx); err != nil || n != 0 {
+		t.Fatalf("CountProcessed after a failed catch-up = %d, %v; want 0", n, err)
+	}
+
+	queued, err := p.catchUp(ctx, docs, catchupDigest, time.Hour)
+	if err != nil || len(queued) != 0 {
+		t.Fatalf("catchUp = %v, %v; want nothing queued individually", queued, err)
+	}
+	if n, err := store.CountProcessed(ctx); err != nil || n != 2 {
+		t.Fatalf("CountProcessed = %d, %v; want the backlog marked processed", n, err)
+	}
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil || len(job.Digest) != 2 || job.Digest[0].Title != "Recalled - Doc 1" {
+		t.Fatalf("ClaimJob = %+v, %v; want the digest, oldest document first", job, err)
+	}
+
+	// A platform failure fails the job; the retry posts there only
+	if err := p.processDigest(ctx, job, nil); err == nil {
+		t.Fatal("processDigest succeeded with a platform failing")
+	}
+	first.err = nil
+	if err := p.processDigest(ctx, job, nil); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if err := p.processDigest(ctx, job, nil); err != nil {
+		t.Fatalf("processDigest once posted: %v", err)
+	}
+	if first.posts != 1 || second.posts != 1 {
+		t.Errorf("posts %d and %d, want one digest per platform", first.posts, second.posts)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"bot/pkg/config"
+	"bot/pkg/logger"
+	"bot/pkg/summary"
+)
+
+// evalUsage documents the eval command
+const evalUsage = `Usage: svc eval [-models list]... [-prompts dir] [-o report.md] dir
+
+Summarizes every PDF in dir and scores the summaries against the facts in
+the JSON file next to each PDF, then writes a Markdown report (default
+stdout). Each -models list is evaluated as a separate summarizer, as in
+GEMINI_MODELS (default: GEMINI_MODELS). Use local/ models with LOCAL_LLM_URL
+to run offline.
+`
+
+// modelLists collects repeated -models flags
+type modelLists []string
+
+func (m *modelLists) String() string { return strings.Join(*m, " ") }
+
+func (m *modelLists) Set(s string) error {
+	*m = append(*m, s)
+	return nil
+}
+
+// evalRun is the scores of one summarizer
+type evalRun struct {
+	models string
+	scores []summary.EvalScore
+}
+
+// runEval runs the eval command and returns the process exit code
+func runEval(args []string) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	var models modelLists
+	flags.Var(&models, "models", "model list to evaluate; repeat to compare")
+	prompts := flags.String("prompts", "", "prompt templates directory (default PROMPTS_DIR)")
+	out := flags.String("o", "-", "report file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	dir := flags.Arg(0)
+
+	cfg, err := config.LoadSummary()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	// Logs go to stderr so that the report can go to stdout
+	logLevel, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logLevel = logger.LevelInfo
+	}
+	log = logger.New(logger.Config{
+		Level:          logLevel,
+		OutputWriter:   os.Stderr,
+		ServiceName:    serviceName,
+		Environment:    cfg.Environment,
+		Version:        cfg.Version,
+		SanitizeFields: true,
+	})
+	logger.SetDefaultLogger(log)
+	cmdLog := log.WithContext("command", "eval")
+
+	cases, err := summary.LoadEvalCases(dir)
+	if err != nil {
+		cmdLog.Error("Error loading evaluation cases", "dir", dir, "error", err)
+		return 1
+	}
+
+	summaryCfg, err := summaryConfig(cfg)
+	if err != nil {
+		cmdLog.Error("Invalid summarizer configuration", "error", err)
+		return 1
+	}
+	// Translations and shadow candidates are not evaluated
+	summaryCfg.Languages, summaryCfg.Shadows = nil, nil
+	if *prompts != "" {
+		summaryCfg.PromptsDir = *prompts
+	}
+	if len(models) == 0 {
+		models = modelLists{cfg.GeminiModels}
+	}
+
+	ctx, _ := logger.NewRequestContext()
+	runs := make([]evalRun, 0, len(models))
+	for _, list := range models {
+		summaryCfg.Models = list
+		summarizer, err := summary.New(summaryCfg)
+		if err != nil {
+			cmdLog.Error("Failed to initialize summarizer", "models", list, "error", err)
+			return 1
+		}
+		runs = append(runs, evaluate(ctx, summarizer, list, cases))
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			cmdLog.Error("Error creating report", "path", *out, "error", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := writeEvalReport(w, dir, cases, runs); err != nil {
+		cmdLog.Error("Error writing report", "path", *out, "error", err)
+		return 1
+	}
+	cmdLog.Info("Evaluation complete", "documents", len(cases), "summarizers", len(runs), "report", *out)
+	return 0
+}
+
+// evaluate scores every case with the summarizer
+func evaluate(ctx context.Context, summarizer *summary.Summarizer, models string, cases []summary.EvalCase) evalRun {
+	run := evalRun{models: models}
+	for _, c := range cases {
+		score := summarizer.Evaluate(ctx, c)
+		log.WithContext("command", "eval").Info("Document evaluated",
+			"models", models,
+			"document", c.Name,
+			"facts_found", score.FactsFound,
+			"facts_total", score.FactsTotal,
+			"error", score.Error)
+		run.scores = append(run.scores, score)
+	}
+	return run
+}
+
+// writeEvalReport writes the comparison report in Markdown: an overview per
+// summarizer, each document's scores side by side, then the missing facts
+// and rule violations
+func writeEvalReport(w io.Writer, dir string, cases []summary.EvalCase, runs []evalRun) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Summary evaluation\n\n%d documents from `%s`, %s.\n\n", len(cases), dir, time.Now().UTC().Format("2006-01-02 15:04 MST"))
+
+	b.WriteString("## Overview\n\n")
+	b.WriteString("| Models | Summarized | Errors | Rejected | Fact coverage | Length OK | Banned tokens | Input tokens | Output tokens | Avg time |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, run := range runs {
+		var summarized, errs, rejected, lengthOK, violations int
+		var coverage float64
+		var usage summary.Usage
+		var elapsed time.Duration
+		for _, sc := range run.scores {
+			elapsed += sc.Duration
+			usage = usage.Add(sc.Usage)
+			if sc.Error != "" {
+				errs++
+				continue
+			}
+			summarized++
+			coverage += sc.Coverage()
+			if sc.Rejected != "" {
+				rejected++
+			}
+			if sc.LengthOK {
+				lengthOK++
+			}
+			violations += len(sc.Violations)
+		}
+		// Errors count as no coverage, so failing models score lower
+		fmt.Fprintf(&b, "| `%s` | %d | %d | %d | %.0f%% | %d/%d | %d | %d | %d | %s |\n",
+			run.models, summarized, errs, rejected,
+			100*coverage/float64(max(len(run.scores), 1)),
+			lengthOK, summarized, violations,
+			usage.InputTokens, usage.OutputTokens,
+			(elapsed / time.Duration(max(len(run.scores), 1))).Round(time.Millisecond))
+	}
+
+	b.WriteString("\n## Documents\n\n| Document |")
+	for _, run := range runs {
+		fmt.Fprintf(&b, " `%s` |", run.models)
+	}
+	b.WriteString("\n|---|")
+	b.WriteString(strings.Repeat("---|", len(runs)))
+	for i, c := range cases {
+		fmt.Fprintf(&b, "\n| %s |", c.Name)
+		for _, run := range runs {
+			fmt.Fprintf(&b, " %s |", scoreCell(run.scores[i]))
+		}
+	}
+
+	b.WriteString("\n\n## Findings\n")
+	findings := 0
+	for _, run := range runs {
+		for _, sc := range run.scores {
+			var notes []string
+			if sc.Error != "" {
+				notes = append(notes, "error: "+sc.Error)
+			}
+			if sc.Rejected != "" {
+				notes = append(notes, "rejected: "+sc.Rejected)
+			}
+			if len(sc.Missing) > 0 {
+				notes = append(notes, "missing facts: "+strings.Join(sc.Missing, ", "))
+			}
+			if len(sc.Violations) > 0 {
+				notes = append(notes, "contains "+strings.Join(sc.Violations, ", "))
+			}
+			if len(notes) == 0 {
+				continue
+			}
+			findings++
+			fmt.Fprintf(&b, "\n- **%s** with `%s`: %s", sc.Case, run.models, strings.Join(notes, "; "))
+			if sc.Summary != "" {
+				fmt.Fprintf(&b, "\n  > %s", strings.Join(strings.Fields(sc.Summary), " "))
+			}
+		}
+	}
+	if findings == 0 {
+		b.WriteString("\nNone.")
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scoreCell summarizes a score for the documents table
+func scoreCell(sc summary.EvalScore) string {
+	if sc.Error != "" {
+		return "error"
+	}
+	cell := fmt.Sprintf("%d/%d facts, %d words", sc.FactsFound, sc.FactsTotal, sc.Words)
+	if !sc.LengthOK {
+		cell += " (length)"
+	}
+	if len(sc.Violations) > 0 {
+		cell += fmt.Sprintf(", %d banned", len(sc.Violations))
+	}
+	if sc.Rejected != "" {
+		cell += ", rejected"
+	}
+	return cell
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"bot/pkg/summary"
+)
+
+func TestWriteEvalReport(t *testing.T) {
+	cases := []summary.EvalCase{{Name: "decision"}, {Name: "summons"}}
+	runs := []evalRun{
+		{models: "local/good", scores: []summary.EvalScore{
+			{Case: "decision", Model: "local/good", Summary: "Verstappen gets\na penalty.", FactsFound: 1, FactsTotal: 2, Missing: []string{"Turn 1"}, Words: 4, LengthOK: true},
+			{Case: "summons", Model: "local/good", FactsFound: 2, FactsTotal: 2, Words: 40, LengthOK: true},
+		}},
+		{models: "local/bad", scores: []summary.EvalScore{
+			{Case: "decision", Error: "model not found", FactsTotal: 2},
+			{Case: "summons", Model: "local/bad", FactsFound: 2, FactsTotal: 2, Words: 90, Violations: []string{`banned token "#"`}},
+		}},
+	}
+
+	var b strings.Builder
+	if err := writeEvalReport(&b, "golden", cases, runs); err != nil {
+		t.Fatal(err)
+	}
+	report := b.String()
+	for _, want := range []string{
+		"| `local/good` | 2 | 0 | 0 | 75% | 2/2 | 0 |",
+		"| `local/bad` | 1 | 1 | 0 | 50% | 0/1 | 1 |",
+		"| decision | 1/2 facts, 4 words | error |",
+		"| summons | 2/2 facts, 40 words | 2/2 facts, 90 words (length), 1 banned |",
+		"- **decision** with `local/good`: missing facts: Turn 1\n  > Verstappen gets a penalty.",
+		"- **decision** with `local/bad`: error: model not found",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+}
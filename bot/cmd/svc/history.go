@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+// historyCandidates bounds the earlier documents scored per summary; an event
+// weekend has well under this many
+const historyCandidates = 200
+
+// summaryHistory provides the summarizer with earlier posted documents from
+// storage
+type summaryHistory struct {
+	store storage.StorageInterface
+}
+
+// RecentDocuments returns documents published since since, newest first
+func (h summaryHistory) RecentDocuments(ctx context.Context, since time.Time) ([]summary.RelatedDocument, error) {
+	docs, err := h.store.RecentDocuments(ctx, since, historyCandidates)
+	if err != nil {
+		return nil, err
+	}
+	related := make([]summary.RelatedDocument, 0, len(docs))
+	for _, doc := range docs {
+		related = append(related, summary.RelatedDocument{
+			Title:     doc.Title,
+			URL:       doc.URL,
+			Published: doc.Timestamp,
+			Summary:   doc.Summary,
+			Text:      doc.PageText,
+		})
+	}
+	return related, nil
+}
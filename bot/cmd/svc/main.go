@@ -6,33 +6,51 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"bot/pkg/config"
+	"bot/pkg/leader"
 	"bot/pkg/logger"
 	"bot/pkg/poster"
 	"bot/pkg/scraper"
+	"bot/pkg/sequencer"
 	"bot/pkg/storage"
 	"bot/pkg/summary"
 	"bot/pkg/utils"
 )
 
 const (
-	maxConcurrentProcessing = 5               // Maximum number of documents to process concurrently
+	maxConcurrentProcessing = 5               // Number of queue workers processing documents concurrently
 	tempDir                 = "temp"          // Temporary directory for downloaded PDFs
-	shortRetryInterval      = 1 * time.Minute // Short retry interval for DB connection
-	longRetryInterval       = 5 * time.Minute // Long retry interval for DB connection
 	serviceName             = "f1-docs-bot"   // Service name for logging
+	modelCheckTimeout       = time.Minute     // Time allowed to check the summarization models
+	modelCheckInterval      = 5 * time.Minute // Interval between summarization model checks
+)
+
+// DB reconnect intervals; variables so tests can shorten them
+var (
+	shortRetryInterval = 1 * time.Minute // Short retry interval for DB connection
+	longRetryInterval  = 5 * time.Minute // Long retry interval for DB connection
 )
 
 // Global logger
 var log *logger.Logger
 
+// checkModels runs CheckModels with modelCheckTimeout. A failure is only
+// logged: Readiness keeps the result for /health.
+func checkModels(ctx context.Context, summarizer *summary.Summarizer) {
+	checkCtx, cancel := context.WithTimeout(ctx, modelCheckTimeout)
+	defer cancel()
+	if _, err := summarizer.CheckModels(checkCtx); err != nil {
+		log.WithRequestContext(ctx).Error("Summarization models unreachable", "error", err)
+	}
+}
+
 // waitForDBConnection waits until the database is reachable, retrying with a
 // short interval first and a long interval after that. sql.DB is a
 // self-healing pool, so a successful ping is all that is needed to recover.
@@ -68,6 +86,74 @@ func waitForDBConnection(ctx context.Context, store storage.StorageInterface) bo
 	}
 }
 
+// openStorage opens the storage backend selected by STORAGE_DRIVER
+func openStorage(cfg *config.Config) (storage.StorageInterface, error) {
+	switch cfg.StorageDriver {
+	case "sqlite":
+		return storage.NewSQLite(cfg.SQLitePath)
+	case "memory":
+		return storage.NewMemory(), nil
+	default:
+		return storage.NewPostgres(
+			cfg.DBHost,
+			cfg.DBPort,
+			cfg.DBUser,
+			cfg.DBPassword,
+			cfg.DBName,
+			cfg.DBSSLMode,
+		)
+	}
+}
+
+// summaryConfig returns the summarizer configuration of cfg, without the
+// parts backed by storage (cache, ledger and history)
+func summaryConfig(cfg *config.Config) (summary.Config, error) {
+	prices, err := summary.ParsePrices(cfg.SummaryPrices)
+	if err != nil {
+		return summary.Config{}, fmt.Errorf("invalid SUMMARY_PRICES: %w", err)
+	}
+	languages, err := summary.ParseLanguages(cfg.SummaryLanguages)
+	if err != nil {
+		return summary.Config{}, fmt.Errorf("invalid SUMMARY_LANGUAGES: %w", err)
+	}
+	shadows, err := summary.ParseShadowCandidates(cfg.SummaryShadow, cfg.GeminiBackend)
+	if err != nil {
+		return summary.Config{}, fmt.Errorf("invalid SUMMARY_SHADOW: %w", err)
+	}
+	return summary.Config{
+		Backend:               cfg.GeminiBackend,
+		APIKey:                cfg.GeminiAPIKey,
+		VertexProject:         cfg.VertexProject,
+		VertexLocation:        cfg.VertexLocation,
+		VertexCredentialsFile: cfg.VertexCredentialsFile,
+		AIStudioAPIKey:        cfg.AIStudioAPIKey,
+		OpenAIBaseURL:         cfg.OpenAIBaseURL,
+		OpenAIAPIKey:          cfg.OpenAIAPIKey,
+		LocalURL:              cfg.LocalLLMURL,
+		Models:                cfg.GeminiModels,
+		PromptsDir:            cfg.PromptsDir,
+		RoutesFile:            cfg.SummaryRoutesFile,
+		Guardrails: summary.Guardrails{
+			MinWords: cfg.SummaryMinWords,
+			MaxWords: cfg.SummaryMaxWords,
+			Banned:   strings.Split(cfg.SummaryBannedTokens, ","),
+		},
+		TextTokenBudget: cfg.SummaryTextTokenBudget,
+		Retry: summary.RetryPolicy{
+			Attempts:         cfg.SummaryRetryAttempts,
+			BreakerThreshold: cfg.SummaryBreakerThreshold,
+			BreakerCooldown:  time.Duration(cfg.SummaryBreakerCooldown) * time.Second,
+		},
+		Prices:        prices,
+		DailyBudget:   cfg.SummaryDailyBudget,
+		BudgetModels:  cfg.SummaryBudgetModels,
+		RelatedWindow: time.Duration(cfg.SummaryRelatedDays) * 24 * time.Hour,
+		MaxRelated:    cfg.SummaryRelatedDocuments,
+		Languages:     languages,
+		Shadows:       shadows,
+	}, nil
+}
+
 // sleepOrShutdown sleeps for the given duration. Returns false if the context
 // was cancelled (shutdown requested) before the duration elapsed.
 func sleepOrShutdown(ctx context.Context, d time.Duration) bool {
@@ -80,6 +166,11 @@ func sleepOrShutdown(ctx context.Context, d time.Duration) bool {
 }
 
 func main() {
+	// Maintenance subcommands (export, import, eval) run and exit
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	// Record start time for uptime tracking
 	startTime := time.Now()
 
@@ -135,17 +226,10 @@ func main() {
 	}
 
 	// Initialize storage based on configuration
-	appLog.Info("Initializing PostgreSQL storage")
-	store, err := storage.NewPostgres(
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBName,
-		cfg.DBSSLMode,
-	)
+	appLog.Info("Initializing storage", "driver", cfg.StorageDriver)
+	store, err := openStorage(cfg)
 	if err != nil {
-		appLog.Error("Failed to initialize PostgreSQL storage", "error", err)
+		appLog.Error("Failed to initialize storage", "driver", cfg.StorageDriver, "error", err)
 		os.Exit(1)
 	}
 
@@ -158,26 +242,69 @@ func main() {
 
 	// Initialize the packages
 	appLog.Info("Initializing summarizer")
-	summarizer, err := summary.New(summary.Config{
-		APIKey: cfg.GeminiAPIKey,
-		Models: cfg.GeminiModels,
-	})
+	summaryCfg, err := summaryConfig(cfg)
+	if err != nil {
+		appLog.Error("Invalid summarizer configuration", "error", err)
+		os.Exit(1)
+	}
+	summaryCfg.Cache = store
+	summaryCfg.Ledger = summaryLedger{store: store}
+	// Without related documents the summarizer needs no history
+	if cfg.SummaryRelatedDocuments > 0 {
+		summaryCfg.History = summaryHistory{store: store}
+	}
+	summarizer, err := summary.New(summaryCfg)
 	if err != nil {
 		appLog.Error("Failed to initialize summarizer", "error", err)
 		os.Exit(1)
 	}
 	defer summarizer.Close()
 
+	// Surface wrong credentials or model names at startup rather than on
+	// the first document. An unreachable provider is reported on /health
+	// and checked again periodically; documents are still posted meanwhile.
+	checkModels(appCtx, summarizer)
+
 	appLog.Info("Initializing scraper and poster")
 	sc := scraper.New(cfg.FIAUrl)
 	appLog.Info("Scraper initialized successfully")
 
-	pstr, err := poster.New(cfg.ThreadsAccessToken, cfg.ThreadsUserID, cfg.ThreadsClientID, cfg.ThreadsClientSecret, cfg.ThreadsRedirectURI, cfg.PicsurAPI, cfg.PicsurURL, cfg.ShortenerAPIKey, cfg.ShortenerURL)
-	if err != nil {
-		appLog.Error("Failed to initialize poster", "error", err)
-		os.Exit(1)
+	// Every document is published to each enabled platform; a dry run only
+	// logs the posts
+	var pstr *poster.Poster
+	var threadsPublisher *poster.Threads
+	if cfg.DryRun {
+		pstr, err = poster.NewDryRun(cfg.PublisherList()...)
+		if err != nil {
+			appLog.Error("Failed to initialize dry run poster", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		var publishers []poster.Publisher
+		for _, platform := range cfg.PublisherList() {
+			switch platform {
+			case poster.PlatformThreads:
+				threadsPublisher, err = poster.NewThreads(cfg.ThreadsAccessToken, cfg.ThreadsClientID, cfg.ThreadsClientSecret, cfg.ThreadsRedirectURI)
+				if err != nil {
+					appLog.Error("Failed to initialize Threads publisher", "error", err)
+					os.Exit(1)
+				}
+				publishers = append(publishers, threadsPublisher)
+			}
+		}
+		pstr = poster.New(cfg.PicsurAPI, cfg.PicsurURL, cfg.ShortenerAPIKey, cfg.ShortenerURL, publishers...)
+	}
+	appLog.Info("Poster initialized successfully", "platforms", strings.Join(pstr.Platforms(), ","))
+
+	alerter := utils.NewAlertClient(cfg.AlertWebhookURL)
+
+	// Failing documents are retried with exponential backoff and
+	// dead-lettered after RetryMaxAttempts
+	retryPolicy := storage.RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.RetryBaseDelay) * time.Second,
+		MaxDelay:    time.Duration(cfg.RetryMaxDelay) * time.Second,
 	}
-	appLog.Info("Poster initialized successfully")
 
 	// Setup graceful shutdown
 	shutdownChan := make(chan os.Signal, 1)
@@ -187,9 +314,58 @@ func main() {
 	bgCtx, bgCancel := context.WithCancel(context.Background())
 	defer bgCancel()
 
+	// SIGHUP reloads the prompt templates without a restart
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-reloadChan:
+				if err := summarizer.ReloadPrompts(); err != nil {
+					appLog.Error("Error reloading prompt templates, keeping the current ones", "error", err)
+				} else {
+					appLog.Info("Prompt templates reloaded")
+				}
+			case <-bgCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Re-check the models so /health recovers once a provider is reachable
+	go func() {
+		ticker := time.NewTicker(modelCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				checkCtx, _ := logger.NewRequestContextFrom(bgCtx)
+				checkModels(checkCtx, summarizer)
+			case <-bgCtx.Done():
+				return
+			}
+		}
+	}()
+
 	// Channel to coordinate shutdown
 	done := make(chan bool, 1)
 
+	// Leader election: singleton duties (scraping, token refresh) only run
+	// on the instance holding the lock, so a rolling deploy never has two
+	// instances posting the same documents.
+	var elector *leader.Elector
+	if cfg.LeaderElection {
+		elector = leader.New(store, time.Duration(cfg.LeaderCheckInterval)*time.Second)
+	} else {
+		appLog.Info("Leader election disabled, running as sole leader")
+		elector = leader.Standalone()
+	}
+	electorDone := make(chan struct{})
+	go func() {
+		defer close(electorDone)
+		elector.Run(bgCtx)
+	}()
+
 	appLog.Info("Service initialization complete, entering main loop")
 
 	// Setup health check endpoint
@@ -202,23 +378,59 @@ func main() {
 
 		uptime := time.Since(startTime)
 		goroutines := runtime.NumGoroutine()
+		leadership := elector.Status()
 
 		healthLog.Debug("Health check requested",
 			"db_connected", dbHealthy,
+			"leader", leadership.Leader,
 			"uptime_seconds", uptime.Seconds(),
 			"goroutines", goroutines,
 		)
 
+		// A standby instance is healthy: leadership is reported, not required.
+		var details strings.Builder
+		_, _ = fmt.Fprintf(&details, "Uptime: %s\nGoroutines: %d\n", uptime, goroutines)
+		_, _ = fmt.Fprintf(&details, "Leader: %t (since %s)\n",
+			leadership.Leader, leadership.Since.UTC().Format(time.RFC3339))
+		if dbHealthy {
+			if counts, err := store.CountJobs(r.Context()); err == nil {
+				_, _ = fmt.Fprintf(&details, "Jobs: pending=%d running=%d dead=%d\n",
+					counts[storage.JobPending], counts[storage.JobRunning], counts[storage.JobDead])
+			}
+		}
+		// An unready summarizer degrades summaries, not the service
+		if readiness := summarizer.Readiness(); readiness.Ready {
+			_, _ = fmt.Fprintf(&details, "Summarizer: ready (models checked %s)\n", readiness.Checked.UTC().Format(time.RFC3339))
+		} else {
+			_, _ = fmt.Fprintf(&details, "Summarizer: not ready: %s\n", readiness.Reason)
+		}
+		if spent, budget, err := summarizer.Spending(r.Context()); err == nil && budget > 0 {
+			_, _ = fmt.Fprintf(&details, "Summary budget: $%.2f of $%.2f spent today\n", spent, budget)
+		}
+		// Open breakers degrade summaries, not the service
+		for _, m := range summarizer.Status() {
+			if m.State == summary.BreakerClosed {
+				_, _ = fmt.Fprintf(&details, "Model %s: %s\n", m.Model, m.State)
+			} else {
+				_, _ = fmt.Fprintf(&details, "Model %s: %s (failures=%d, until %s)\n",
+					m.Model, m.State, m.Failures, m.OpenUntil.UTC().Format(time.RFC3339))
+			}
+		}
+
 		if dbHealthy {
 			w.WriteHeader(http.StatusOK)
-			_, _ = fmt.Fprintf(w, "OK\nUptime: %s\nGoroutines: %d\n", uptime, goroutines)
+			_, _ = fmt.Fprintf(w, "OK\n%s", details.String())
 		} else {
 			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = fmt.Fprintf(w, "Database connection lost\nUptime: %s\nGoroutines: %d\n", uptime, goroutines)
+			_, _ = fmt.Fprintf(w, "Database connection lost\n%s", details.String())
 			healthLog.Warn("Health check failed - database connection lost")
 		}
 	})
 
+	// Admin endpoints and metrics share the health server
+	registerAdminHandlers(mux, cfg.AdminToken, store)
+	registerMetrics(mux, store, summarizer, elector)
+
 	// Start health check server with graceful shutdown support
 	healthServer := &http.Server{
 		Addr:    ":6060",
@@ -232,8 +444,12 @@ func main() {
 		}
 	}()
 
-	// Start a goroutine to periodically check and refresh token
+	// Start a goroutine to periodically check and refresh the Threads token
 	go func() {
+		if threadsPublisher == nil {
+			return
+		}
+
 		tokenCtx, _ := logger.NewRequestContextFrom(bgCtx)
 		tokenLog := log.WithRequestContext(tokenCtx).WithContext("component", "token_refresher")
 
@@ -250,17 +466,20 @@ func main() {
 		for {
 			tokenLog.Debug("Checking token status")
 
-			// Check if token needs refresh
-			if pstr.ThreadsClient.IsTokenExpired() {
+			// Check if token needs refresh. Only the leader refreshes, so
+			// instances sharing a token don't race each other.
+			if !elector.IsLeader() {
+				tokenLog.Debug("Not the leader, skipping token check")
+			} else if threadsPublisher.Client.IsTokenExpired() {
 				tokenLog.Info("Token is expired, attempting to refresh")
-				if err := pstr.ThreadsClient.RefreshToken(tokenCtx); err != nil {
+				if err := threadsPublisher.Client.RefreshToken(tokenCtx); err != nil {
 					tokenLog.Error("Failed to refresh expired token", "error", err)
 				} else {
 					tokenLog.Info("Token refreshed successfully")
 				}
-			} else if pstr.ThreadsClient.IsTokenExpiringSoon(240 * time.Hour) {
+			} else if threadsPublisher.Client.IsTokenExpiringSoon(240 * time.Hour) {
 				tokenLog.Info("Token is expiring soon, refreshing proactively")
-				if err := pstr.ThreadsClient.RefreshToken(tokenCtx); err != nil {
+				if err := threadsPublisher.Client.RefreshToken(tokenCtx); err != nil {
 					tokenLog.Warn("Failed to proactively refresh token", "error", err)
 				} else {
 					tokenLog.Info("Token refreshed successfully")
@@ -278,13 +497,79 @@ func main() {
 		}
 	}()
 
-	// Start main processing loop in a goroutine
+	proc := &processor{
+		scraper:     sc,
+		summarizer:  summarizer,
+		poster:      pstr,
+		store:       store,
+		alerter:     alerter,
+		retryPolicy: retryPolicy,
+
+		publishOrder: cfg.PublishOrder,
+		archiveDir:   cfg.PDFArchiveDir,
+
+		shadowCtx:   bgCtx,
+		shadowSlots: make(chan struct{}, maxShadowRuns),
+	}
+	if cfg.PublishOrder != publishOrderNone {
+		// Ordering is per instance: documents claimed here publish in order
+		proc.sequencer = sequencer.New(time.Duration(cfg.PublishOrderTimeout) * time.Second)
+		appLog.Info("Ordered publishing enabled",
+			"order", cfg.PublishOrder,
+			"timeout_seconds", cfg.PublishOrderTimeout)
+	}
+
+	// Retention runs on the leader only, like scraping
+	ret := &retention{
+		store:       store,
+		picsur:      pstr.PicsurClient,
+		archiveDir:  cfg.PDFArchiveDir,
+		pageTextAge: time.Duration(cfg.RetentionPageTextDays) * 24 * time.Hour,
+		pdfAge:      time.Duration(cfg.RetentionPDFDays) * 24 * time.Hour,
+		imageAge:    time.Duration(cfg.RetentionImageDays) * 24 * time.Hour,
+		jobAge:      time.Duration(cfg.RetentionJobDays) * 24 * time.Hour,
+		summaryAge:  time.Duration(cfg.RetentionSummaryDays) * 24 * time.Hour,
+	}
+	go ret.loop(bgCtx, elector)
+
+	// Wakes idle workers as soon as discovery queues new jobs
+	jobsQueued := make(chan struct{}, maxConcurrentProcessing)
+
+	// Start the document workers. They run on every instance, not just the
+	// leader: claims are exclusive in the database, so standbys help drain
+	// the queue.
+	var workers sync.WaitGroup
+	for i := range maxConcurrentProcessing {
+		workerID := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), i+1)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			proc.runWorker(bgCtx, workerID, jobsQueued)
+		}()
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+
+	// Start the discovery loop in a goroutine. It only scrapes and queues
+	// jobs, so a slow document never delays spotting the next one.
 	go func() {
 		defer func() {
 			done <- true
 		}()
 
+		// The first cycle that finds documents decides on the baseline
+		baselinePending := cfg.Baseline != baselineOff
+
+		// endCycle cancels the previous cycle's leader context
+		endCycle := func() {}
+		defer func() { endCycle() }()
+
 		for {
+			endCycle()
+
 			// bgCtx is cancelled by main() on shutdown. Watching it here
 			// (rather than shutdownChan, whose single signal is consumed by
 			// main's blocking receive) is what lets this loop actually exit.
@@ -295,9 +580,22 @@ func main() {
 				// Continue with normal processing
 			}
 
+			// Standby instances wait for leadership instead of scraping. A
+			// cycle stops as soon as leadership is lost, so a demoted
+			// instance never posts alongside the new leader.
+			leaderCtx, cancel, isLeader := elector.LeaderContext(bgCtx)
+			endCycle = cancel
+			if !isLeader {
+				log.WithContext("component", "main_cycle").Debug("Not the leader, standing by")
+				if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
+					return
+				}
+				continue
+			}
+
 			// Create a session context for this cycle. sessionID ties together all
-			// logs for one scrape cycle (cycle-level ops + all worker goroutines).
-			cycleCtx, _ := logger.NewSessionContextFrom(bgCtx)
+			// logs for one scrape cycle.
+			cycleCtx, _ := logger.NewSessionContextFrom(leaderCtx)
 			cycleLog := log.WithRequestContext(cycleCtx).WithContext("component", "main_cycle")
 
 			cycleLog.Info("Checking for new documents")
@@ -342,81 +640,114 @@ func main() {
 				continue
 			}
 
-			// Create a worker pool with limited concurrency
-			var wg sync.WaitGroup
-			semaphore := make(chan struct{}, maxConcurrentProcessing)
-
 			// Track skipped documents for a single summary log line. A slice
 			// (not a title-keyed map) so same-title documents with different
 			// URLs are each counted.
 			var skippedDocs []string
-
+			var newDocs []*scraper.Document
 			for _, doc := range docs {
-				// Skip already processed documents (checked before the recall
-				// handling so it covers recalled documents too)
 				if alreadyProcessed[storage.DocKey(doc.Title, doc.URL)] {
 					skippedDocs = append(skippedDocs, doc.Title)
 					continue
 				}
+				newDocs = append(newDocs, doc)
+			}
 
-				// Check if this is a recalled document by its title
-				if sc.IsRecalledDocument(*doc) {
-					cycleLog.Info("Detected recalled document from title", "document", doc.Title)
+			// Log skipped documents after the loop (if any)
+			if len(skippedDocs) > 0 {
+				cycleLog.Info("Skipping already processed document(s)", "count", len(skippedDocs), "documents", skippedDocs)
+			}
 
-					// Process recalled document specially
-					cycleLog.Info("Posting recalled document notice")
-					err := postRecalledDocumentNotice(cycleCtx, pstr, doc)
+			// On the first cycle, record the listing as seen instead of
+			// flooding a new account (or an empty database) with posts
+			if baselinePending && len(newDocs) > 0 {
+				baseline, err := shouldBaseline(cycleCtx, store, cfg.Baseline)
+				if err != nil {
+					cycleLog.Error("Error checking for baseline", "error", err)
+					cycleLog.Info("Sleeping before retrying", "seconds", cfg.ScrapeInterval)
+					if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
+						return
+					}
+					continue
+				}
+				if baseline {
+					found := newDocs
+					newDocs, err = applyBaseline(cycleCtx, store, cfg.Baseline, newDocs, cfg.BaselinePostNewest)
 					if err != nil {
-						cycleLog.Error("Error posting recalled document notice", "error", err)
-						// Skip marking as processed if posting the notice failed, allow retry next cycle
+						cycleLog.Error("Error recording baseline", "error", err)
+						cycleLog.Info("Sleeping before retrying", "seconds", cfg.ScrapeInterval)
+						if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
+							return
+						}
 						continue
 					}
-
-					// Mark as processed only if the notice was successfully posted
-					cycleLog.Info("Marking recalled document as processed")
-					err = store.AddProcessedDocument(cycleCtx, storage.ProcessedDocument{
-						Title:     doc.Title,
-						URL:       doc.URL,
-						Timestamp: doc.Published,
-					})
-					if err != nil {
-						cycleLog.Error("Error updating storage", "error", err)
+					var skipped []string
+					for _, doc := range found {
+						if !slices.Contains(newDocs, doc) {
+							skipped = append(skipped, doc.Title)
+						}
+					}
+					cycleLog.Warn("Baseline recorded; documents marked as seen without posting",
+						"mode", cfg.Baseline,
+						"skipped", len(skipped),
+						"documents", skipped,
+						"posting_newest", len(newDocs))
+				}
+			}
+			baselinePending = false
+
+			// A backlog larger than the threshold (typically after downtime)
+			// is handled by the catch-up policy instead of a burst of posts.
+			// Documents already in the queue are left to their jobs.
+			if len(newDocs) > cfg.CatchupThreshold {
+				queued, err := store.FilterQueued(cycleCtx, newDocs)
+				if err != nil {
+					cycleLog.Error("Error checking queued documents", "error", err)
+					cycleLog.Info("Sleeping before retrying", "seconds", cfg.ScrapeInterval)
+					if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
+						return
 					}
-
-					// Include in the skipped-documents summary log
-					skippedDocs = append(skippedDocs, doc.Title)
-
 					continue
 				}
+				var backlog []*scraper.Document
+				for _, doc := range newDocs {
+					if !queued[storage.DocKey(doc.Title, doc.URL)] {
+						backlog = append(backlog, doc)
+					}
+				}
 
-				// Limit concurrency using semaphore
-				semaphore <- struct{}{}
-				wg.Add(1)
-
-				go func(document *scraper.Document) {
-					defer wg.Done()
-					defer func() { <-semaphore }()
-
-					// Each goroutine gets its own requestID so its logs can be
-					// isolated from other concurrent workers. sessionID from
-					// cycleCtx is inherited, linking this back to the cycle.
-					docCtx, _ := logger.NewRequestContextFrom(cycleCtx)
-					docLog := log.WithRequestContext(docCtx).
-						WithContext("component", "document_processor")
-
-					docLog.Info("Processing new document", "title", document.Title)
-					processDocument(docCtx, document, sc, summarizer, pstr, store)
-				}(doc)
+				if len(backlog) > cfg.CatchupThreshold {
+					newDocs, err = proc.catchUp(cycleCtx, backlog, cfg.CatchupPolicy,
+						time.Duration(cfg.CatchupMaxAge)*time.Minute)
+					if err != nil {
+						cycleLog.Error("Error catching up on missed documents", "error", err)
+						cycleLog.Info("Sleeping before retrying", "seconds", cfg.ScrapeInterval)
+						if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
+							return
+						}
+						continue
+					}
+				}
 			}
 
-			// Log skipped documents after the loop (if any)
-			if len(skippedDocs) > 0 {
-				cycleLog.Info("Skipping already processed document(s)", "count", len(skippedDocs), "documents", skippedDocs)
+			// Queue the rest. Documents already in the queue (pending,
+			// backing off, running or dead-lettered) are left as they are;
+			// recalled documents are queued too and handled by the worker.
+			if len(newDocs) > 0 {
+				queued, err := store.EnqueueJobs(cycleCtx, newDocs)
+				if err != nil {
+					cycleLog.Error("Error queueing documents", "error", err)
+				} else if queued > 0 {
+					cycleLog.Info("Queued new document(s)", "count", queued)
+					for range min(queued, maxConcurrentProcessing) {
+						select {
+						case jobsQueued <- struct{}{}:
+						default:
+						}
+					}
+				}
 			}
 
-			// Wait for all goroutines to finish
-			wg.Wait()
-
 			cycleLog.Info("Sleeping before next check", "seconds", cfg.ScrapeInterval)
 			if !sleepOrShutdown(bgCtx, time.Duration(cfg.ScrapeInterval)*time.Second) {
 				return
@@ -445,139 +776,45 @@ func main() {
 
 	appLog.Info("Draining connections and cleaning up...")
 
-	// Wait for main loop to finish (with timeout)
+	// Wait for the discovery loop and workers to finish. One deadline covers
+	// every wait, so the whole drain takes at most 30 seconds.
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer drainCancel()
 	select {
 	case <-done:
-		appLog.Info("Main processing loop stopped gracefully")
-	case <-time.After(30 * time.Second):
+		appLog.Info("Discovery loop stopped gracefully")
+	case <-drainCtx.Done():
 		appLog.Warn("Shutdown timeout reached, forcing exit")
 	}
-
-	appLog.Info("Application shutdown complete",
-		"uptime", uptime.String(),
-		"final_goroutines", runtime.NumGoroutine(),
-	)
-}
-
-// processDocument handles all steps for a single document
-func processDocument(ctx context.Context, doc *scraper.Document, scraper *scraper.Scraper, summarizer *summary.Summarizer, poster *poster.Poster, store storage.StorageInterface) {
-	// Get logger from context for this document
-	docLog := log.WithRequestContext(ctx).
-		WithContext("component", "document_processor")
-
-	// Create a unique directory for this document
-	docDir := filepath.Join(tempDir, fmt.Sprintf("%d", time.Now().UnixNano()))
-	if err := os.MkdirAll(docDir, 0755); err != nil {
-		docLog.Error("Error creating directory for document", "error", err)
-		return
-	}
-	defer func(path string) {
-		err := os.RemoveAll(path)
-		if err != nil {
-			docLog.Error("Error removing directory for document", "error", err)
-		}
-	}(docDir) // Clean up when done
-
-	// Download the document
-	docLog.Debug("Downloading document")
-	pdfPath, err := scraper.DownloadDocument(ctx, *doc, docDir)
-	if err != nil {
-		// Check if this is a recalled document
-		if strings.Contains(err.Error(), "document has been recalled") ||
-			strings.Contains(err.Error(), "invalid PDF file (possibly recalled)") {
-			docLog.Info("Detected recalled document")
-
-			// Post a text-only message about the recalled document
-			docLog.Info("Posting recalled document notice")
-			err = postRecalledDocumentNotice(ctx, poster, doc)
-			if err != nil {
-				docLog.Error("Error posting recalled document notice", "error", err)
-				return
-			}
-
-			// Check database connection before updating
-			if !waitForDBConnection(ctx, store) {
-				return
-			}
-
-			// Mark as processed to avoid repeated attempts
-			docLog.Info("Marking recalled document as processed")
-			err = store.AddProcessedDocument(ctx, storage.ProcessedDocument{
-				Title:     doc.Title,
-				URL:       doc.URL,
-				Timestamp: doc.Published,
-			})
-			if err != nil {
-				docLog.Error("Error updating storage", "error", err)
-			}
-
-			return
-		}
-
-		docLog.Error("Error downloading document", "error", err)
-		return
-	}
-	docLog.Info("Downloaded Document")
-
-	// Generate AI summary of the document by calling Gemini
-	docLog.Debug("Generating AI summary")
-	aiSummary, err := summarizer.GenerateSummary(ctx, pdfPath)
-	if err != nil {
-		docLog.Error("Error generating summary", "error", err)
-		// Continue with posting even if summary generation fails
-	}
-
-	// Convert the PDF to images
-	docLog.Info("Converting PDF to images")
-	images, err := utils.ConvertToImages(ctx, pdfPath)
-	if err != nil {
-		docLog.Error("Error processing document", "error", err)
-		return
-	}
-
-	docLog.Info("Converted PDF to images", "pages", len(images))
-
-	// Ensure that URL is properly encoded
-	documentURL := utils.EncodeURL(doc.URL)
-
-	// Attempt to post with the new format
-	docLog.Info("Posting document to Threads")
-	err = poster.Post(ctx, images, doc.Title, doc.Published, documentURL, aiSummary)
-	if err != nil {
-		docLog.Error("Error posting to Threads", "error", err)
-		return
+	select {
+	case <-workersDone:
+		appLog.Info("Workers stopped gracefully")
+	case <-drainCtx.Done():
+		appLog.Warn("Shutdown timeout reached while waiting for workers, forcing exit")
 	}
 
-	docLog.Info("Successfully posted to Threads")
-
-	// Check database connection before updating
-	if !waitForDBConnection(ctx, store) {
-		docLog.Warn("Shutdown during DB write — document was posted but not recorded; may be re-posted on next start",
-			"title", doc.Title, "url", doc.URL)
-		return
+	// Shadow candidates were cancelled with bgCtx; wait for them to let go
+	// of storage and their PDF copies
+	shadowsDone := make(chan struct{})
+	go func() {
+		proc.shadows.Wait()
+		close(shadowsDone)
+	}()
+	select {
+	case <-shadowsDone:
+	case <-drainCtx.Done():
+		appLog.Warn("Shutdown timeout reached while stopping shadow candidates, forcing exit")
 	}
 
-	// Update storage after successful posting
-	docLog.Debug("Marking document as processed")
-	err = store.AddProcessedDocument(ctx, storage.ProcessedDocument{
-		Title:     doc.Title,
-		URL:       doc.URL,
-		Timestamp: doc.Published,
-	})
-	if err != nil {
-		docLog.Error("Error updating storage", "error", err)
+	// Wait for the elector to hand back the leader lock before storage closes
+	select {
+	case <-electorDone:
+	case <-drainCtx.Done():
+		appLog.Warn("Shutdown timeout reached while releasing leadership, forcing exit")
 	}
 
-	docLog.Info("Document processing complete")
-}
-
-// postRecalledDocumentNotice posts a text-only message about a recalled document
-func postRecalledDocumentNotice(ctx context.Context, poster *poster.Poster, doc *scraper.Document) error {
-	// Create a message about the recalled document
-	message := fmt.Sprintf("🚫 DOCUMENT RECALLED 🚫\n\nThe FIA has recalled the following document:\n\n%s\n\nPublished: %s\n\nThis document is no longer available.",
-		doc.Title,
-		doc.Published.Format("02-01-2006 15:04 MST"))
-
-	// Post a text-only message
-	return poster.PostTextOnly(ctx, message)
+	appLog.Info("Application shutdown complete",
+		"uptime", uptime.String(),
+		"final_goroutines", runtime.NumGoroutine(),
+	)
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"bot/pkg/logger"
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+	"bot/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	log = logger.New(logger.Config{Level: logger.LevelError})
+	shortRetryInterval = 10 * time.Millisecond
+	longRetryInterval = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+func TestWaitForDBConnectionRecovers(t *testing.T) {
+	store := storage.NewMemory()
+	store.SetConnectionError(errors.New("connection refused"))
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		store.SetConnectionError(nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !waitForDBConnection(ctx, store) {
+		t.Fatal("waitForDBConnection = false, want true once the outage ends")
+	}
+}
+
+func TestWaitForDBConnectionShutdown(t *testing.T) {
+	store := storage.NewMemory()
+	store.SetConnectionError(errors.New("connection refused"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if waitForDBConnection(ctx, store) {
+		t.Fatal("waitForDBConnection = true during an outage, want false on shutdown")
+	}
+}
+
+func TestProcessDocumentFailureSchedulesRetry(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := storage.NewMemory()
+	p := &processor{
+		scraper:     scraper.New(srv.URL),
+		store:       store,
+		alerter:     utils.NewAlertClient(""),
+		retryPolicy: storage.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour},
+	}
+	ctx := context.Background()
+	doc := &scraper.Document{Title: "Doc 7 - Summons", URL: srv.URL + "/doc7.pdf", Published: time.Now()}
+
+	if _, err := store.EnqueueJobs(ctx, []*scraper.Document{doc}); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		job, err := store.ClaimJob(ctx, "test", jobLease)
+		if err != nil || job == nil {
+			t.Fatalf("attempt %d: ClaimJob = %v, %v", attempt, job, err)
+		}
+		procErr := p.processDocument(ctx, job.Document(), nil)
+		if procErr == nil {
+			t.Fatalf("attempt %d: processDocument succeeded against a failing server", attempt)
+		}
+		p.finishJob(ctx, job, procErr)
+
+		// The backoff keeps the job out of reach until an operator or the
+		// clock intervenes, and the document is never marked processed
+		if next, _ := store.ClaimJob(ctx, "test", jobLease); next != nil {
+			t.Fatalf("attempt %d: job claimable again during backoff", attempt)
+		}
+		processed, _ := store.FilterProcessed(ctx, []*scraper.Document{doc})
+		if len(processed) != 0 {
+			t.Fatalf("attempt %d: failed document marked processed", attempt)
+		}
+		if attempt == 1 {
+			// Skip the backoff so the second attempt can run now
+			if err := store.FailJob(ctx, job.ID, time.Now(), false); err != nil {
+				t.Fatalf("FailJob: %v", err)
+			}
+		}
+	}
+
+	dead, err := store.ListDeadLetters(ctx)
+	if err != nil || len(dead) != 1 || dead[0].Attempts != 2 {
+		t.Fatalf("ListDeadLetters = %+v, %v; want the document dead-lettered after 2 attempts", dead, err)
+	}
+	counts, _ := store.CountJobs(ctx)
+	if counts[storage.JobDead] != 1 {
+		t.Errorf("CountJobs = %v, want 1 dead job", counts)
+	}
+}
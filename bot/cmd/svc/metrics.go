@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot/pkg/leader"
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+// metricsSnapshot is the state exported on /metrics
+type metricsSnapshot struct {
+	leader bool
+	ready  bool                      // whether the summarizer can summarize
+	jobs   map[storage.JobStatus]int // nil when the database is unreachable
+	models []summary.ModelStatus
+	usage  []storage.SummaryUsage // today's usage per model; nil when unavailable
+	budget float64                // daily budget in US dollars; 0 when unlimited
+}
+
+// registerMetrics serves /metrics in the Prometheus text format
+func registerMetrics(mux *http.ServeMux, store storage.StorageInterface, summarizer *summary.Summarizer, elector *leader.Elector) {
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		snap := metricsSnapshot{
+			leader: elector.Status().Leader,
+			ready:  summarizer.Readiness().Ready,
+			models: summarizer.Status(),
+		}
+		if counts, err := store.CountJobs(r.Context()); err == nil {
+			snap.jobs = counts
+		}
+		if usage, err := store.DailySummaryUsage(r.Context(), summary.Day(time.Now())); err == nil {
+			snap.usage = usage
+		}
+		if _, budget, err := summarizer.Spending(r.Context()); err == nil {
+			snap.budget = budget
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, snap)
+	})
+}
+
+// breakerStates are the states exported per model, one series each
+var breakerStates = []summary.BreakerState{summary.BreakerClosed, summary.BreakerOpen, summary.BreakerHalfOpen}
+
+// writeMetrics writes snap in the Prometheus text format
+func writeMetrics(w io.Writer, snap metricsSnapshot) {
+	leaderValue := 0
+	if snap.leader {
+		leaderValue = 1
+	}
+	_, _ = fmt.Fprintf(w, "# HELP fia_bot_leader Whether this instance is the leader.\n# TYPE fia_bot_leader gauge\nfia_bot_leader %d\n", leaderValue)
+
+	readyValue := 0
+	if snap.ready {
+		readyValue = 1
+	}
+	_, _ = fmt.Fprintf(w, "# HELP fia_bot_summary_ready Whether a summarization model is reachable.\n# TYPE fia_bot_summary_ready gauge\nfia_bot_summary_ready %d\n", readyValue)
+
+	if snap.jobs != nil {
+		_, _ = fmt.Fprint(w, "# HELP fia_bot_jobs Document jobs by status.\n# TYPE fia_bot_jobs gauge\n")
+		for _, status := range []storage.JobStatus{storage.JobPending, storage.JobRunning, storage.JobDone, storage.JobDead} {
+			_, _ = fmt.Fprintf(w, "fia_bot_jobs{status=%q} %d\n", status, snap.jobs[status])
+		}
+	}
+
+	_, _ = fmt.Fprint(w, "# HELP fia_bot_summary_breaker_state Circuit breaker state per summarization model (1 for the current state).\n# TYPE fia_bot_summary_breaker_state gauge\n")
+	for _, m := range snap.models {
+		for _, state := range breakerStates {
+			value := 0
+			if m.State == state {
+				value = 1
+			}
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_breaker_state{model=\"%s\",state=%q} %d\n", labelValue(m.Model), state, value)
+		}
+	}
+
+	_, _ = fmt.Fprint(w, "# HELP fia_bot_summary_breaker_failures Consecutive failures per summarization model.\n# TYPE fia_bot_summary_breaker_failures gauge\n")
+	for _, m := range snap.models {
+		_, _ = fmt.Fprintf(w, "fia_bot_summary_breaker_failures{model=\"%s\"} %d\n", labelValue(m.Model), m.Failures)
+	}
+
+	_, _ = fmt.Fprint(w, "# HELP fia_bot_summary_errors_total Failed summarization calls per model and error kind.\n# TYPE fia_bot_summary_errors_total counter\n")
+	for _, m := range snap.models {
+		for _, kind := range summary.ErrorKinds {
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_errors_total{model=\"%s\",kind=%q} %d\n", labelValue(m.Model), kind, m.Errors[kind])
+		}
+	}
+
+	if snap.usage != nil {
+		_, _ = fmt.Fprint(w, "# HELP fia_bot_summary_cost_today_usd Summarization cost per model on the current UTC day.\n# TYPE fia_bot_summary_cost_today_usd gauge\n")
+		for _, u := range snap.usage {
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_cost_today_usd{model=\"%s\"} %g\n", labelValue(u.Model), u.Cost)
+		}
+		_, _ = fmt.Fprint(w, "# HELP fia_bot_summary_tokens_today Summarization tokens per model and type on the current UTC day; output includes thinking.\n# TYPE fia_bot_summary_tokens_today gauge\n")
+		for _, u := range snap.usage {
+			model := labelValue(u.Model)
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_tokens_today{model=\"%s\",type=\"input\"} %d\n", model, u.InputTokens)
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_tokens_today{model=\"%s\",type=\"output\"} %d\n", model, u.OutputTokens)
+			_, _ = fmt.Fprintf(w, "fia_bot_summary_tokens_today{model=\"%s\",type=\"thinking\"} %d\n", model, u.ThinkingTokens)
+		}
+	}
+	if snap.budget > 0 {
+		_, _ = fmt.Fprintf(w, "# HELP fia_bot_summary_budget_usd Daily summarization budget.\n# TYPE fia_bot_summary_budget_usd gauge\nfia_bot_summary_budget_usd %g\n", snap.budget)
+	}
+}
+
+// labelValue escapes a Prometheus label value
+func labelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	var out strings.Builder
+	writeMetrics(&out, metricsSnapshot{
+		leader: true,
+		ready:  true,
+		jobs:   map[storage.JobStatus]int{storage.JobPending: 2, storage.JobDead: 1},
+		models: []summary.ModelStatus{
+			{Model: "vertex/gemini-2.5-flash:thinking", State: summary.BreakerOpen, Failures: 3,
+				Errors: map[summary.ErrorKind]int{summary.KindRateLimit: 2, summary.KindQuota: 1}},
+			{Model: "local/llama3.1:8b", State: summary.BreakerClosed},
+		},
+		usage: []storage.SummaryUsage{
+			{Day: "2026-03-08", Model: "vertex/gemini-2.5-flash", Calls: 4, InputTokens: 9000, OutputTokens: 700, ThinkingTokens: 500, Cost: 0.0125},
+		},
+		budget: 2.5,
+	})
+
+	for _, want := range []string{
+		"fia_bot_leader 1\n",
+		"fia_bot_summary_ready 1\n",
+		`fia_bot_jobs{status="pending"} 2` + "\n",
+		`fia_bot_jobs{status="dead"} 1` + "\n",
+		`fia_bot_summary_breaker_state{model="vertex/gemini-2.5-flash:thinking",state="open"} 1` + "\n",
+		`fia_bot_summary_breaker_state{model="vertex/gemini-2.5-flash:thinking",state="closed"} 0` + "\n",
+		`fia_bot_summary_breaker_state{model="local/llama3.1:8b",state="closed"} 1` + "\n",
+		`fia_bot_summary_breaker_failures{model="vertex/gemini-2.5-flash:thinking"} 3` + "\n",
+		`fia_bot_summary_errors_total{model="vertex/gemini-2.5-flash:thinking",kind="rate_limit"} 2` + "\n",
+		`fia_bot_summary_errors_total{model="local/llama3.1:8b",kind="safety"} 0` + "\n",
+		"# TYPE fia_bot_summary_errors_total counter\n",
+		`fia_bot_summary_cost_today_usd{model="vertex/gemini-2.5-flash"} 0.0125` + "\n",
+		`fia_bot_summary_tokens_today{model="vertex/gemini-2.5-flash",type="input"} 9000` + "\n",
+		`fia_bot_summary_tokens_today{model="vertex/gemini-2.5-flash",type="thinking"} 500` + "\n",
+		"fia_bot_summary_budget_usd 2.5\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bot/pkg/logger"
+	"bot/pkg/poster"
+	"bot/pkg/scraper"
+	"bot/pkg/sequencer"
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+	"bot/pkg/utils"
+)
+
+const (
+	jobLease        = 30 * time.Minute // A running job is reclaimed after this long without renewal (its worker died)
+	jobPollInterval = 5 * time.Second  // How often an idle worker checks the queue
+	jobReleaseGrace = 5 * time.Second  // Time allowed for job bookkeeping after shutdown
+)
+
+const recordPostAttempts = 3 // Attempts at recording a published post before failing the job
+
+// jobLeaseRenewal is how often a worker renews the lease of its running
+// job; a variable so tests can shorten it
+var jobLeaseRenewal = 5 * time.Minute
+
+// recordPostRetryInterval is the wait between attempts at recording a
+// published post; a variable so tests can shorten it
+var recordPostRetryInterval = 2 * time.Second
+
+// processor holds everything a document needs on its way from the FIA
+// listing to every enabled platform
+type processor struct {
+	scraper     *scraper.Scraper
+	summarizer  *summary.Summarizer
+	poster      *poster.Poster
+	store       storage.StorageInterface
+	alerter     *utils.AlertClient
+	retryPolicy storage.RetryPolicy
+
+	// sequencer orders publishing (nil publishes as soon as ready) and
+	// publishOrder selects its key: "published" or "number"
+	sequencer    *sequencer.Sequencer
+	publishOrder string
+
+	// archiveDir keeps a copy of every posted PDF (empty disables archiving)
+	archiveDir string
+
+	// shadows tracks shadow candidate runs in the background, which run on
+	// shadowCtx (cancelled on shutdown) rather than the job's context.
+	// shadowSlots bounds how many documents run them at once.
+	shadows     sync.WaitGroup
+	shadowCtx   context.Context
+	shadowSlots chan struct{}
+}
+
+// Publishing orders (PUBLISH_ORDER)
+const (
+	publishOrderNone      = "none"      // publish as soon as a document is ready
+	publishOrderPublished = "published" // FIA publication time
+	publishOrderNumber    = "number"    // FIA document number ("Doc 12")
+)
+
+// orderKey returns the job's position in the publishing sequence. Documents
+// without a number sort after numbered ones, by publication time.
+func (p *processor) orderKey(job *storage.Job) sequencer.Key {
+	if p.publishOrder == publishOrderNumber {
+		major := int64(math.MaxInt64)
+		if n, ok := scraper.DocumentNumber(job.Title); ok {
+			major = int64(n)
+		}
+		return sequencer.Key{Major: major, Minor: job.Published.UnixNano()}
+	}
+	return sequencer.Key{Major: job.Published.UnixNano(), Minor: job.ID}
+}
+
+// runWorker claims and processes queued jobs until ctx is cancelled. An idle
+// worker polls every jobPollInterval, or sooner when discovery signals wake.
+func (p *processor) runWorker(ctx context.Context, workerID string, wake <-chan struct{}) {
+	workerLog := log.WithContext("component", "worker").WithContext("worker_id", workerID)
+	workerLog.Debug("Worker started")
+
+	for {
+		if !waitForDBConnection(ctx, p.store) {
+			return
+		}
+
+		job, err := p.store.ClaimJob(ctx, workerID, jobLease)
+		if err != nil && ctx.Err() == nil {
+			workerLog.Error("Error claiming job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-wake:
+			case <-time.After(jobPollInterval):
+			case <-ctx.Done():
+				workerLog.Debug("Worker shutting down")
+				return
+			}
+			continue
+		}
+
+		// Each job gets its own requestID so its logs can be isolated from
+		// other concurrent workers
+		docCtx, _ := logger.NewRequestContextFrom(ctx)
+		docLog := log.WithRequestContext(docCtx).
+			WithContext("component", "document_processor")
+
+		docLog.Info("Processing new document", "title", job.Title, "job_id", job.ID, "worker_id", workerID)
+		jobCtx, cancelJob := context.WithCancel(docCtx)
+		leaseLost := p.renewLease(jobCtx, cancelJob, job, workerID)
+		ticket := p.sequencer.Enter(p.orderKey(job))
+		if job.Digest != nil {
+			err = p.processDigest(jobCtx, job, ticket)
+		} else {
+			err = p.processDocument(jobCtx, job.Document(), ticket)
+		}
+		ticket.Done()
+		cancelJob()
+
+		// Another worker owns the job now; its outcome is theirs to record
+		if leaseLost() {
+			docLog.Warn("Job lease lost to another worker, leaving the job to it", "job_id", job.ID, "error", err)
+			continue
+		}
+		p.finishJob(docCtx, job, err)
+	}
+}
+
+// renewLease renews the lease of job every jobLeaseRenewal until ctx is
+// done, so a slow document is not reclaimed by another worker while it is
+// still being processed. If the job turns out to be reclaimed anyway (this
+// worker stalled past its lease), cancel stops the processing. The returned
+// function waits for the renewals to stop and reports whether the lease was
+// lost.
+func (p *processor) renewLease(ctx context.Context, cancel context.CancelFunc, job *storage.Job, workerID string) func() bool {
+	leaseLog := log.WithRequestContext(ctx).
+		WithContext("component", "worker").
+		WithContext("job_id", job.ID)
+
+	lost := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(jobLeaseRenewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			held, err := p.store.RenewJob(ctx, job.ID, workerID)
+			if err != nil {
+				// The lease may still be valid; try again next tick
+				if ctx.Err() == nil {
+					leaseLog.Warn("Error renewing job lease", "error", err)
+				}
+				continue
+			}
+			if !held {
+				leaseLog.Error("Job reclaimed by another worker, stopping", "title", job.Title)
+				lost = true
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() bool {
+		<-done
+		return lost
+	}
+}
+
+// finishJob records the outcome of a processing attempt: success completes
+// the job and clears earlier failures, failure schedules a backoff retry and
+// raises an alert once the document is dead-lettered.
+func (p *processor) finishJob(ctx context.Context, job *storage.Job, procErr error) {
+	jobLog := log.WithRequestContext(ctx).
+		WithContext("component", "retry_tracker").
+		WithContext("job_id", job.ID)
+
+	// Bookkeeping must land even when shutdown cancelled ctx mid-document,
+	// otherwise a posted document's job would be reclaimed and re-posted.
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobReleaseGrace)
+	defer cancel()
+
+	if procErr == nil {
+		if err := p.store.CompleteJob(storeCtx, job.ID); err != nil {
+			jobLog.Error("Error completing job", "title", job.Title, "error", err)
+		}
+		if err := p.store.ClearFailure(storeCtx, job.Title, job.URL); err != nil {
+			jobLog.Error("Error clearing document failure", "title", job.Title, "error", err)
+		}
+		return
+	}
+
+	// A shutdown mid-processing is not the document's fault: hand the job
+	// back so the next worker (here or on another instance) picks it up.
+	if ctx.Err() != nil {
+		if err := p.store.FailJob(storeCtx, job.ID, time.Now(), false); err != nil {
+			jobLog.Warn("Error releasing job on shutdown; it will be reclaimed after its lease", "error", err)
+		}
+		return
+	}
+
+	failure, err := p.store.RecordFailure(ctx, storage.ProcessedDocument{
+		Title:     job.Title,
+		URL:       job.URL,
+		Timestamp: job.Published,
+	}, procErr.Error(), p.retryPolicy)
+	if err != nil {
+		// The job stays running and is reclaimed once its lease expires
+		jobLog.Error("Error recording document failure", "title", job.Title, "error", err)
+		return
+	}
+
+	if err := p.store.FailJob(ctx, job.ID, failure.NextAttemptAt, failure.DeadLettered); err != nil {
+		jobLog.Error("Error rescheduling job", "title", job.Title, "error", err)
+	}
+
+	if !failure.DeadLettered {
+		jobLog.Warn("Document failed, retry scheduled",
+			"title", job.Title,
+			"attempts", failure.Attempts,
+			"next_attempt_at", failure.NextAttemptAt)
+		return
+	}
+
+	jobLog.Error("Document dead-lettered after repeated failures",
+		"title", job.Title,
+		"url", job.URL,
+		"attempts", failure.Attempts,
+		"last_error", failure.LastError)
+	alert := fmt.Sprintf("Document dead-lettered after %d attempts: %s\n%s\nLast error: %s",
+		failure.Attempts, job.Title, job.URL, failure.LastError)
+	if err := p.alerter.Send(ctx, alert); err != nil {
+		jobLog.Error("Error sending dead-letter alert", "error", err)
+	}
+}
+
+// processDocument handles all steps for a single document. It returns an
+// error only when the document was not published and should be retried;
+// failures after a successful post are logged, since retrying would post the
+// document twice. Everything up to publishing runs freely; publishing waits
+// for the document's turn in ticket's sequence.
+func (p *processor) processDocument(ctx context.Context, doc *scraper.Document, ticket *sequencer.Ticket) error {
+	// Get logger from context for this document
+	docLog := log.WithRequestContext(ctx).
+		WithContext("component", "document_processor")
+
+	// Create a unique directory for this document
+	docDir := filepath.Join(tempDir, fmt.Sprintf("%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(docDir, 0755); err != nil {
+		docLog.Error("Error creating directory for document", "error", err)
+		return fmt.Errorf("error creating directory for document: %w", err)
+	}
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		if err != nil {
+			docLog.Error("Error removing directory for document", "error", err)
+		}
+	}(docDir) // Clean up when done
+
+	// Download the document
+	docLog.Debug("Downloading document")
+	pdfPath, err := p.scraper.DownloadDocument(ctx, *doc, docDir)
+	if err != nil {
+		// Check if this is a recalled document
+		if strings.Contains(err.Error(), "document has been recalled") ||
+			strings.Contains(err.Error(), "invalid PDF file (possibly recalled)") {
+			docLog.Info("Detected recalled document")
+
+			if err := waitTurn(ctx, ticket); err != nil {
+				return err
+			}
+
+			if processed, err := p.isProcessed(ctx, doc); err != nil {
+				docLog.Error("Error checking processed state", "error", err)
+				return err
+			} else if processed {
+				docLog.Warn("Recalled document notice already posted by another worker")
+				return nil
+			}
+
+			// Post a text-only message about the recalled document
+			docLog.Info("Posting recalled document notice")
+			postID, err := p.postRecalledDocumentNotice(ctx, doc)
+			if err != nil {
+				docLog.Error("Error posting recalled document notice", "error", err)
+				return fmt.Errorf("error posting recalled document notice: %w", err)
+			}
+
+			// Check database connection before updating
+			if !waitForDBConnection(ctx, p.store) {
+				return nil
+			}
+
+			// Mark as processed to avoid repeated attempts
+			docLog.Info("Marking recalled document as processed")
+			err = p.store.AddProcessedDocument(ctx, storage.ProcessedDocument{
+				Title:     doc.Title,
+				URL:       doc.URL,
+				Timestamp: doc.Published,
+				PostID:    postID,
+			})
+			if err != nil {
+				docLog.Error("Error updating storage", "error", err)
+			}
+
+			return nil
+		}
+
+		docLog.Error("Error downloading document", "error", err)
+		return fmt.Errorf("error downloading document: %w", err)
+	}
+	docLog.Info("Downloaded Document")
+
+	// The hash identifies the exact PDF that was posted (FIA re-uploads
+	// corrected documents under the same title)
+	pdfHash, err := utils.HashFile(pdfPath)
+	if err != nil {
+		docLog.Warn("Error hashing document", "error", err)
+	}
+	if p.archiveDir != "" && pdfHash != "" {
+		if err := archivePDF(p.archiveDir, pdfHash, pdfPath); err != nil {
+			docLog.Warn("Error archiving document", "error", err)
+		}
+	}
+
+	// Page text is kept for search and context until it is past retention
+	pageText, err := utils.ExtractText(ctx, pdfPath)
+	if err != nil {
+		docLog.Warn("Error extracting document text", "error", err)
+	}
+
+	// Generate AI summary of the document by calling Gemini
+	docLog.Debug("Generating AI summary")
+	summaryStart := time.Now()
+	summaryDoc := p.summaryDocument(doc)
+	summaryDoc.Text = pageText
+	aiSummary, err := p.summarizer.GenerateSummary(ctx, pdfPath, summaryDoc)
+	production := shadowRun{result: aiSummary, err: err, elapsed: time.Since(summaryStart)}
+	var rejected *summary.RejectedError
+	if errors.As(err, &rejected) {
+		// Post without the summary and hold it for review; the tokens were
+		// still spent
+		docLog.Warn("Summary failed the guardrails, posting without it", "model", rejected.Result.Model, "reason", rejected.Reason)
+		aiSummary.Input, aiSummary.Usage = rejected.Result.Input, rejected.Result.Usage
+		aiSummary.Related = rejected.Result.Related
+		production.result = rejected.Result
+	} else if errors.Is(err, summary.ErrSkipped) {
+		docLog.Info("Route skips summaries of this document, posting without one")
+	} else if errors.Is(err, summary.ErrBudgetExhausted) {
+		docLog.Warn("Daily summarization budget spent, posting without a summary")
+	} else if err != nil {
+		docLog.Error("Error generating summary", "error", err)
+		// Continue with posting even if summary generation fails
+	}
+
+	// Convert the PDF to images
+	docLog.Info("Converting PDF to images")
+	images, err := utils.ConvertToImages(ctx, pdfPath)
+	if err != nil {
+		docLog.Error("Error processing document", "error", err)
+		return fmt.Errorf("error converting document to images: %w", err)
+	}
+
+	docLog.Info("Converted PDF to images", "pages", len(images))
+
+	// Ensure that URL is properly encoded
+	documentURL := utils.EncodeURL(doc.URL)
+
+	// Upload images and format the post while other documents do the same
+	docLog.Info("Preparing post")
+	prepared, err := p.poster.Prepare(ctx, images, doc.Title, doc.Published, documentURL, aiSummary.Text, postTranslations(aiSummary.Translations))
+	if err != nil {
+		docLog.Error("Error preparing post", "error", err)
+		return fmt.Errorf("error preparing post: %w", err)
+	}
+
+	// Record the uploads right away so retention can remove them even if
+	// publishing fails
+	if err := p.store.AddHostedImages(ctx, hostedImages(doc, prepared.Images())); err != nil {
+		docLog.Warn("Error recording hosted images", "error", err)
+	}
+
+	if err := waitTurn(ctx, ticket); err != nil {
+		return err
+	}
+
+	// Another worker may have posted the document while this one prepared
+	// it (e.g. after reclaiming a stalled job)
+	if processed, err := p.isProcessed(ctx, doc); err != nil {
+		docLog.Error("Error checking processed state", "error", err)
+		return err
+	} else if processed {
+		docLog.Warn("Document already posted by another worker, not publishing it again")
+		return nil
+	}
+
+	// Platforms that published the document on an earlier attempt are
+	// skipped, so a retry only posts where it failed
+	published, err := p.publishedPosts(ctx, doc)
+	if err != nil {
+		docLog.Error("Error listing platform posts", "error", err)
+		return err
+	}
+
+	docLog.Info("Publishing document", "platforms", strings.Join(p.poster.Platforms(), ","))
+	postID, err := p.recordPosts(ctx, doc, p.poster.Publish(ctx, prepared, published), published)
+	if err != nil {
+		docLog.Error("Error publishing document", "error", err)
+		return err
+	}
+
+	docLog.Info("Successfully published document")
+
+	// Check database connection before updating
+	if !waitForDBConnection(ctx, p.store) {
+		docLog.Warn("Shutdown during DB write — document was posted but not recorded as processed",
+			"title", doc.Title, "url", doc.URL)
+		return nil
+	}
+
+	// Update storage after successful posting
+	docLog.Debug("Marking document as processed")
+	err = p.store.AddProcessedDocument(ctx, storage.ProcessedDocument{
+		Title:     doc.Title,
+		URL:       doc.URL,
+		Timestamp: doc.Published,
+		PostID:    postID,
+		PDFHash:   pdfHash,
+		Summary:   aiSummary.Text,
+		PageText:  pageText,
+
+		PromptVersion: aiSummary.PromptVersion,
+		SummaryInput:  aiSummary.Input,
+		InputTokens:   aiSummary.Usage.InputTokens,
+		OutputTokens:  aiSummary.Usage.OutputTokens,
+
+		RelatedDocuments: aiSummary.Related,
+	})
+	if err != nil {
+		docLog.Error("Error updating storage", "error", err)
+	}
+
+	if rejected != nil {
+		err = p.store.AddSummaryReview(ctx, storage.SummaryReview{
+			Title:         doc.Title,
+			URL:           doc.URL,
+			PostID:        postID,
+			Model:         rejected.Result.Model,
+			PromptVersion: rejected.Result.PromptVersion,
+			Summary:       rejected.Result.Text,
+			Reason:        rejected.Reason,
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			docLog.Error("Error holding summary for review", "error", err)
+		}
+	}
+
+	// Shadow candidates run once the post is out, so they never delay it
+	if !errors.Is(production.err, summary.ErrSkipped) {
+		p.startShadows(ctx, pdfPath, doc, summaryDoc, production)
+	}
+
+	docLog.Info("Document processing complete")
+	return nil
+}
+
+// summaryDocument describes doc for prompt selection and the usage ledger
+func (p *processor) summaryDocument(doc *scraper.Document) summary.Document {
+	return summary.Document{
+		Title:  doc.Title,
+		URL:    doc.URL,
+		Type:   scraper.DocumentType(doc.Title),
+		Series: p.scraper.Series(),
+	}
+}
+
+// hostedImages describes a document's uploaded images for storage
+func hostedImages(doc *scraper.Document, images []utils.UploadedImage) []storage.HostedImage {
+	now := time.Now().UTC()
+	hosted := make([]storage.HostedImage, 0, len(images))
+	for _, img := range images {
+		hosted = append(hosted, storage.HostedImage{
+			ImageID:    img.ID,
+			URL:        img.URL,
+			DeleteKey:  img.DeleteKey,
+			Title:      doc.Title,
+			DocURL:     doc.URL,
+			UploadedAt: now,
+		})
+	}
+	return hosted
+}
+
+// waitTurn waits until the document may publish. Only shutdown is an error:
+// a timed-out wait publishes out of order rather than not at all.
+func waitTurn(ctx context.Context, ticket *sequencer.Ticket) error {
+	if _, err := ticket.Wait(ctx); err != nil {
+		return fmt.Errorf("shutdown while waiting to publish: %w", err)
+	}
+	return nil
+}
+
+// postTranslations converts translated summaries for the poster
+func postTranslations(translations []summary.Translation) []poster.Translation {
+	var out []poster.Translation
+	for _, t := range translations {
+		out = append(out, poster.Translation{Language: t.Language, Text: t.Text})
+	}
+	return out
+}
+
+// postRecalledDocumentNotice posts a text-only message about a recalled
+// document on every platform and returns the post ID, as recordPosts
+func (p *processor) postRecalledDocumentNotice(ctx context.Context, doc *scraper.Document) (string, error) {
+	// Create a message about the recalled document
+	message := fmt.Sprintf("🚫 DOCUMENT RECALLED 🚫\n\nThe FIA has recalled the following document:\n\n%s\n\nPublished: %s\n\nThis document is no longer available.",
+		doc.Title,
+		doc.Published.Format("02-01-2006 15:04 MST"))
+
+	published, err := p.publishedPosts(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	return p.recordPosts(ctx, doc, p.poster.PublishText(ctx, message, published), published)
+}
+
+// isProcessed reports whether doc is already recorded as processed
+func (p *processor) isProcessed(ctx context.Context, doc *scraper.Document) (bool, error) {
+	processed, err := p.store.FilterProcessed(ctx, []*scraper.Document{doc})
+	if err != nil {
+		return false, fmt.Errorf("error checking processed documents: %w", err)
+	}
+	return processed[storage.DocKey(doc.Title, doc.URL)], nil
+}
+
+// publishedPosts returns the posts of doc already published, by platform
+func (p *processor) publishedPosts(ctx context.Context, doc *scraper.Document) (map[string]string, error) {
+	posts, err := p.store.ListPlatformPosts(ctx, doc.Title, doc.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error listing platform posts: %w", err)
+	}
+	published := make(map[string]string, len(posts))
+	for _, post := range posts {
+		published[post.Platform] = post.PostID
+	}
+	return published, nil
+}
+
+// recordPosts records the platforms that published doc and returns the post
+// ID of the first enabled platform that has it, the one kept with the
+// processed document. The error names the platforms that failed, so the job
+// is retried for them only. A post that cannot be recorded after
+// recordPostAttempts also fails the job: its platform is posted again on
+// retry, which is preferred to losing track of the document.
+func (p *processor) recordPosts(ctx context.Context, doc *scraper.Document, results []poster.Result, published map[string]string) (string, error) {
+	var failed, unrecorded []string
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r.Platform)
+			errs = append(errs, r.Err)
+			continue
+		}
+		published[r.Platform] = r.PostID
+		err := p.recordPost(ctx, storage.PlatformPost{
+			Title:    doc.Title,
+			URL:      doc.URL,
+			Platform: r.Platform,
+			PostID:   r.PostID,
+			PostedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			unrecorded = append(unrecorded, r.Platform)
+			errs = append(errs, err)
+		}
+	}
+	switch {
+	case len(failed) > 0 && len(unrecorded) > 0:
+		return "", fmt.Errorf("error publishing to %s and recording %s: %w",
+			strings.Join(failed, ", "), strings.Join(unrecorded, ", "), errors.Join(errs...))
+	case len(failed) > 0:
+		return "", fmt.Errorf("error publishing to %s: %w", strings.Join(failed, ", "), errors.Join(errs...))
+	case len(unrecorded) > 0:
+		return "", fmt.Errorf("error recording posts on %s: %w", strings.Join(unrecorded, ", "), errors.Join(errs...))
+	}
+
+	for _, platform := range p.poster.Platforms() {
+		if postID, ok := published[platform]; ok {
+			return postID, nil
+		}
+	}
+	return "", nil
+}
+
+// recordPost adds post to the store, retrying recordPostAttempts times since
+// the post itself cannot be undone
+func (p *processor) recordPost(ctx context.Context, post storage.PlatformPost) error {
+	docLog := log.WithRequestContext(ctx).
+		WithContext("method", "recordPost").
+		WithContext("document", post.Title).
+		WithContext("platform", post.Platform)
+
+	var err error
+	for attempt := 1; attempt <= recordPostAttempts; attempt++ {
+		if err = p.store.AddPlatformPost(ctx, post); err == nil {
+			return nil
+		}
+		docLog.Warn("Error recording platform post", "attempt", attempt, "error", err)
+		if attempt < recordPostAttempts && !sleepOrShutdown(ctx, recordPostRetryInterval) {
+			break
+		}
+	}
+	docLog.Error("Platform post not recorded, it will be posted again on retry", "post_id", post.PostID, "error", err)
+	return fmt.Errorf("error recording %s post %s: %w", post.Platform, post.PostID, err)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bot/pkg/poster"
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+)
+
+func TestOrderKey(t *testing.T) {
+	base := time.Date(2026, 6, 14, 15, 0, 0, 0, time.UTC)
+	summons := &storage.Job{ID: 2, Title: "Doc 30 - Summons - Car 4", Published: base.Add(time.Minute)}
+	decision := &storage.Job{ID: 1, Title: "Doc 31 - Decision - Car 4", Published: base}
+	notes := &storage.Job{ID: 3, Title: "Event Notes", Published: base.Add(-time.Hour)}
+
+	tests := []struct {
+		name        string
+		order       string
+		first, then *storage.Job
+	}{
+		{"published time", publishOrderPublished, decision, summons},
+		{"document number", publishOrderNumber, summons, decision},
+		{"numbered before unnumbered", publishOrderNumber, decision, notes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &processor{publishOrder: tt.order}
+			if !p.orderKey(tt.first).Less(p.orderKey(tt.then)) {
+				t.Errorf("%q does not sort before %q", tt.first.Title, tt.then.Title)
+			}
+		})
+	}
+}
+
+// stubPublisher publishes text posts, or fails with err
+type stubPublisher struct {
+	platform string
+	err      error
+	posts    int
+}
+
+func (s *stubPublisher) Platform() string { return s.platform }
+
+func (s *stubPublisher) Capabilities() poster.Capabilities {
+	return poster.Capabilities{MaxChars: 300, MaxImages: 4}
+}
+
+func (s *stubPublisher) Publish(ctx context.Context, post poster.Post) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	s.posts++
+	return s.platform + "-post", nil
+}
+
+func TestPostRecalledDocumentNoticePerPlatform(t *testing.T) {
+	ctx := context.Background()
+	first := &stubPublisher{platform: "first", err: errors.New("unavailable")}
+	second := &stubPublisher{platform: "second"}
+	p := &processor{
+		poster: poster.New("", "", "", "", first, second),
+		store:  storage.NewMemory(),
+	}
+	doc := &scraper.Document{Title: "Doc 12", URL: "https://fia.example/doc12.pdf", Published: time.Now()}
+
+	if _, err := p.postRecalledDocumentNotice(ctx, doc); err == nil {
+		t.Fatal("postRecalledDocumentNotice succeeded with a platform failing")
+	}
+	posts, err := p.store.ListPlatformPosts(ctx, doc.Title, doc.URL)
+	if err != nil || len(posts) != 1 || posts[0].Platform != "second" {
+		t.Fatalf("platform posts = %+v, %v, want second only", posts, err)
+	}
+
+	// The retry posts on the failed platform only and returns the post ID
+	// of the first platform
+	first.err = nil
+	postID, err := p.postRecalledDocumentNotice(ctx, doc)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if postID != "first-post" || first.posts != 1 || second.posts != 1 {
+		t.Errorf("post ID %q, posts %d and %d, want first-post and one post each", postID, first.posts, second.posts)
+	}
+}
+
+// flakyPostStore fails AddPlatformPost the given number of times
+type flakyPostStore struct {
+	storage.StorageInterface
+	failures int
+}
+
+func (s *flakyPostStore) AddPlatformPost(ctx context.Context, post storage.PlatformPost) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("database is locked")
+	}
+	return s.StorageInterface.AddPlatformPost(ctx, post)
+}
+
+func TestRecordPostsRetriesStore(t *testing.T) {
+	defer func(d time.Duration) { recordPostRetryInterval = d }(recordPostRetryInterval)
+	recordPostRetryInterval = time.Millisecond
+
+	ctx := context.Background()
+	pub := &stubPublisher{platform: "first"}
+	doc := &scraper.Document{Title: "Doc 14", URL: "https://fia.example/doc14.pdf", Published: time.Now()}
+	results := []poster.Result{{Platform: "first", PostID: "first-post"}}
+
+	// A transient failure is retried
+	store := &flakyPostStore{StorageInterface: storage.NewMemory(), failures: recordPostAttempts - 1}
+	p := &processor{poster: poster.New("", "", "", "", pub), store: store}
+	if postID, err := p.recordPosts(ctx, doc, results, map[string]string{}); err != nil || postID != "first-post" {
+		t.Fatalf("recordPosts = %q, %v; want first-post", postID, err)
+	}
+	if posts, err := store.ListPlatformPosts(ctx, doc.Title, doc.URL); err != nil || len(posts) != 1 {
+		t.Fatalf("platform posts = %+v, %v; want one", posts, err)
+	}
+
+	// A post that cannot be recorded fails the job
+	store = &flakyPostStore{StorageInterface: storage.NewMemory(), failures: recordPostAttempts}
+	p.store = store
+	if _, err := p.recordPosts(ctx, doc, results, map[string]string{}); err == nil {
+		t.Fatal("recordPosts succeeded without recording the post")
+	}
+	if posts, err := store.ListPlatformPosts(ctx, doc.Title, doc.URL); err != nil || len(posts) != 0 {
+		t.Fatalf("platform posts = %+v, %v; want none", posts, err)
+	}
+}
+
+func TestRenewLease(t *testing.T) {
+	defer func(d time.Duration) { jobLeaseRenewal = d }(jobLeaseRenewal)
+	jobLeaseRenewal = 10 * time.Millisecond
+
+	ctx := context.Background()
+	store := storage.NewMemory()
+	p := &processor{store: store}
+	if _, err := store.EnqueueJobs(ctx, []*scraper.Document{{Title: "Doc 1", URL: "u1", Published: time.Now()}}); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob = %+v, %v", job, err)
+	}
+
+	// A renewed lease outlives the claim lease of another worker
+	jobCtx, cancel := context.WithCancel(ctx)
+	leaseLost := p.renewLease(jobCtx, cancel, job, "w1")
+	time.Sleep(10 * jobLeaseRenewal)
+	if other, err := store.ClaimJob(ctx, "w2", 4*jobLeaseRenewal); err != nil || other != nil {
+		t.Fatalf("ClaimJob by another worker = %+v, %v; want the renewed job kept", other, err)
+	}
+	cancel()
+	if leaseLost() {
+		t.Error("lease reported lost while renewed")
+	}
+
+	// Once reclaimed, the first worker's processing is stopped
+	if other, err := store.ClaimJob(ctx, "w2", 0); err != nil || other == nil {
+		t.Fatalf("ClaimJob of an expired lease = %+v, %v", other, err)
+	}
+	jobCtx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	leaseLost = p.renewLease(jobCtx, cancel, job, "w1")
+	select {
+	case <-jobCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("processing not stopped after the job was reclaimed")
+	}
+	if !leaseLost() {
+		t.Error("lease not reported lost after the job was reclaimed")
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bot/pkg/leader"
+	"bot/pkg/logger"
+	"bot/pkg/storage"
+	"bot/pkg/utils"
+)
+
+const (
+	retentionInterval  = 24 * time.Hour // How often the leader prunes expired artifacts
+	retentionDelay     = time.Minute    // Delay before the first pass after startup
+	retentionImagePage = 100            // Hosted images deleted per batch
+)
+
+// retention removes stored artifacts once they are past their policy age.
+// Processed documents (the dedupe keys) are kept forever; only the bulky
+// artifacts attached to them expire. A zero age keeps that artifact forever.
+type retention struct {
+	store      storage.StorageInterface
+	picsur     *utils.Client
+	archiveDir string
+
+	pageTextAge time.Duration
+	pdfAge      time.Duration
+	imageAge    time.Duration
+	jobAge      time.Duration
+	summaryAge  time.Duration
+}
+
+// retentionResult counts what a retention pass removed
+type retentionResult struct {
+	PageTexts int
+	PDFs      int
+	Images    int
+	Jobs      int
+	Summaries int
+}
+
+// loop runs a retention pass every retentionInterval while this instance is
+// the leader, until ctx is cancelled
+func (r *retention) loop(ctx context.Context, elector *leader.Elector) {
+	retentionCtx, _ := logger.NewRequestContextFrom(ctx)
+	retentionLog := log.WithRequestContext(retentionCtx).WithContext("component", "retention")
+
+	if !sleepOrShutdown(ctx, retentionDelay) {
+		return
+	}
+	for {
+		if leaderCtx, cancel, isLeader := elector.LeaderContext(retentionCtx); isLeader {
+			res, err := r.run(leaderCtx, time.Now())
+			cancel()
+			if err != nil {
+				retentionLog.Error("Retention pass incomplete", "error", err)
+			}
+			retentionLog.Info("Retention pass finished",
+				"page_texts", res.PageTexts,
+				"pdfs", res.PDFs,
+				"images", res.Images,
+				"jobs", res.Jobs,
+				"summaries", res.Summaries)
+		} else {
+			retentionLog.Debug("Not the leader, skipping retention")
+		}
+
+		if !sleepOrShutdown(ctx, retentionInterval) {
+			retentionLog.Info("Retention shutting down")
+			return
+		}
+	}
+}
+
+// run prunes everything older than its policy age at now. Each artifact is
+// pruned independently: a failure is reported but does not stop the others.
+func (r *retention) run(ctx context.Context, now time.Time) (retentionResult, error) {
+	var res retentionResult
+	var errs []error
+
+	if r.pageTextAge > 0 {
+		n, err := r.store.PrunePageText(ctx, now.Add(-r.pageTextAge))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.PageTexts = n
+	}
+
+	if r.pdfAge > 0 && r.archiveDir != "" {
+		n, err := pruneArchive(r.archiveDir, now.Add(-r.pdfAge))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.PDFs = n
+	}
+
+	if r.imageAge > 0 && r.picsur != nil {
+		n, err := r.pruneImages(ctx, now.Add(-r.imageAge))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.Images = n
+	}
+
+	if r.jobAge > 0 {
+		n, err := r.store.PruneJobs(ctx, now.Add(-r.jobAge))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.Jobs = n
+	}
+
+	if r.summaryAge > 0 {
+		n, err := r.store.PruneSummaries(ctx, now.Add(-r.summaryAge))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.Summaries = n
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// pruneImages deletes hosted images uploaded before before from Picsur and
+// forgets them. An image Picsur fails to delete stays recorded and is retried
+// on the next pass.
+func (r *retention) pruneImages(ctx context.Context, before time.Time) (int, error) {
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "pruneImages")
+
+	deleted, failed := 0, 0
+	for {
+		images, err := r.store.ListHostedImages(ctx, before, retentionImagePage+failed)
+		if err != nil {
+			return deleted, err
+		}
+		// The first failed images are still listed; skip past them
+		if len(images) <= failed {
+			break
+		}
+
+		for _, img := range images[failed:] {
+			if err := r.picsur.DeleteImage(ctx, img.ImageID, img.DeleteKey); err != nil {
+				ctxLog.Warn("Error deleting hosted image", "image_id", img.ImageID, "title", img.Title, "error", err)
+				failed++
+				continue
+			}
+			if err := r.store.DeleteHostedImage(ctx, img.ImageID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(images) < retentionImagePage+failed {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return deleted, fmt.Errorf("%d hosted images could not be deleted", failed)
+	}
+	return deleted, nil
+}
+
+// pruneArchive removes archived PDFs last written before before
+func pruneArchive(dir string, before time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error reading PDF archive: %v", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pdf") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return removed, fmt.Errorf("error removing archived PDF: %v", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// archivePDF copies a posted PDF into dir, named after its hash so identical
+// re-uploads share one file
+func archivePDF(dir, hash, pdfPath string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating PDF archive: %v", err)
+	}
+
+	if err := copyFile(pdfPath, filepath.Join(dir, hash+".pdf")); err != nil {
+		return fmt.Errorf("error archiving PDF: %v", err)
+	}
+	return nil
+}
+
+// copyFile copies the file at src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
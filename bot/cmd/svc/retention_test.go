@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+	"bot/pkg/utils"
+)
+
+func TestRetentionRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// Picsur accepts every delete except image "stuck"
+	var mu sync.Mutex
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ ID, Key string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/api/image/delete/key" || body.ID == "stuck" {
+			_, _ = w.Write([]byte(`{"success": false, "statusCode": 500}`))
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, body.ID+":"+body.Key)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"success": true, "statusCode": 200}`))
+	}))
+	defer srv.Close()
+
+	store := storage.NewMemory()
+	docs := []storage.ProcessedDocument{
+		{Title: "Doc 3", URL: "u3", Timestamp: now.AddDate(-2, 0, 0), PageText: "old"},
+		{Title: "Doc 8", URL: "u8", Timestamp: now.AddDate(0, 0, -1), PageText: "new"},
+	}
+	for _, doc := range docs {
+		if err := store.AddProcessedDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddHostedImages(ctx, []storage.HostedImage{
+		{ImageID: "stuck", DeleteKey: "k0", UploadedAt: now.AddDate(0, -3, 0)},
+		{ImageID: "old", DeleteKey: "k1", UploadedAt: now.AddDate(0, -2, 0)},
+		{ImageID: "new", DeleteKey: "k2", UploadedAt: now.Add(-time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := t.TempDir()
+	for name, age := range map[string]time.Duration{"old.pdf": 100 * 24 * time.Hour, "new.pdf": time.Hour} {
+		path := filepath.Join(archive, name)
+		if err := os.WriteFile(path, []byte("%PDF"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &retention{
+		store:       store,
+		picsur:      utils.New("key", srv.URL),
+		archiveDir:  archive,
+		pageTextAge: 365 * 24 * time.Hour,
+		pdfAge:      90 * 24 * time.Hour,
+		imageAge:    30 * 24 * time.Hour,
+	}
+	res, err := r.run(ctx, now)
+	if err == nil {
+		t.Error("run succeeded although an image could not be deleted")
+	}
+	if res != (retentionResult{PageTexts: 1, PDFs: 1, Images: 1}) {
+		t.Errorf("run = %+v, want 1 page text, 1 PDF and 1 image", res)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "old:k1" {
+		t.Errorf("Picsur deletes = %v, want old:k1", deleted)
+	}
+	remaining, _ := store.ListHostedImages(ctx, now, 10)
+	if len(remaining) != 2 || remaining[0].ImageID != "stuck" {
+		t.Errorf("hosted images after run = %+v, want stuck kept for the next pass", remaining)
+	}
+	if _, err := os.Stat(filepath.Join(archive, "old.pdf")); !os.IsNotExist(err) {
+		t.Error("old.pdf still archived")
+	}
+	if _, err := os.Stat(filepath.Join(archive, "new.pdf")); err != nil {
+		t.Errorf("new.pdf removed: %v", err)
+	}
+	if n, _ := store.CountProcessed(ctx); n != 2 {
+		t.Errorf("CountProcessed = %d, want both documents kept", n)
+	}
+}
+
+func TestRetentionZeroAgeKeepsEverything(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	if err := store.AddProcessedDocument(ctx, storage.ProcessedDocument{
+		Title: "Doc 1", URL: "u1", Timestamp: time.Now().AddDate(-5, 0, 0), PageText: "text",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &retention{store: store, archiveDir: t.TempDir()}
+	res, err := r.run(ctx, time.Now())
+	if err != nil || res != (retentionResult{}) {
+		t.Errorf("run = %+v, %v; want nothing removed", res, err)
+	}
+}
+
+func TestRetentionPrunesDoneJobs(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: time.Now()},
+		{Title: "Doc 2", URL: "u2", Published: time.Now().Add(time.Minute)},
+	}
+	if _, err := store.EnqueueJobs(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	// Doc 1 is posted; Doc 2 is still waiting for a worker
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob = %+v, %v", job, err)
+	}
+	if err := store.AddProcessedDocument(ctx, storage.ProcessedDocument{Title: job.Title, URL: job.URL, Timestamp: job.Published}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CompleteJob(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &retention{store: store, jobAge: 30 * 24 * time.Hour}
+	res, err := r.run(ctx, time.Now().AddDate(0, 0, 31))
+	if err != nil || res != (retentionResult{Jobs: 1}) {
+		t.Errorf("run = %+v, %v; want the done job pruned", res, err)
+	}
+	if counts, _ := store.CountJobs(ctx); counts[storage.JobDone] != 0 || counts[storage.JobPending] != 1 {
+		t.Errorf("CountJobs = %v, want only the pending job left", counts)
+	}
+}
+
+func TestRetentionPrunesSummaryCache(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	if err := store.PutSummary(ctx, "ab12", "vertex/gemini-2.5-flash", "1", "Summary"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &retention{store: store, summaryAge: 90 * 24 * time.Hour}
+	if res, err := r.run(ctx, time.Now()); err != nil || res != (retentionResult{}) {
+		t.Errorf("run = %+v, %v; want the fresh summary kept", res, err)
+	}
+	res, err := r.run(ctx, time.Now().AddDate(0, 0, 91))
+	if err != nil || res != (retentionResult{Summaries: 1}) {
+		t.Errorf("run = %+v, %v; want the summary pruned", res, err)
+	}
+}
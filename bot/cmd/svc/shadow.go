@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bot/pkg/logger"
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+// shadowRun is a summarizer run to record for comparison
+type shadowRun struct {
+	result  summary.Result
+	err     error
+	elapsed time.Duration
+}
+
+const (
+	shadowTimeout = 10 * time.Minute // Bounds the shadow candidates of one document
+	maxShadowRuns = 2                // Documents running shadow candidates at once
+)
+
+// startShadows runs the shadow candidates on the document in the background,
+// see recordShadows. They work on a copy of the PDF, as the document's
+// directory is removed once it is posted, and run detached from the job on
+// p.shadowCtx within shadowTimeout, so they never hold up the worker or its
+// lease. When maxShadowRuns documents are already running them, the
+// document is skipped: shadows only produce comparison data.
+func (p *processor) startShadows(ctx context.Context, pdfPath string, doc *scraper.Document, summaryDoc summary.Document, production shadowRun) {
+	if !p.summarizer.HasShadows() {
+		return
+	}
+	shadowLog := log.WithRequestContext(ctx).
+		WithContext("method", "startShadows").
+		WithContext("title", doc.Title)
+
+	select {
+	case p.shadowSlots <- struct{}{}:
+	default:
+		shadowLog.Info("Shadow candidates busy, skipping document", "running", maxShadowRuns)
+		return
+	}
+
+	dir := filepath.Join(tempDir, fmt.Sprintf("shadow-%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		shadowLog.Warn("Error creating directory for shadow candidates", "error", err)
+		<-p.shadowSlots
+		return
+	}
+	shadowPath := filepath.Join(dir, filepath.Base(pdfPath))
+	if err := copyFile(pdfPath, shadowPath); err != nil {
+		shadowLog.Warn("Error copying PDF for shadow candidates", "error", err)
+		_ = os.RemoveAll(dir)
+		<-p.shadowSlots
+		return
+	}
+
+	shadowCtx, cancel := context.WithTimeout(p.shadowCtx, shadowTimeout)
+	shadowCtx, shadowID := logger.NewRequestContextFrom(shadowCtx)
+	shadowLog.Debug("Starting shadow candidates", "shadow_request_id", shadowID)
+	p.shadows.Go(func() {
+		defer func() { <-p.shadowSlots }()
+		defer cancel()
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				shadowLog.Warn("Error removing directory of shadow candidates", "error", err)
+			}
+		}()
+		p.recordShadows(shadowCtx, shadowPath, doc, summaryDoc, production)
+	})
+}
+
+// recordShadows runs the shadow candidates on the document and records their
+// summaries next to the production one. Nothing is recorded without
+// candidates, or when shutdown interrupts them; errors are only logged,
+// since the document is already posted.
+func (p *processor) recordShadows(ctx context.Context, pdfPath string, doc *scraper.Document, summaryDoc summary.Document, production shadowRun) {
+	results := p.summarizer.Shadow(ctx, pdfPath, summaryDoc)
+	if len(results) == 0 {
+		return
+	}
+	if errors.Is(p.shadowCtx.Err(), context.Canceled) {
+		log.WithRequestContext(ctx).
+			WithContext("method", "recordShadows").
+			Info("Shadow candidates interrupted by shutdown, not recorded", "title", doc.Title)
+		return
+	}
+
+	now := time.Now().UTC()
+	summaries := make([]storage.ShadowSummary, 0, len(results)+1)
+	summaries = append(summaries, shadowSummary(doc, storage.ShadowProduction, production, now))
+	for _, r := range results {
+		summaries = append(summaries, shadowSummary(doc, r.Candidate, shadowRun{result: r.Result, err: r.Err, elapsed: r.Duration}, now))
+	}
+	if err := p.store.AddShadowSummaries(ctx, summaries); err != nil {
+		log.WithRequestContext(ctx).
+			WithContext("method", "recordShadows").
+			Warn("Error recording shadow summaries", "title", doc.Title, "error", err)
+	}
+}
+
+// shadowSummary describes a run of candidate for storage
+func shadowSummary(doc *scraper.Document, candidate string, run shadowRun, now time.Time) storage.ShadowSummary {
+	sh := storage.ShadowSummary{
+		Title:          doc.Title,
+		URL:            doc.URL,
+		Candidate:      candidate,
+		Model:          run.result.Model,
+		PromptVersion:  run.result.PromptVersion,
+		Summary:        run.result.Text,
+		LatencyMS:      run.elapsed.Milliseconds(),
+		InputTokens:    run.result.Usage.InputTokens,
+		OutputTokens:   run.result.Usage.OutputTokens,
+		ThinkingTokens: run.result.Usage.ThinkingTokens,
+		Cost:           run.result.Cost,
+		CreatedAt:      now,
+	}
+	if run.err != nil {
+		sh.Error = run.err.Error()
+	}
+	return sh
+}
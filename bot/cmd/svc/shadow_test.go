@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+func TestShadowDocuments(t *testing.T) {
+	now := time.Date(2025, 3, 16, 14, 0, 0, 0, time.UTC)
+	doc := &scraper.Document{Title: "Doc 4 - Summons", URL: "u4"}
+	summaries := []storage.ShadowSummary{
+		shadowSummary(doc, storage.ShadowProduction, shadowRun{
+			result:  summary.Result{Text: "Summons issued.", Model: "vertex/a", Usage: summary.Usage{InputTokens: 900, OutputTokens: 80}, Cost: 0.001},
+			elapsed: 1500 * time.Millisecond,
+		}, now),
+		shadowSummary(doc, "local/b", shadowRun{err: errors.New("model not found"), elapsed: 20 * time.Millisecond}, now),
+		{Title: "Doc 3", URL: "u3", Candidate: storage.ShadowProduction, Summary: "Earlier.", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	views := shadowDocuments(summaries)
+	if len(views) != 2 || views[0].Title != "Doc 4 - Summons" || len(views[0].Summaries) != 2 || len(views[1].Summaries) != 1 {
+		t.Fatalf("shadowDocuments = %+v, want Doc 4 with two summaries, then Doc 3", views)
+	}
+	production, candidate := views[0].Summaries[0], views[0].Summaries[1]
+	if production.Candidate != "production" || production.LatencyMS != 1500 || production.InputTokens != 900 || production.Cost != 0.001 {
+		t.Errorf("production = %+v", production)
+	}
+	if candidate.Candidate != "local/b" || candidate.Error != "model not found" || candidate.Summary != "" {
+		t.Errorf("candidate = %+v", candidate)
+	}
+	if len(shadowDocuments(nil)) != 0 || shadowDocuments(nil) == nil {
+		t.Error("shadowDocuments(nil) should be an empty list, written as []")
+	}
+}
+
+func TestStartShadowsOutlivesDocument(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	// A local candidate cannot read the PDF, which still yields a recorded
+	// run without a model server
+	summarizer, err := summary.New(summary.Config{
+		Models:   "local/llama3.1",
+		LocalURL: "http://localhost:11434/v1",
+		Shadows:  []summary.ShadowCandidate{{Models: "local/qwen3"}},
+	})
+	if err != nil {
+		t.Fatalf("summary.New: %v", err)
+	}
+	store := storage.NewMemory()
+	p := &processor{summarizer: summarizer, store: store, shadowCtx: ctx, shadowSlots: make(chan struct{}, maxShadowRuns)}
+
+	docDir := t.TempDir()
+	pdfPath := filepath.Join(docDir, "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte("%PDF-1.4 scan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &scraper.Document{Title: "Doc 4 - Summons", URL: "u4", Published: time.Now()}
+	jobCtx, cancelJob := context.WithCancel(ctx)
+	p.startShadows(jobCtx, pdfPath, doc, summary.Document{Title: doc.Title, URL: doc.URL}, shadowRun{result: summary.Result{Text: "Summons issued."}})
+
+	// The job finishes and removes its directory while the shadows run
+	cancelJob()
+	if err := os.RemoveAll(docDir); err != nil {
+		t.Fatal(err)
+	}
+	p.shadows.Wait()
+
+	summaries, err := store.ListShadowSummaries(ctx, 10)
+	if err != nil || len(summaries) != 2 || summaries[1].Candidate != "local/qwen3" || summaries[1].Error == "" {
+		t.Fatalf("ListShadowSummaries = %+v, %v; want production and the failed candidate", summaries, err)
+	}
+	if strings.Contains(summaries[1].Error, "context canceled") || strings.Contains(summaries[1].Error, "no such file") {
+		t.Errorf("candidate error = %q, want it to have run on its own copy", summaries[1].Error)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp entries = %v, want the shadow copy removed", entries)
+	}
+}
+
+func TestStartShadowsSkipsWhenBusyOrShutDown(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	summarizer, err := summary.New(summary.Config{
+		Models:   "local/llama3.1",
+		LocalURL: "http://localhost:11434/v1",
+		Shadows:  []summary.ShadowCandidate{{Models: "local/qwen3"}},
+	})
+	if err != nil {
+		t.Fatalf("summary.New: %v", err)
+	}
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte("%PDF-1.4 scan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &scraper.Document{Title: "Doc 4 - Summons", URL: "u4", Published: time.Now()}
+	summaryDoc := summary.Document{Title: doc.Title, URL: doc.URL}
+
+	t.Run("busy", func(t *testing.T) {
+		store := storage.NewMemory()
+		p := &processor{summarizer: summarizer, store: store, shadowCtx: ctx, shadowSlots: make(chan struct{}, 1)}
+		p.shadowSlots <- struct{}{}
+		p.startShadows(ctx, pdfPath, doc, summaryDoc, shadowRun{})
+		p.shadows.Wait()
+
+		if summaries, _ := store.ListShadowSummaries(ctx, 10); len(summaries) != 0 {
+			t.Errorf("ListShadowSummaries = %+v, want none while the slots are taken", summaries)
+		}
+		if len(p.shadowSlots) != 1 {
+			t.Errorf("slots taken = %d, want 1", len(p.shadowSlots))
+		}
+	})
+
+	t.Run("shut down", func(t *testing.T) {
+		store := storage.NewMemory()
+		shutdownCtx, shutdown := context.WithCancel(ctx)
+		p := &processor{summarizer: summarizer, store: store, shadowCtx: shutdownCtx, shadowSlots: make(chan struct{}, maxShadowRuns)}
+		shutdown()
+		p.startShadows(ctx, pdfPath, doc, summaryDoc, shadowRun{})
+		p.shadows.Wait()
+
+		if summaries, _ := store.ListShadowSummaries(ctx, 10); len(summaries) != 0 {
+			t.Errorf("ListShadowSummaries = %+v, want none after shutdown", summaries)
+		}
+		if len(p.shadowSlots) != 0 {
+			t.Errorf("slots taken = %d, want the run's slot released", len(p.shadowSlots))
+		}
+		if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+			t.Errorf("temp entries = %v, want the shadow copy removed", entries)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"bot/pkg/config"
+	"bot/pkg/logger"
+	"bot/pkg/storage"
+)
+
+// importBatchSize is the number of records imported per flush
+const importBatchSize = 500
+
+// runCommand runs a maintenance subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "export", "import":
+	case "eval":
+		return runEval(args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\nUsage:\n  svc                 run the bot\n  svc export [file]   write processed documents, platform posts, hosted images, failures and unfinished jobs as JSON Lines (default stdout)\n  svc import [file]   read an export from JSON Lines (default stdin)\n  svc eval dir        score summaries of sample PDFs against their expected facts\n", name)
+		return 2
+	}
+
+	cfg, err := config.LoadStorage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	// Logs go to stderr so that export can write to stdout
+	logLevel, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logLevel = logger.LevelInfo
+	}
+	log = logger.New(logger.Config{
+		Level:          logLevel,
+		OutputWriter:   os.Stderr,
+		ServiceName:    serviceName,
+		Environment:    cfg.Environment,
+		Version:        cfg.Version,
+		SanitizeFields: true,
+	})
+	logger.SetDefaultLogger(log)
+	cmdLog := log.WithContext("command", name)
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		cmdLog.Error("Failed to initialize storage", "driver", cfg.StorageDriver, "error", err)
+		return 1
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			cmdLog.Error("Error closing storage", "error", err)
+		}
+	}()
+
+	ctx, _ := logger.NewRequestContext()
+	path := "-"
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	if name == "export" {
+		out := io.Writer(os.Stdout)
+		if path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				cmdLog.Error("Error creating export file", "path", path, "error", err)
+				return 1
+			}
+			defer func() { _ = f.Close() }()
+			out = f
+		}
+
+		n, err := exportState(ctx, store, out)
+		if err != nil {
+			cmdLog.Error("Export failed", "exported", n, "error", err)
+			return 1
+		}
+		cmdLog.Info("Export complete", "records", n, "path", path)
+		return 0
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			cmdLog.Error("Error opening import file", "path", path, "error", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	read, changed, err := importState(ctx, store, in)
+	if err != nil {
+		cmdLog.Error("Import failed", "read", read, "changed", changed, "error", err)
+		return 1
+	}
+	cmdLog.Info("Import complete", "read", read, "changed", changed, "path", path)
+	return 0
+}
+
+// Record types of the export; a line without a type is a processed
+// document, as written by earlier versions
+const (
+	recordDocument     = "document"
+	recordPlatformPost = "platform_post"
+	recordHostedImage  = "hosted_image"
+	recordFailure      = "failure"
+	recordJob          = "job"
+)
+
+// transferRecord is one line of an export: a typed record
+type transferRecord struct {
+	Type   string          `json:"type"`
+	Record json.RawMessage `json:"record"`
+}
+
+// exportState writes the deployment's state to w as one typed JSON record
+// per line and returns how many were written: processed documents, platform
+// posts, hosted images, document failures and jobs that are not done, each
+// oldest first. The summary cache, reviews, shadow summaries, baselines and
+// the usage ledger are left out; they are rebuilt or only matter locally.
+func exportState(ctx context.Context, store storage.StorageInterface, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	n := 0
+	write := func(recordType string, v any) error {
+		record, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("error encoding %s: %w", recordType, err)
+		}
+		if err := enc.Encode(transferRecord{Type: recordType, Record: record}); err != nil {
+			return fmt.Errorf("error writing %s: %w", recordType, err)
+		}
+		n++
+		return nil
+	}
+
+	err := store.ExportDocuments(ctx, func(doc storage.ProcessedDocument) error {
+		return write(recordDocument, doc)
+	})
+	if err == nil {
+		err = store.ExportPlatformPosts(ctx, func(post storage.PlatformPost) error {
+			return write(recordPlatformPost, post)
+		})
+	}
+	if err == nil {
+		err = store.ExportHostedImages(ctx, func(img storage.HostedImage) error {
+			return write(recordHostedImage, img)
+		})
+	}
+	if err == nil {
+		err = store.ExportFailures(ctx, func(f storage.DocumentFailure) error {
+			return write(recordFailure, f)
+		})
+	}
+	if err == nil {
+		err = store.ExportJobs(ctx, func(job storage.Job) error {
+			return write(recordJob, job)
+		})
+	}
+	if err != nil {
+		return n, err
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("error writing export: %w", err)
+	}
+	return n, nil
+}
+
+// importBatch holds the records read since the last flush, by type
+type importBatch struct {
+	docs     []storage.ProcessedDocument
+	posts    []storage.PlatformPost
+	images   []storage.HostedImage
+	failures []storage.DocumentFailure
+	jobs     []storage.Job
+}
+
+func (b *importBatch) len() int {
+	return len(b.docs) + len(b.posts) + len(b.images) + len(b.failures) + len(b.jobs)
+}
+
+// add decodes a line into the batch
+func (b *importBatch) add(line []byte) error {
+	var rec transferRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return err
+	}
+	if rec.Type == "" {
+		rec.Type, rec.Record = recordDocument, line
+	}
+
+	switch rec.Type {
+	case recordDocument:
+		var doc storage.ProcessedDocument
+		if err := json.Unmarshal(rec.Record, &doc); err != nil {
+			return err
+		}
+		if doc.Title == "" || doc.URL == "" {
+			return errors.New("title and url are required")
+		}
+		b.docs = append(b.docs, doc)
+	case recordPlatformPost:
+		var post storage.PlatformPost
+		if err := json.Unmarshal(rec.Record, &post); err != nil {
+			return err
+		}
+		if post.Title == "" || post.URL == "" || post.Platform == "" {
+			return errors.New("title, url and platform are required")
+		}
+		b.posts = append(b.posts, post)
+	case recordHostedImage:
+		var img storage.HostedImage
+		if err := json.Unmarshal(rec.Record, &img); err != nil {
+			return err
+		}
+		if img.ImageID == "" {
+			return errors.New("image_id is required")
+		}
+		b.images = append(b.images, img)
+	case recordFailure:
+		var f storage.DocumentFailure
+		if err := json.Unmarshal(rec.Record, &f); err != nil {
+			return err
+		}
+		if f.Title == "" || f.URL == "" {
+			return errors.New("title and url are required")
+		}
+		b.failures = append(b.failures, f)
+	case recordJob:
+		var job storage.Job
+		if err := json.Unmarshal(rec.Record, &job); err != nil {
+			return err
+		}
+		if job.Title == "" || job.URL == "" {
+			return errors.New("title and url are required")
+		}
+		if job.Status != storage.JobPending && job.Status != storage.JobDead {
+			return fmt.Errorf("job status %q cannot be imported", job.Status)
+		}
+		b.jobs = append(b.jobs, job)
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+	return nil
+}
+
+// flush imports the batch, documents first, and returns the rows changed
+func (b *importBatch) flush(ctx context.Context, store storage.StorageInterface) (int, error) {
+	changed := 0
+	for _, step := range []func() (int, error){
+		func() (int, error) { return store.ImportDocuments(ctx, b.docs) },
+		func() (int, error) { return store.ImportPlatformPosts(ctx, b.posts) },
+		func() (int, error) { return store.ImportHostedImages(ctx, b.images) },
+		func() (int, error) { return store.ImportFailures(ctx, b.failures) },
+		func() (int, error) { return store.ImportJobs(ctx, b.jobs) },
+	} {
+		n, err := step()
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	*b = importBatch{}
+	return changed, nil
+}
+
+// importState reads JSON Lines from r into store in batches. Re-importing a
+// file is a no-op. Returns the records read and the rows changed.
+func importState(ctx context.Context, store storage.StorageInterface, r io.Reader) (int, int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	read, changed, line := 0, 0, 0
+	var batch importBatch
+	flush := func() error {
+		if batch.len() == 0 {
+			return nil
+		}
+		n, err := batch.flush(ctx, store)
+		changed += n
+		return err
+	}
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := batch.add(scanner.Bytes()); err != nil {
+			return read, changed, fmt.Errorf("line %d: %w", line, err)
+		}
+		read++
+		if batch.len() == importBatchSize {
+			if err := flush(); err != nil {
+				return read, changed, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return read, changed, fmt.Errorf("error reading import: %w", err)
+	}
+	if err := flush(); err != nil {
+		return read, changed, err
+	}
+	return read, changed, nil
+}
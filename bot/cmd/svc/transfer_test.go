@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+	"bot/pkg/storage"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := storage.NewMemory()
+	docs := []storage.ProcessedDocument{
+		{Title: "Doc 1 - Event Notes", URL: "u1", Timestamp: time.Date(2026, 4, 3, 9, 0, 0, 0, time.UTC)},
+		{Title: "Doc 2 - Summons", URL: "u2", Timestamp: time.Date(2026, 4, 3, 10, 0, 0, 0, time.UTC),
+			PostID: "17890", PDFHash: "9f86d0", Summary: "Car 4 summoned for impeding."},
+	}
+	for _, doc := range docs {
+		if err := source.AddProcessedDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.AddPlatformPost(ctx, storage.PlatformPost{Title: docs[1].Title, URL: docs[1].URL,
+		Platform: "threads", PostID: "17890", PostedAt: docs[1].Timestamp}); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.AddHostedImages(ctx, []storage.HostedImage{{ImageID: "img1", URL: "https://picsur.example/i/img1",
+		DeleteKey: "key1", Title: docs[1].Title, DocURL: docs[1].URL, UploadedAt: docs[1].Timestamp}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dead-lettered document keeps its failure record and job
+	dead := &scraper.Document{Title: "Doc 3 - Decision", URL: "u3", Published: time.Date(2026, 4, 3, 11, 0, 0, 0, time.UTC)}
+	if _, err := source.EnqueueJobs(ctx, []*scraper.Document{dead}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := source.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob = %+v, %v", job, err)
+	}
+	if err := source.FailJob(ctx, job.ID, time.Now(), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.RecordFailure(ctx, storage.ProcessedDocument{Title: dead.Title, URL: dead.URL, Timestamp: dead.Published},
+		"download failed", storage.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := exportState(ctx, source, &buf)
+	if err != nil || n != 6 {
+		t.Fatalf("exportState = %d, %v; want 6", n, err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 6 {
+		t.Fatalf("export has %d lines, want 6:\n%s", lines, buf.String())
+	}
+
+	target := storage.NewMemory()
+	read, changed, err := importState(ctx, target, bytes.NewReader(buf.Bytes()))
+	if err != nil || read != 6 || changed != 6 {
+		t.Fatalf("importState = %d, %d, %v; want 6 read, 6 changed", read, changed, err)
+	}
+
+	// Importing the same file again changes nothing
+	read, changed, err = importState(ctx, target, bytes.NewReader(buf.Bytes()))
+	if err != nil || read != 6 || changed != 0 {
+		t.Fatalf("second importState = %d, %d, %v; want 6 read, 0 changed", read, changed, err)
+	}
+
+	var again bytes.Buffer
+	if _, err := exportState(ctx, target, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != buf.String() {
+		t.Errorf("re-export differs:\n%s\nwant:\n%s", again.String(), buf.String())
+	}
+
+	// The imported state is usable: posts are known, images can be deleted
+	// by retention and the dead letter can be replayed
+	if posts, err := target.ListPlatformPosts(ctx, docs[1].Title, docs[1].URL); err != nil || len(posts) != 1 || posts[0].PostID != "17890" {
+		t.Errorf("ListPlatformPosts = %+v, %v", posts, err)
+	}
+	if images, err := target.ListHostedImages(ctx, time.Now(), 10); err != nil || len(images) != 1 || images[0].DeleteKey != "key1" {
+		t.Errorf("ListHostedImages = %+v, %v", images, err)
+	}
+	if replayed, err := target.ReplayDeadLetter(ctx, dead.Title, dead.URL); err != nil || !replayed {
+		t.Errorf("ReplayDeadLetter = %v, %v", replayed, err)
+	}
+	if job, err := target.ClaimJob(ctx, "w2", time.Hour); err != nil || job == nil || job.Title != dead.Title {
+		t.Errorf("ClaimJob after replay = %+v, %v", job, err)
+	}
+
+}
+
+func TestImportReadsUntypedDocuments(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	input := `{"title": "Doc 1 - Event Notes", "url": "u1", "timestamp": "2026-04-03T09:00:00Z", "post_id": "17890"}`
+
+	read, changed, err := importState(ctx, store, strings.NewReader(input))
+	if err != nil || read != 1 || changed != 1 {
+		t.Fatalf("importState = %d, %d, %v; want 1 read, 1 changed", read, changed, err)
+	}
+	if n, err := store.CountProcessed(ctx); err != nil || n != 1 {
+		t.Errorf("CountProcessed = %d, %v; want 1", n, err)
+	}
+}
+
+func TestImportRejectsInvalidLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"malformed JSON", `{"title": "Doc 1", "url": `},
+		{"missing url", `{"title": "Doc 1"}`},
+		{"unknown type", `{"type": "baseline", "record": {}}`},
+		{"platform post without platform", `{"type": "platform_post", "record": {"title": "Doc 1", "url": "u1"}}`},
+		{"done job", `{"type": "job", "record": {"title": "Doc 1", "url": "u1", "status": "done"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := importState(context.Background(), storage.NewMemory(), strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), "line 1") {
+				t.Errorf("importState error = %v, want a line 1 error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"bot/pkg/storage"
+	"bot/pkg/summary"
+)
+
+// summaryLedger records summarization usage in storage
+type summaryLedger struct {
+	store storage.StorageInterface
+}
+
+// AddUsage adds one call's usage to the document's total for the model
+func (l summaryLedger) AddUsage(ctx context.Context, day string, doc summary.Document, model string, usage summary.Usage, cost float64) error {
+	return l.store.AddSummaryUsage(ctx, storage.SummaryUsage{
+		Day:            day,
+		Title:          doc.Title,
+		URL:            doc.URL,
+		Model:          model,
+		Calls:          1,
+		InputTokens:    usage.InputTokens,
+		OutputTokens:   usage.OutputTokens,
+		ThinkingTokens: usage.ThinkingTokens,
+		Cost:           cost,
+	})
+}
+
+// Spent returns the cost of all calls on day
+func (l summaryLedger) Spent(ctx context.Context, day string) (float64, error) {
+	usage, err := l.store.DailySummaryUsage(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	var spent float64
+	for _, u := range usage {
+		if u.Day == day {
+			spent += u.Cost
+		}
+	}
+	return spent, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -9,12 +12,22 @@ import (
 // Config represents the configuration of the bot.
 type Config struct {
 	// Storage configuration
-	DBHost     string `mapstructure:"DB_HOST"`
-	DBPort     string `mapstructure:"DB_PORT"`
-	DBUser     string `mapstructure:"DB_USER"`
-	DBPassword string `mapstructure:"DB_PASSWORD"`
-	DBName     string `mapstructure:"DB_NAME"`
-	DBSSLMode  string `mapstructure:"DB_SSL_MODE"`
+	StorageDriver string `mapstructure:"STORAGE_DRIVER"`
+	SQLitePath    string `mapstructure:"SQLITE_PATH"`
+	DBHost        string `mapstructure:"DB_HOST"`
+	DBPort        string `mapstructure:"DB_PORT"`
+	DBUser        string `mapstructure:"DB_USER"`
+	DBPassword    string `mapstructure:"DB_PASSWORD"`
+	DBName        string `mapstructure:"DB_NAME"`
+	DBSSLMode     string `mapstructure:"DB_SSL_MODE"`
+
+	// Publishers are the comma-separated platforms every document is
+	// published to, e.g. "threads"
+	Publishers string `mapstructure:"PUBLISHERS"`
+
+	// DryRun runs the bot without side effects: posts are logged instead of
+	// published, and state is kept in memory
+	DryRun bool `mapstructure:"DRY_RUN"`
 
 	// Other configuration
 	FIAUrl              string `mapstructure:"FIA_URL"`
@@ -25,12 +38,99 @@ type Config struct {
 	ThreadsRedirectURI  string `mapstructure:"THREADS_REDIRECT_URI"`
 	ScrapeInterval      int    `mapstructure:"SCRAPE_INTERVAL"`
 	DocumentsToFetch    int    `mapstructure:"DOCUMENTS_TO_FETCH"`
+	GeminiBackend       string `mapstructure:"GEMINI_BACKEND"`
 	GeminiAPIKey        string `mapstructure:"GEMINI_API_KEY"`
 	GeminiModels        string `mapstructure:"GEMINI_MODELS"`
-	PicsurAPI           string `mapstructure:"PICSUR_API"`
-	PicsurURL           string `mapstructure:"PICSUR_URL"`
-	ShortenerAPIKey     string `mapstructure:"SHORTENER_API_KEY"`
-	ShortenerURL        string `mapstructure:"SHORTENER_URL"`
+	AIStudioAPIKey      string `mapstructure:"AISTUDIO_API_KEY"`
+	OpenAIBaseURL       string `mapstructure:"OPENAI_BASE_URL"`
+	OpenAIAPIKey        string `mapstructure:"OPENAI_API_KEY"`
+	LocalLLMURL         string `mapstructure:"LOCAL_LLM_URL"`
+
+	// Vertex AI project, location and service account key file, used
+	// instead of GEMINI_API_KEY; without a key file the project uses
+	// Application Default Credentials
+	VertexProject         string `mapstructure:"VERTEX_PROJECT"`
+	VertexLocation        string `mapstructure:"VERTEX_LOCATION"`
+	VertexCredentialsFile string `mapstructure:"VERTEX_CREDENTIALS_FILE"`
+
+	PromptsDir             string `mapstructure:"PROMPTS_DIR"`
+	SummaryRoutesFile      string `mapstructure:"SUMMARY_ROUTES_FILE"`
+	SummaryMinWords        int    `mapstructure:"SUMMARY_MIN_WORDS"`
+	SummaryMaxWords        int    `mapstructure:"SUMMARY_MAX_WORDS"`
+	SummaryBannedTokens    string `mapstructure:"SUMMARY_BANNED_TOKENS"`
+	SummaryTextTokenBudget int    `mapstructure:"SUMMARY_TEXT_TOKEN_BUDGET"`
+	PicsurAPI              string `mapstructure:"PICSUR_API"`
+	PicsurURL              string `mapstructure:"PICSUR_URL"`
+	ShortenerAPIKey        string `mapstructure:"SHORTENER_API_KEY"`
+	ShortenerURL           string `mapstructure:"SHORTENER_URL"`
+
+	// Summarization retries: calls per model and document, and the
+	// consecutive failures that open a model's circuit breaker for
+	// SummaryBreakerCooldown seconds
+	SummaryRetryAttempts    int `mapstructure:"SUMMARY_RETRY_ATTEMPTS"`
+	SummaryBreakerThreshold int `mapstructure:"SUMMARY_BREAKER_THRESHOLD"`
+	SummaryBreakerCooldown  int `mapstructure:"SUMMARY_BREAKER_COOLDOWN"`
+
+	// Summarization cost: model prices ("model=input/output" in US dollars
+	// per million tokens) and a daily budget in US dollars (0 disables it),
+	// after which SummaryBudgetModels are used, or none
+	SummaryPrices       string  `mapstructure:"SUMMARY_PRICES"`
+	SummaryDailyBudget  float64 `mapstructure:"SUMMARY_DAILY_BUDGET"`
+	SummaryBudgetModels string  `mapstructure:"SUMMARY_BUDGET_MODELS"`
+
+	// Related documents: how many earlier documents about the same incident
+	// to add to the prompt (0 disables it), from the last SummaryRelatedDays
+	SummaryRelatedDocuments int `mapstructure:"SUMMARY_RELATED_DOCUMENTS"`
+	SummaryRelatedDays      int `mapstructure:"SUMMARY_RELATED_DAYS"`
+
+	// SummaryLanguages are comma-separated language codes, e.g. "es,it";
+	// each translation of the summary is posted as a reply
+	SummaryLanguages string `mapstructure:"SUMMARY_LANGUAGES"`
+
+	// SummaryShadow lists ";"-separated candidates, "models[@prompts dir]",
+	// run on every summarized document for comparison only
+	SummaryShadow string `mapstructure:"SUMMARY_SHADOW"`
+
+	// Retry and dead-letter configuration
+	RetryMaxAttempts int `mapstructure:"RETRY_MAX_ATTEMPTS"`
+	RetryBaseDelay   int `mapstructure:"RETRY_BASE_DELAY"`
+	RetryMaxDelay    int `mapstructure:"RETRY_MAX_DELAY"`
+
+	// Operations configuration
+	AlertWebhookURL string `mapstructure:"ALERT_WEBHOOK_URL"`
+	AdminToken      string `mapstructure:"ADMIN_TOKEN"`
+
+	// Baseline configuration: record the listing as seen without posting on
+	// the first cycle (off, auto on an empty database, or force)
+	Baseline           string `mapstructure:"BASELINE"`
+	BaselinePostNewest int    `mapstructure:"BASELINE_POST_NEWEST"`
+
+	// Catch-up configuration: how a backlog of more than CatchupThreshold new
+	// documents (e.g. after downtime) is posted: all, recent or digest
+	CatchupThreshold int    `mapstructure:"CATCHUP_THRESHOLD"`
+	CatchupPolicy    string `mapstructure:"CATCHUP_POLICY"`
+	CatchupMaxAge    int    `mapstructure:"CATCHUP_MAX_AGE"`
+
+	// Publishing order: none, published (FIA publish time) or number (FIA
+	// document number); a document waits PublishOrderTimeout for its turn
+	PublishOrder        string `mapstructure:"PUBLISH_ORDER"`
+	PublishOrderTimeout int    `mapstructure:"PUBLISH_ORDER_TIMEOUT"`
+
+	// Retention: posted PDFs are archived in PDFArchiveDir (empty disables
+	// archiving); page text, archived PDFs, Picsur images, done jobs and
+	// cached summaries are removed after the given number of days (0 keeps
+	// them forever). Processed documents themselves are kept forever so
+	// nothing is posted twice.
+	PDFArchiveDir         string `mapstructure:"PDF_ARCHIVE_DIR"`
+	RetentionPageTextDays int    `mapstructure:"RETENTION_PAGE_TEXT_DAYS"`
+	RetentionPDFDays      int    `mapstructure:"RETENTION_PDF_DAYS"`
+	RetentionImageDays    int    `mapstructure:"RETENTION_IMAGE_DAYS"`
+	RetentionJobDays      int    `mapstructure:"RETENTION_JOB_DAYS"`
+	RetentionSummaryDays  int    `mapstructure:"RETENTION_SUMMARY_DAYS"`
+
+	// Leader election configuration
+	LeaderElection      bool `mapstructure:"LEADER_ELECTION"`
+	LeaderCheckInterval int  `mapstructure:"LEADER_CHECK_INTERVAL"`
 
 	// Logging configuration
 	LogLevel     string `mapstructure:"LOG_LEVEL"`
@@ -41,22 +141,199 @@ type Config struct {
 
 // Load loads the configuration from environment variables and .env file.
 func Load() (*Config, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate required fields
+	if err := cfg.validatePublishers(); err != nil {
+		return nil, err
+	}
+	if cfg.GeminiModels == "" {
+		return nil, fmt.Errorf("GEMINI_MODELS is required")
+	}
+	// A dry run uploads no images and shortens no links
+	if !cfg.DryRun {
+		if cfg.PicsurAPI == "" {
+			return nil, fmt.Errorf("PICSUR_API is required")
+		}
+		if cfg.PicsurURL == "" {
+			return nil, fmt.Errorf("PICSUR_URL is required")
+		}
+		if cfg.ShortenerAPIKey == "" {
+			return nil, fmt.Errorf("SHORTENER_API_KEY is required")
+		}
+		if cfg.ShortenerURL == "" {
+			return nil, fmt.Errorf("SHORTENER_URL is required")
+		}
+	}
+
+	if err := cfg.validateSummary(); err != nil {
+		return nil, err
+	}
+
+	if cfg.DocumentsToFetch <= 0 {
+		return nil, fmt.Errorf("DOCUMENTS_TO_FETCH must be positive, got %d", cfg.DocumentsToFetch)
+	}
+
+	if cfg.RetryMaxAttempts <= 0 {
+		return nil, fmt.Errorf("RETRY_MAX_ATTEMPTS must be positive, got %d", cfg.RetryMaxAttempts)
+	}
+	if cfg.RetryBaseDelay <= 0 || cfg.RetryMaxDelay < cfg.RetryBaseDelay {
+		return nil, fmt.Errorf("RETRY_BASE_DELAY must be positive and not exceed RETRY_MAX_DELAY, got %d and %d", cfg.RetryBaseDelay, cfg.RetryMaxDelay)
+	}
+
+	switch cfg.Baseline {
+	case "off", "auto", "force":
+	default:
+		return nil, fmt.Errorf("BASELINE must be off, auto or force, got %q", cfg.Baseline)
+	}
+	if cfg.BaselinePostNewest < 0 {
+		return nil, fmt.Errorf("BASELINE_POST_NEWEST must not be negative, got %d", cfg.BaselinePostNewest)
+	}
+
+	switch cfg.CatchupPolicy {
+	case "all", "recent", "digest":
+	default:
+		return nil, fmt.Errorf("CATCHUP_POLICY must be all, recent or digest, got %q", cfg.CatchupPolicy)
+	}
+	if cfg.CatchupThreshold <= 0 {
+		return nil, fmt.Errorf("CATCHUP_THRESHOLD must be positive, got %d", cfg.CatchupThreshold)
+	}
+	if cfg.CatchupMaxAge <= 0 {
+		return nil, fmt.Errorf("CATCHUP_MAX_AGE must be positive, got %d", cfg.CatchupMaxAge)
+	}
+
+	switch cfg.PublishOrder {
+	case "none", "published", "number":
+	default:
+		return nil, fmt.Errorf("PUBLISH_ORDER must be none, published or number, got %q", cfg.PublishOrder)
+	}
+	if cfg.PublishOrderTimeout <= 0 {
+		return nil, fmt.Errorf("PUBLISH_ORDER_TIMEOUT must be positive, got %d", cfg.PublishOrderTimeout)
+	}
+
+	if cfg.RetentionPageTextDays < 0 || cfg.RetentionPDFDays < 0 || cfg.RetentionImageDays < 0 || cfg.RetentionJobDays < 0 || cfg.RetentionSummaryDays < 0 {
+		return nil, fmt.Errorf("RETENTION_PAGE_TEXT_DAYS, RETENTION_PDF_DAYS, RETENTION_IMAGE_DAYS, RETENTION_JOB_DAYS and RETENTION_SUMMARY_DAYS must not be negative, got %d, %d, %d, %d and %d",
+			cfg.RetentionPageTextDays, cfg.RetentionPDFDays, cfg.RetentionImageDays, cfg.RetentionJobDays, cfg.RetentionSummaryDays)
+	}
+
+	if cfg.LeaderCheckInterval <= 0 {
+		return nil, fmt.Errorf("LEADER_CHECK_INTERVAL must be positive, got %d", cfg.LeaderCheckInterval)
+	}
+
+	if err := cfg.validateStorage(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadStorage loads the configuration like Load but validates only the
+// storage settings, for maintenance commands that need no Threads or Gemini
+// credentials.
+func LoadStorage() (*Config, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validateStorage(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadSummary loads the configuration like Load but validates only the
+// summarization settings, for the eval command, which needs no Threads
+// credentials or storage.
+func LoadSummary() (*Config, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validateSummary(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// read reads the .env file and environment and applies defaults
+func read() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.AddConfigPath(".")
 	viper.AutomaticEnv()
 
+	// Reported on stderr: stdout may carry command output (see export)
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Error reading config file: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading config file: %s\n", err)
 	}
 
 	// Set default values before unmarshalling so they take effect
+	viper.SetDefault("PUBLISHERS", "threads")
+	viper.SetDefault("DRY_RUN", false)
 	viper.SetDefault("SCRAPE_INTERVAL", 30)
 	viper.SetDefault("DOCUMENTS_TO_FETCH", 15)
-	// Comma-separated Gemini models in order of preference; a ":thinking"
-	// suffix enables thinking for that model.
+	// Comma-separated models in order of preference; a "provider/" prefix
+	// picks the provider (Vertex AI by default) and a ":thinking" suffix
+	// enables thinking for that model. Each provider's credentials are
+	// checked when the summarizer starts.
 	viper.SetDefault("GEMINI_MODELS", "gemini-3.1-flash-lite:thinking,gemini-2.5-flash-lite")
+	// Models without a prefix run on Vertex AI ("vertex") or the Gemini
+	// Developer API of AI Studio ("aistudio")
+	viper.SetDefault("GEMINI_BACKEND", "vertex")
+	viper.SetDefault("VERTEX_PROJECT", "")
+	viper.SetDefault("VERTEX_LOCATION", "global")
+	viper.SetDefault("VERTEX_CREDENTIALS_FILE", "")
+	viper.SetDefault("OPENAI_BASE_URL", "https://api.openai.com/v1")
+	viper.SetDefault("LOCAL_LLM_URL", "http://localhost:11434/v1")
+	// Summaries are asked for 40-60 words; the guardrails allow some slack
+	// (0 disables a bound). Banned tokens are comma-separated.
+	viper.SetDefault("SUMMARY_MIN_WORDS", 30)
+	viper.SetDefault("SUMMARY_MAX_WORDS", 75)
+	viper.SetDefault("SUMMARY_BANNED_TOKENS", "#,http://,https://,**")
+	// Documents with a text layer are summarized from their text, cut to
+	// about this many tokens; scans are sent as PDFs
+	viper.SetDefault("SUMMARY_TEXT_TOKEN_BUDGET", 8000)
+	viper.SetDefault("SUMMARY_RETRY_ATTEMPTS", 3)
+	viper.SetDefault("SUMMARY_BREAKER_THRESHOLD", 3)
+	viper.SetDefault("SUMMARY_BREAKER_COOLDOWN", 300)
+	// Models without a price cost nothing, so the budget only counts priced
+	// models
+	viper.SetDefault("SUMMARY_PRICES", "")
+	viper.SetDefault("SUMMARY_DAILY_BUDGET", 0)
+	viper.SetDefault("SUMMARY_BUDGET_MODELS", "")
+	// Decisions are given the summonses before them, usually from the same
+	// event weekend
+	viper.SetDefault("SUMMARY_RELATED_DOCUMENTS", 3)
+	viper.SetDefault("SUMMARY_RELATED_DAYS", 4)
+	viper.SetDefault("SUMMARY_LANGUAGES", "")
+	viper.SetDefault("SUMMARY_SHADOW", "")
+	// JSON routes choosing models by document; empty uses GEMINI_MODELS for
+	// all
+	viper.SetDefault("SUMMARY_ROUTES_FILE", "")
+	viper.SetDefault("STORAGE_DRIVER", "postgres")
+	viper.SetDefault("SQLITE_PATH", "data/fia-docs.db")
 	viper.SetDefault("DB_PORT", "5432")
 	viper.SetDefault("DB_SSL_MODE", "disable")
+	viper.SetDefault("RETRY_MAX_ATTEMPTS", 6)
+	viper.SetDefault("RETRY_BASE_DELAY", 60)
+	viper.SetDefault("RETRY_MAX_DELAY", 1800)
+	viper.SetDefault("BASELINE", "auto")
+	viper.SetDefault("BASELINE_POST_NEWEST", 0)
+	viper.SetDefault("CATCHUP_THRESHOLD", 5)
+	viper.SetDefault("CATCHUP_POLICY", "all")
+	viper.SetDefault("CATCHUP_MAX_AGE", 120)
+	viper.SetDefault("PUBLISH_ORDER", "none")
+	viper.SetDefault("PUBLISH_ORDER_TIMEOUT", 300)
+	viper.SetDefault("PDF_ARCHIVE_DIR", "")
+	viper.SetDefault("RETENTION_PAGE_TEXT_DAYS", 365)
+	viper.SetDefault("RETENTION_PDF_DAYS", 90)
+	viper.SetDefault("RETENTION_IMAGE_DAYS", 30)
+	viper.SetDefault("RETENTION_JOB_DAYS", 30)
+	viper.SetDefault("RETENTION_SUMMARY_DAYS", 90)
+	viper.SetDefault("LEADER_ELECTION", true)
+	viper.SetDefault("LEADER_CHECK_INTERVAL", 15)
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("LOG_ADD_SOURCE", false)
 	viper.SetDefault("ENVIRONMENT", "production")
@@ -66,59 +343,136 @@ func Load() (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-
-	// Validate required fields
-	if cfg.ThreadsAccessToken == "" {
-		return nil, fmt.Errorf("THREADS_ACCESS_TOKEN is required")
-	}
-	if cfg.ThreadsUserID == "" {
-		return nil, fmt.Errorf("THREADS_USER_ID is required")
+	// A dry run must not mark documents processed in a real database, or
+	// the live bot would skip them
+	if cfg.DryRun {
+		cfg.StorageDriver = "memory"
 	}
-	if cfg.ThreadsClientID == "" {
-		return nil, fmt.Errorf("THREADS_CLIENT_ID is required")
-	}
-	if cfg.ThreadsClientSecret == "" {
-		return nil, fmt.Errorf("THREADS_CLIENT_SECRET is required")
-	}
-	if cfg.ThreadsRedirectURI == "" {
-		return nil, fmt.Errorf("THREADS_REDIRECT_URI is required")
+	return &cfg, nil
+}
+
+// PublisherList returns the enabled platforms, in publishing order
+func (cfg *Config) PublisherList() []string {
+	var platforms []string
+	for _, platform := range strings.Split(cfg.Publishers, ",") {
+		if platform = strings.TrimSpace(platform); platform != "" && !slices.Contains(platforms, platform) {
+			platforms = append(platforms, platform)
+		}
 	}
-	if cfg.PicsurAPI == "" {
-		return nil, fmt.Errorf("PICSUR_API is required")
+	return platforms
+}
+
+// validatePublishers checks that some known platform is enabled and, unless
+// this is a dry run, has its settings
+func (cfg *Config) validatePublishers() error {
+	platforms := cfg.PublisherList()
+	if len(platforms) == 0 {
+		return fmt.Errorf("PUBLISHERS is required")
 	}
-	if cfg.GeminiAPIKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY is required")
+	for _, platform := range platforms {
+		switch platform {
+		case "threads":
+			if cfg.DryRun {
+				continue
+			}
+			if cfg.ThreadsAccessToken == "" {
+				return fmt.Errorf("THREADS_ACCESS_TOKEN is required")
+			}
+			if cfg.ThreadsUserID == "" {
+				return fmt.Errorf("THREADS_USER_ID is required")
+			}
+			if cfg.ThreadsClientID == "" {
+				return fmt.Errorf("THREADS_CLIENT_ID is required")
+			}
+			if cfg.ThreadsClientSecret == "" {
+				return fmt.Errorf("THREADS_CLIENT_SECRET is required")
+			}
+			if cfg.ThreadsRedirectURI == "" {
+				return fmt.Errorf("THREADS_REDIRECT_URI is required")
+			}
+		default:
+			return fmt.Errorf("PUBLISHERS must list threads, got %q", platform)
+		}
 	}
-	if cfg.GeminiModels == "" {
-		return nil, fmt.Errorf("GEMINI_MODELS is required")
+	return nil
+}
+
+// validateSummary checks the summarization settings
+func (cfg *Config) validateSummary() error {
+	switch cfg.GeminiBackend {
+	case "vertex", "aistudio":
+	default:
+		return fmt.Errorf("GEMINI_BACKEND must be vertex or aistudio, got %q", cfg.GeminiBackend)
 	}
-	if cfg.PicsurURL == "" {
-		return nil, fmt.Errorf("PICSUR_URL is required")
+
+	// With the aistudio backend GEMINI_API_KEY is the AI Studio key
+	if cfg.GeminiBackend == "vertex" && cfg.VertexProject != "" && cfg.GeminiAPIKey != "" {
+		return fmt.Errorf("GEMINI_API_KEY and VERTEX_PROJECT are exclusive: Vertex AI uses an API key or a project")
 	}
-	if cfg.ShortenerAPIKey == "" {
-		return nil, fmt.Errorf("SHORTENER_API_KEY is required")
+	if cfg.VertexCredentialsFile != "" {
+		if cfg.VertexProject == "" {
+			return fmt.Errorf("VERTEX_CREDENTIALS_FILE needs VERTEX_PROJECT")
+		}
+		if _, err := os.Stat(cfg.VertexCredentialsFile); err != nil {
+			return fmt.Errorf("VERTEX_CREDENTIALS_FILE: %v", err)
+		}
 	}
-	if cfg.ShortenerURL == "" {
-		return nil, fmt.Errorf("SHORTENER_URL is required")
+
+	if cfg.SummaryMinWords < 0 || cfg.SummaryMaxWords < 0 ||
+		(cfg.SummaryMaxWords > 0 && cfg.SummaryMaxWords < cfg.SummaryMinWords) {
+		return fmt.Errorf("SUMMARY_MIN_WORDS and SUMMARY_MAX_WORDS must not be negative and SUMMARY_MAX_WORDS must not be below SUMMARY_MIN_WORDS, got %d and %d",
+			cfg.SummaryMinWords, cfg.SummaryMaxWords)
 	}
 
-	if cfg.DocumentsToFetch <= 0 {
-		return nil, fmt.Errorf("DOCUMENTS_TO_FETCH must be positive, got %d", cfg.DocumentsToFetch)
+	if cfg.SummaryTextTokenBudget <= 0 {
+		return fmt.Errorf("SUMMARY_TEXT_TOKEN_BUDGET must be positive, got %d", cfg.SummaryTextTokenBudget)
 	}
 
-	// Validate PostgreSQL configuration
-	if cfg.DBHost == "" {
-		return nil, fmt.Errorf("DB_HOST is required")
+	if cfg.SummaryRetryAttempts <= 0 || cfg.SummaryBreakerThreshold <= 0 || cfg.SummaryBreakerCooldown <= 0 {
+		return fmt.Errorf("SUMMARY_RETRY_ATTEMPTS, SUMMARY_BREAKER_THRESHOLD and SUMMARY_BREAKER_COOLDOWN must be positive, got %d, %d and %d",
+			cfg.SummaryRetryAttempts, cfg.SummaryBreakerThreshold, cfg.SummaryBreakerCooldown)
 	}
-	if cfg.DBUser == "" {
-		return nil, fmt.Errorf("DB_USER is required")
+
+	if cfg.SummaryDailyBudget < 0 {
+		return fmt.Errorf("SUMMARY_DAILY_BUDGET must not be negative, got %v", cfg.SummaryDailyBudget)
 	}
-	if cfg.DBPassword == "" {
-		return nil, fmt.Errorf("DB_PASSWORD is required")
+
+	if cfg.SummaryRelatedDocuments < 0 || cfg.SummaryRelatedDays <= 0 {
+		return fmt.Errorf("SUMMARY_RELATED_DOCUMENTS must not be negative and SUMMARY_RELATED_DAYS must be positive, got %d and %d",
+			cfg.SummaryRelatedDocuments, cfg.SummaryRelatedDays)
 	}
-	if cfg.DBName == "" {
-		return nil, fmt.Errorf("DB_NAME is required")
+
+	return nil
+}
+
+// validateStorage checks the settings of the selected storage driver
+func (cfg *Config) validateStorage() error {
+	switch cfg.StorageDriver {
+	case "postgres":
+		if cfg.DBHost == "" {
+			return fmt.Errorf("DB_HOST is required")
+		}
+		if cfg.DBUser == "" {
+			return fmt.Errorf("DB_USER is required")
+		}
+		if cfg.DBPassword == "" {
+			return fmt.Errorf("DB_PASSWORD is required")
+		}
+		if cfg.DBName == "" {
+			return fmt.Errorf("DB_NAME is required")
+		}
+	case "sqlite":
+		if cfg.SQLitePath == "" {
+			return fmt.Errorf("SQLITE_PATH is required")
+		}
+	case "memory":
+		// State is lost on exit, so a restart would post the listing again
+		if !cfg.DryRun {
+			return fmt.Errorf("STORAGE_DRIVER=memory requires DRY_RUN=true: nothing is persisted, so every restart would post the listing again")
+		}
+	default:
+		return fmt.Errorf("STORAGE_DRIVER must be postgres, sqlite or memory, got %q", cfg.StorageDriver)
 	}
 
-	return &cfg, nil
+	return nil
 }
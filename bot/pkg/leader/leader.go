@@ -0,0 +1,174 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bot/pkg/logger"
+)
+
+// Package logger
+var log = logger.Package("leader")
+
+// releaseTimeout bounds how long Run waits to release the lock on shutdown.
+const releaseTimeout = 5 * time.Second
+
+// Locker is a cluster-wide mutual exclusion lock. TryAcquireLeaderLock must
+// be idempotent: while the caller holds the lock it keeps returning true, and
+// it returns false once the lock has been lost (e.g. the session holding it
+// died).
+type Locker interface {
+	TryAcquireLeaderLock(ctx context.Context) (bool, error)
+	ReleaseLeaderLock(ctx context.Context) error
+}
+
+// Status is a snapshot of this instance's leadership.
+type Status struct {
+	Leader bool
+	Since  time.Time // when the current state (leader or follower) began
+}
+
+// Elector decides whether this instance runs singleton duties (scraping,
+// token refresh, ...). Every instance runs an Elector; the one holding the
+// lock is the leader and the others stand by, re-checking every interval so
+// one of them takes over when the leader goes away.
+type Elector struct {
+	locker   Locker
+	interval time.Duration
+
+	mu     sync.RWMutex
+	status Status
+
+	// term is cancelled when this instance loses leadership
+	term    context.Context
+	endTerm context.CancelFunc
+}
+
+// New creates an Elector backed by locker that re-checks leadership every
+// interval. Call Run to start campaigning.
+func New(locker Locker, interval time.Duration) *Elector {
+	return &Elector{
+		locker:   locker,
+		interval: interval,
+		status:   Status{Since: time.Now()},
+	}
+}
+
+// Standalone returns an Elector that is always the leader. Used when leader
+// election is disabled and a single instance is guaranteed.
+func Standalone() *Elector {
+	return &Elector{
+		status: Status{Leader: true, Since: time.Now()},
+		term:   context.Background(),
+	}
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.status.Leader
+}
+
+// Status returns the current leadership snapshot.
+func (e *Elector) Status() Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.status
+}
+
+// LeaderContext returns a context derived from ctx that is also cancelled
+// when this instance loses leadership, so work started as the leader stops
+// once another instance may take over. It returns false, and ctx, if this
+// instance is not the leader. Call the cancel function when the work is done.
+func (e *Elector) LeaderContext(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	e.mu.RLock()
+	leader, term := e.status.Leader, e.term
+	e.mu.RUnlock()
+	if !leader {
+		return ctx, func() {}, false
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(term, cancel)
+	return leaderCtx, func() {
+		stop()
+		cancel()
+	}, true
+}
+
+// Run campaigns for leadership until ctx is cancelled, then releases the
+// lock so a standby instance can take over without waiting for the session
+// to time out. Returns immediately for a Standalone elector.
+func (e *Elector) Run(ctx context.Context) {
+	if e.locker == nil {
+		return
+	}
+
+	ctxLog := log.WithContext("method", "Run")
+	ctxLog.Info("Starting leader election", "interval", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.check(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if e.IsLeader() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+				if err := e.locker.ReleaseLeaderLock(releaseCtx); err != nil {
+					ctxLog.Warn("Failed to release leader lock", "error", err)
+				} else {
+					ctxLog.Info("Leader lock released")
+				}
+				cancel()
+				e.setLeader(false)
+			}
+			return
+		}
+	}
+}
+
+// check runs one acquire attempt and records any change in leadership.
+// Errors demote the instance: a leader that cannot confirm it still holds
+// the lock must assume it has lost it.
+func (e *Elector) check(ctx context.Context) {
+	ctxLog := log.WithContext("method", "check")
+
+	acquired, err := e.locker.TryAcquireLeaderLock(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		ctxLog.Error("Leader lock check failed", "error", err)
+		acquired = false
+	}
+
+	if changed := e.setLeader(acquired); changed {
+		if acquired {
+			ctxLog.Info("Acquired leadership")
+		} else {
+			ctxLog.Warn("Lost leadership, standing by")
+		}
+	}
+}
+
+// setLeader updates the leadership state and reports whether it changed.
+func (e *Elector) setLeader(leader bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status.Leader == leader {
+		return false
+	}
+	e.status = Status{Leader: leader, Since: time.Now()}
+	if leader {
+		e.term, e.endTerm = context.WithCancel(context.Background())
+	} else if e.endTerm != nil {
+		e.endTerm()
+	}
+	return true
+}
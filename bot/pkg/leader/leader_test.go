@@ -0,0 +1,98 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeLocker struct {
+	acquired bool
+	err      error
+	released bool
+}
+
+func (f *fakeLocker) TryAcquireLeaderLock(context.Context) (bool, error) {
+	return f.acquired, f.err
+}
+
+func (f *fakeLocker) ReleaseLeaderLock(context.Context) error {
+	f.released = true
+	return nil
+}
+
+func TestElectorFailover(t *testing.T) {
+	locker := &fakeLocker{}
+	e := New(locker, 0)
+	ctx := context.Background()
+
+	e.check(ctx)
+	if e.IsLeader() {
+		t.Fatal("should stand by while another instance holds the lock")
+	}
+
+	locker.acquired = true
+	e.check(ctx)
+	if !e.IsLeader() {
+		t.Fatal("should take over once the lock is free")
+	}
+
+	// A failed check must demote: the leader can no longer prove it holds
+	// the lock.
+	locker.err = errors.New("connection reset")
+	e.check(ctx)
+	if e.IsLeader() {
+		t.Fatal("should step down when the lock check fails")
+	}
+}
+
+func TestStandaloneIsAlwaysLeader(t *testing.T) {
+	e := Standalone()
+	if !e.IsLeader() {
+		t.Fatal("standalone elector must be leader")
+	}
+	e.Run(context.Background()) // must return immediately
+	if !e.IsLeader() {
+		t.Fatal("standalone elector must stay leader")
+	}
+}
+
+func TestLeaderContextEndsWithLeadership(t *testing.T) {
+	locker := &fakeLocker{}
+	e := New(locker, 0)
+	ctx := context.Background()
+
+	if _, cancel, ok := e.LeaderContext(ctx); ok {
+		cancel()
+		t.Fatal("LeaderContext succeeded on a standby")
+	}
+
+	locker.acquired = true
+	e.check(ctx)
+	leaderCtx, cancel, ok := e.LeaderContext(ctx)
+	if !ok {
+		t.Fatal("LeaderContext failed on the leader")
+	}
+	defer cancel()
+	if leaderCtx.Err() != nil {
+		t.Fatal("leader context cancelled while still leading")
+	}
+
+	locker.acquired = false
+	e.check(ctx)
+	select {
+	case <-leaderCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("leader context not cancelled when leadership was lost")
+	}
+
+	// A new term gets a fresh context
+	locker.acquired = true
+	e.check(ctx)
+	next, cancelNext, ok := e.LeaderContext(ctx)
+	if !ok || next.Err() != nil {
+		t.Fatal("leader context of a new term is not usable")
+	}
+	cancelNext()
+}
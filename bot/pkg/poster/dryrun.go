@@ -0,0 +1,60 @@
+package poster
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// dryRunPublisher logs the posts of a platform instead of publishing them
+type dryRunPublisher struct {
+	platform string
+	caps     Capabilities
+	posts    atomic.Int64
+}
+
+// NewDryRun creates a Poster that shapes posts for platforms as New would
+// but publishes nothing: images are not uploaded, links are not shortened
+// and each post is logged with a made-up post ID
+func NewDryRun(platforms ...string) (*Poster, error) {
+	publishers := make([]Publisher, 0, len(platforms))
+	for _, platform := range platforms {
+		var caps Capabilities
+		switch platform {
+		case PlatformThreads:
+			caps = threadsCapabilities
+		default:
+			return nil, fmt.Errorf("unknown platform %q", platform)
+		}
+		publishers = append(publishers, &dryRunPublisher{platform: platform, caps: caps})
+	}
+
+	log.WithContext("method", "NewDryRun").
+		Warn("Dry run: posts are logged, not published", "platforms", len(publishers))
+	return &Poster{publishers: publishers, dryRun: true}, nil
+}
+
+// Platform names the platform posts are shaped for
+func (d *dryRunPublisher) Platform() string {
+	return d.platform
+}
+
+// Capabilities of the platform
+func (d *dryRunPublisher) Capabilities() Capabilities {
+	return d.caps
+}
+
+// Publish logs post and returns a made-up post ID
+func (d *dryRunPublisher) Publish(ctx context.Context, post Post) (string, error) {
+	postID := fmt.Sprintf("dry-run-%s-%d", d.platform, d.posts.Add(1))
+	log.WithRequestContext(ctx).
+		WithContext("method", "dryRunPublisher.Publish").
+		WithContext("platform", d.platform).
+		Info("Dry run post",
+			"post_id", postID,
+			"text", post.Root.Text,
+			"images", len(post.Root.ImageURLs),
+			"chain", len(post.Chain),
+			"replies", len(post.Replies))
+	return postID, nil
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"bot/pkg/logger"
 	"bot/pkg/utils"
 
-	"github.com/tirthpatell/threads-go"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -18,309 +18,387 @@ import (
 var log = logger.Package("poster")
 
 const (
-	maxCharacterLimit    = 500
-	maxImagesPerPost     = 20
 	maxConcurrentUploads = 5
+	maxAltTextLength     = 1000
 	ellipsis             = "..."
-	TopicTag             = "F1Threads"
 )
 
-// Poster is a struct that holds the configuration for the poster
+// Poster publishes documents to every enabled platform. It uploads the page
+// images to Picsur and shortens the document link once, then shapes a post
+// for each publisher's capabilities and publishes them concurrently. Each
+// platform succeeds or fails on its own.
 type Poster struct {
-	ThreadsClient   *threads.Client
 	PicsurClient    *utils.Client
 	ShortenerClient *utils.ShortenerClient
+	publishers      []Publisher
+	dryRun          bool // see NewDryRun
 }
 
-// New creates a new Poster
-func New(accessToken, userID, clientID, clientSecret, redirectURI, picsurAPI, picsurURL, shortenerAPIKey, shortenerURL string) (*Poster, error) {
+// New creates a new Poster publishing to publishers, in that order
+func New(picsurAPI, picsurURL, shortenerAPIKey, shortenerURL string, publishers ...Publisher) *Poster {
 	ctxLog := log.WithContext("method", "New")
-	ctxLog.Info("Creating new poster client")
-
-	// Create threads client with existing token
-	threadsClient, err := threads.NewClientWithToken(accessToken, &threads.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURI:  redirectURI,
-	})
-	if err != nil {
-		ctxLog.Error("Failed to create threads client", "error", err)
-		return nil, fmt.Errorf("failed to create threads client: %w", err)
+	platforms := make([]string, 0, len(publishers))
+	for _, pub := range publishers {
+		platforms = append(platforms, pub.Platform())
 	}
-	ctxLog.Info("Threads client initialized successfully")
+	ctxLog.Info("Creating new poster", "platforms", strings.Join(platforms, ","))
 
 	return &Poster{
-		ThreadsClient:   threadsClient,
 		PicsurClient:    utils.New(picsurAPI, picsurURL),
 		ShortenerClient: utils.NewShortenerClient(shortenerAPIKey, shortenerURL),
-	}, nil
+		publishers:      publishers,
+	}
 }
 
-// Post posts the images to Threads. When more than maxImagesPerPost images are
-// provided, the post is split into a chain: the first chunk becomes the root
-// post (with the AI summary text); each subsequent chunk is posted as an
-// image-only reply to the previous post in the chain.
-//
-// Failure policy:
-//   - Root post failure: returns the error; caller skips marking the document
-//     as processed and will retry on the next scrape cycle.
-//   - Reply chunk failure: logs the failure with the root post ID and chunk
-//     index, then returns nil. The root post and any earlier replies remain
-//     published; the document is marked processed so we don't re-publish the
-//     root on the next cycle. Some tail images may be lost.
-func (p *Poster) Post(ctx context.Context, images [][]byte, title string, publishTime time.Time, documentURL, aiSummary string) error {
-	start := time.Now()
+// Platforms returns the names of the enabled platforms, in publishing order
+func (p *Poster) Platforms() []string {
+	platforms := make([]string, 0, len(p.publishers))
+	for _, pub := range p.publishers {
+		platforms = append(platforms, pub.Platform())
+	}
+	return platforms
+}
+
+// Result is the outcome of publishing on one platform
+type Result struct {
+	Platform string
+	PostID   string // root post ID; empty on error
+	Err      error
+}
+
+// PreparedPost is a document whose images are uploaded and whose post is
+// shaped for every platform, ready to be published
+type PreparedPost struct {
+	images []utils.UploadedImage
+	posts  map[string]Post // by platform
+}
+
+// Translation is the AI summary in another language, posted as a reply
+type Translation struct {
+	Language string // code, e.g. "es"
+	Text     string
+}
+
+// replyTemplate holds the labels of a translated summary reply
+type replyTemplate struct {
+	document string // precedes the title
+	summary  string // precedes the summary
+}
+
+// replyTemplates are keyed by language code; other languages use the English
+// labels
+var replyTemplates = map[string]replyTemplate{
+	"de": {document: "Dokument", summary: "KI-Zusammenfassung"},
+	"es": {document: "Documento", summary: "Resumen IA"},
+	"fr": {document: "Document", summary: "Résumé IA"},
+	"it": {document: "Documento", summary: "Riepilogo IA"},
+	"nl": {document: "Document", summary: "AI-samenvatting"},
+	"pt": {document: "Documento", summary: "Resumo IA"},
+}
+
+// Images returns the images uploaded for the post, for cleanup once they are
+// past retention
+func (pp *PreparedPost) Images() []utils.UploadedImage {
+	if pp == nil {
+		return nil
+	}
+	return pp.images
+}
+
+// document is the platform-neutral content of a document's post
+type document struct {
+	title        string
+	published    time.Time
+	link         string // shortened document URL; empty if shortening failed
+	summary      string
+	imageURLs    []string
+	translations []Translation
+}
+
+// Prepare does everything up to publishing: it uploads the images to Picsur,
+// shortens the document URL and shapes the post for each platform, with
+// replies carrying translations of the summary. Preparation is safe to run
+// concurrently for several documents; only Publish makes anything visible.
+func (p *Poster) Prepare(ctx context.Context, images [][]byte, title string, publishTime time.Time, documentURL, aiSummary string, translations []Translation) (*PreparedPost, error) {
 	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "Post")
+		WithContext("method", "Prepare")
 
 	if len(images) == 0 {
-		ctxLog.Warn("Post called with zero images; nothing to do")
-		return nil
+		ctxLog.Warn("Prepare called with zero images; nothing to post")
+		return &PreparedPost{}, nil
 	}
 
 	// Upload images to Picsur
 	ctxLog.Debug("Uploading images to Picsur", "count", len(images))
 	uploadStart := time.Now()
-	imageURLs, err := p.uploadImages(ctx, images)
+	uploaded, imageURLs, err := p.uploadImages(ctx, images)
 	uploadDuration := time.Since(uploadStart)
 
 	if err != nil {
 		ctxLog.ErrorWithType("Failed to upload images", err,
 			"upload_duration_ms", uploadDuration.Milliseconds())
-		return err
+		return nil, err
 	}
 
+	doc := document{
+		title:        title,
+		published:    publishTime,
+		link:         p.shortenURL(ctx, documentURL),
+		summary:      aiSummary,
+		imageURLs:    imageURLs,
+		translations: translations,
+	}
 	ctxLog.Info("Images uploaded successfully",
-		"count", len(imageURLs),
+		"count", len(doc.imageURLs),
 		"upload_duration_ms", uploadDuration.Milliseconds())
 
-	// Format the text for the root post
-	ctxLog.Debug("Formatting post text")
-	postText, err := p.formatPostText(ctx, title, publishTime, documentURL, aiSummary)
-	if err != nil {
-		ctxLog.ErrorWithType("Failed to format post text", err)
-		return err
+	posts := make(map[string]Post, len(p.publishers))
+	for _, pub := range p.publishers {
+		caps := pub.Capabilities()
+		posts[pub.Platform()] = documentPost(caps, doc)
+		if !caps.ReplyChains && len(doc.imageURLs) > caps.MaxImages {
+			ctxLog.Warn("Platform has no reply chains, leaving out images",
+				"platform", pub.Platform(),
+				"images", len(doc.imageURLs),
+				"max_images", caps.MaxImages)
+		}
 	}
-	ctxLog.Debug("Post character count", "chars", utf8.RuneCountInString(postText))
+	return &PreparedPost{images: uploaded, posts: posts}, nil
+}
 
-	// Partition images into chunks of ≤ maxImagesPerPost
-	chunks := chunkURLs(imageURLs, maxImagesPerPost)
-	ctxLog.Info("Posting to Threads",
-		"image_count", len(imageURLs),
-		"chunk_count", len(chunks))
+// Publish publishes a prepared post on every platform not in published
+// (platforms that already have the document, e.g. from an earlier attempt)
+// and returns the outcome per platform, in publisher order. When a document
+// has more images than a platform's posts hold, the first chunk becomes the
+// root post with the text and each further chunk an image-only reply to the
+// previous post. Translated summaries are posted as replies to the root.
+//
+// Failure policy, per platform:
+//   - Root post failure: the platform's Result has the error; the caller
+//     retries the document on that platform only.
+//   - Chain or reply failure: logged by the publisher; the root is already
+//     published, so the platform counts as done. Some tail images or
+//     translations may be lost.
+func (p *Poster) Publish(ctx context.Context, prepared *PreparedPost, published map[string]string) []Result {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "Publish")
 
-	// Post the root chunk
-	postStart := time.Now()
-	rootPost, err := p.postChunk(ctx, chunks[0], postText, "")
-	if err != nil {
-		ctxLog.ErrorWithType("Failed to post root chunk to Threads", err,
-			"chunk_size", len(chunks[0]),
-			"upload_duration_ms", uploadDuration.Milliseconds(),
-			"total_duration_ms", time.Since(start).Milliseconds())
-		return err
-	}
-	ctxLog.Info("Root post published", "post_id", rootPost.ID, "images", len(chunks[0]))
-
-	// Chain replies for the remaining chunks. chunk_index in logs is 1-based;
-	// the root post is chunk 1, the first reply is chunk 2, etc.
-	prevID := rootPost.ID
-	for i := 1; i < len(chunks); i++ {
-		replyPost, replyErr := p.postChunk(ctx, chunks[i], "", prevID)
-		if replyErr != nil {
-			// Loss-tolerant: log loudly, stop the chain, but do not fail the
-			// whole Post() call. Caller will mark the document as processed so
-			// we don't re-publish the root on the next cycle.
-			ctxLog.ErrorWithType("Failed to post reply chunk; remaining images dropped", replyErr,
-				"root_post_id", rootPost.ID,
-				"chunk_index", i+1,
-				"total_chunks", len(chunks),
-				"chunk_size", len(chunks[i]),
-				"dropped_chunks", len(chunks)-i)
-			break
+	if prepared == nil || len(prepared.posts) == 0 {
+		ctxLog.Warn("Publish called with zero images; nothing to do")
+		return nil
+	}
+	return p.fanOut(ctx, published, func(pub Publisher) Post {
+		return prepared.posts[pub.Platform()]
+	})
+}
+
+// PublishText posts a text-only message on every platform not in published
+// and returns the outcome per platform
+func (p *Poster) PublishText(ctx context.Context, text string, published map[string]string) []Result {
+	return p.fanOut(ctx, published, func(pub Publisher) Post {
+		caps := pub.Capabilities()
+		if utf8.RuneCountInString(text) > caps.MaxChars {
+			log.WithRequestContext(ctx).
+				WithContext("method", "PublishText").
+				Warn("Truncating text due to character limit",
+					"platform", pub.Platform(),
+					"original", utf8.RuneCountInString(text),
+					"limit", caps.MaxChars)
 		}
-		ctxLog.Info("Reply chunk published",
-			"post_id", replyPost.ID,
-			"reply_to", prevID,
-			"chunk_index", i+1,
-			"total_chunks", len(chunks),
-			"images", len(chunks[i]))
-		prevID = replyPost.ID
-	}
-
-	totalDuration := time.Since(start)
-	ctxLog.Info("Post to Threads completed",
-		"chunks_total", len(chunks),
-		"posting_duration_ms", time.Since(postStart).Milliseconds(),
-		"upload_duration_ms", uploadDuration.Milliseconds(),
-		"total_duration_ms", totalDuration.Milliseconds())
-
-	return nil
+		return Post{Root: Message{Text: truncateText(text, caps.MaxChars)}}
+	})
 }
 
-// PostTextOnly posts a text-only message to Threads without any media
-func (p *Poster) PostTextOnly(ctx context.Context, text string) error {
-	start := time.Now()
+// DigestEntry is one document listed in a catch-up digest
+type DigestEntry struct {
+	Title       string
+	Published   time.Time
+	DocumentURL string
+	Summary     string
+}
+
+// PostDigest posts a catch-up digest on every platform not in published:
+// intro as the root post, then a chain with one post per entry formatted
+// like a regular document post. Platforms without reply chains get the intro
+// only. Returns the outcome per platform, as Publish.
+func (p *Poster) PostDigest(ctx context.Context, intro string, entries []DigestEntry, published map[string]string) []Result {
+	docs := make([]document, 0, len(entries))
+	for _, e := range entries {
+		docs = append(docs, document{
+			title:     e.Title,
+			published: e.Published,
+			link:      p.shortenURL(ctx, e.DocumentURL),
+			summary:   e.Summary,
+		})
+	}
+
+	return p.fanOut(ctx, published, func(pub Publisher) Post {
+		return digestPost(pub.Capabilities(), intro, docs)
+	})
+}
+
+// fanOut publishes the post built for each publisher not in published,
+// concurrently, and returns the outcomes in publisher order
+func (p *Poster) fanOut(ctx context.Context, published map[string]string, build func(Publisher) Post) []Result {
 	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "PostTextOnly")
+		WithContext("method", "fanOut")
+
+	var pending []Publisher
+	for _, pub := range p.publishers {
+		if _, ok := published[pub.Platform()]; ok {
+			ctxLog.Debug("Already published, skipping platform", "platform", pub.Platform())
+			continue
+		}
+		pending = append(pending, pub)
+	}
 
-	// Truncate text if it exceeds the character limit
-	if utf8.RuneCountInString(text) > maxCharacterLimit {
-		ctxLog.Warn("Truncating text due to character limit", "original", utf8.RuneCountInString(text), "limit", maxCharacterLimit)
-		text = truncateText(text, maxCharacterLimit)
+	results := make([]Result, len(pending))
+	var wg sync.WaitGroup
+	for i, pub := range pending {
+		wg.Go(func() {
+			postID, err := pub.Publish(ctx, build(pub))
+			results[i] = Result{Platform: pub.Platform(), PostID: postID, Err: err}
+			if err != nil {
+				ctxLog.ErrorWithType("Failed to publish", err, "platform", pub.Platform())
+			} else {
+				ctxLog.Info("Published", "platform", pub.Platform(), "post_id", postID)
+			}
+		})
 	}
+	wg.Wait()
+	return results
+}
 
-	ctxLog.Info("Posting text-only message to Threads")
+// documentPost shapes a document's post for a platform: the images in
+// chunks of caps.MaxImages, the first with the post text, and the
+// translations as replies
+func documentPost(caps Capabilities, doc document) Post {
+	var alts []string
+	if caps.AltText {
+		for i := range doc.imageURLs {
+			alts = append(alts, truncateText(fmt.Sprintf("Page %d of %d of FIA document: %s", i+1, len(doc.imageURLs), doc.title), maxAltTextLength))
+		}
+	}
 
-	// Use the threads-go client to create text post
-	_, err := p.ThreadsClient.CreateTextPost(ctx, &threads.TextPostContent{
-		Text:     text,
-		TopicTag: TopicTag,
-	})
-	duration := time.Since(start)
+	var messages []Message
+	for i, chunk := range chunkURLs(doc.imageURLs, caps.MaxImages) {
+		msg := Message{ImageURLs: chunk}
+		if alts != nil {
+			start := i * caps.MaxImages
+			msg.AltTexts = alts[start : start+len(chunk)]
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) == 0 {
+		messages = []Message{{}}
+	}
+	messages[0].Text = formatPostText(doc.title, doc.published, doc.link, doc.summary, caps.MaxChars)
 
-	if err != nil {
-		ctxLog.ErrorWithType("Failed to create text-only post", err,
-			"duration_ms", duration.Milliseconds())
-		return fmt.Errorf("failed to create text-only post: %v", err)
+	post := Post{Root: messages[0]}
+	if !caps.ReplyChains {
+		return post
+	}
+	post.Chain = messages[1:]
+	for _, t := range doc.translations {
+		if t.Text != "" {
+			post.Replies = append(post.Replies, Message{Text: formatReplyText(t.Language, doc.title, t.Text, caps.MaxChars)})
+		}
 	}
+	return post
+}
 
-	ctxLog.Info("Text-only message posted successfully",
-		"duration_ms", duration.Milliseconds())
-	return nil
+// digestPost shapes a catch-up digest for a platform: the intro, then one
+// text post per document with its link as a card where the platform has
+// them
+func digestPost(caps Capabilities, intro string, docs []document) Post {
+	post := Post{Root: Message{Text: truncateText(intro, caps.MaxChars)}}
+	if !caps.ReplyChains {
+		return post
+	}
+	for _, doc := range docs {
+		msg := Message{Text: formatPostText(doc.title, doc.published, doc.link, doc.summary, caps.MaxChars)}
+		if caps.LinkCards {
+			msg.Link = doc.link
+		}
+		post.Chain = append(post.Chain, msg)
+	}
+	return post
 }
 
 // uploadImages uploads PNG-encoded images to Picsur in parallel (bounded by
-// maxConcurrentUploads) and returns their URLs in the original order. The
-// first upload error cancels the remaining uploads via the errgroup context.
-func (p *Poster) uploadImages(ctx context.Context, images [][]byte) ([]string, error) {
+// maxConcurrentUploads) and returns them and their URLs in the original
+// order. The first upload error cancels the remaining uploads via the
+// errgroup context. A dry run uploads nothing and returns placeholder URLs.
+func (p *Poster) uploadImages(ctx context.Context, images [][]byte) ([]utils.UploadedImage, []string, error) {
 	ctxLog := log.WithRequestContext(ctx).
 		WithContext("method", "uploadImages").
 		WithContext("imageCount", len(images))
 
-	imageURLs := make([]string, len(images))
+	if p.dryRun {
+		urls := make([]string, len(images))
+		for i := range images {
+			urls[i] = fmt.Sprintf("https://dry-run.invalid/page-%d.png", i+1)
+		}
+		return nil, urls, nil
+	}
+
+	uploaded := make([]utils.UploadedImage, len(images))
 	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxConcurrentUploads)
 
 	for i, img := range images {
 		g.Go(func() error {
 			ctxLog.Debug("Uploading image", "index", i+1)
-			url, err := p.PicsurClient.UploadImage(gctx, img)
+			image, err := p.PicsurClient.UploadImage(gctx, img)
 			if err != nil {
 				ctxLog.Error("Failed to upload image", "index", i+1, "error", err)
 				return fmt.Errorf("failed to upload image %d: %v", i+1, err)
 			}
-			imageURLs[i] = url
+			uploaded[i] = image
 			ctxLog.Debug("Uploaded image", "index", i+1, "total", len(images))
 			return nil
 		})
 	}
 
 	if err := g.Wait(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	ctxLog.Info("All images uploaded successfully", "count", len(imageURLs))
-	return imageURLs, nil
-}
-
-// postSingleImage posts a single image to Threads. If replyToID is non-empty,
-// the post is created as a reply to that post.
-func (p *Poster) postSingleImage(ctx context.Context, imageURL, postText, replyToID string) (*threads.Post, error) {
-	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "postSingleImage")
-
-	ctxLog.Debug("Creating single image post", "url", imageURL, "reply_to", replyToID)
-
-	post, err := p.ThreadsClient.CreateImagePost(ctx, &threads.ImagePostContent{
-		Text:     postText,
-		ImageURL: imageURL,
-		ReplyTo:  replyToID,
-		TopicTag: topicTagForReply(replyToID),
-	})
-	if err != nil {
-		ctxLog.Error("Failed to create image post", "error", err)
-		return nil, fmt.Errorf("failed to create image post: %v", err)
+	urls := make([]string, len(uploaded))
+	for i, img := range uploaded {
+		urls[i] = img.URL
 	}
-
-	ctxLog.Debug("Successfully posted single image", "post_id", post.ID)
-	return post, nil
+	ctxLog.Info("All images uploaded successfully", "count", len(uploaded))
+	return uploaded, urls, nil
 }
 
-// postCarousel posts multiple images as a carousel to Threads. If replyToID
-// is non-empty, the carousel is posted as a reply to that post.
-func (p *Poster) postCarousel(ctx context.Context, imageURLs []string, postText, replyToID string) (*threads.Post, error) {
-	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "postCarousel").
-		WithContext("imageCount", len(imageURLs))
-
-	var containerIDs []string
-
-	for i, imageURL := range imageURLs {
-		ctxLog.Debug("Creating media container for carousel image", "index", i+1)
-		containerID, err := p.ThreadsClient.CreateMediaContainer(ctx, threads.MediaTypeImage, imageURL, "")
-		if err != nil {
-			ctxLog.Error("Failed to create media container", "index", i+1, "error", err)
-			return nil, fmt.Errorf("failed to create media container: %v", err)
-		}
-		containerIDs = append(containerIDs, string(containerID))
+// shortenURL shortens the document URL; on failure the post goes out
+// without a link. A dry run keeps the URL as it is.
+func (p *Poster) shortenURL(ctx context.Context, documentURL string) string {
+	if documentURL == "" || p.dryRun {
+		return documentURL
 	}
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "shortenURL")
 
-	ctxLog.Debug("Creating carousel post", "itemCount", len(containerIDs), "reply_to", replyToID)
-	post, err := p.ThreadsClient.CreateCarouselPost(ctx, &threads.CarouselPostContent{
-		Text:     postText,
-		Children: containerIDs,
-		ReplyTo:  replyToID,
-		TopicTag: topicTagForReply(replyToID),
-	})
+	ctxLog.Debug("Shortening document URL")
+	shortenedURL, err := p.ShortenerClient.ShortenURL(ctx, documentURL)
 	if err != nil {
-		ctxLog.Error("Failed to create carousel post", "error", err)
-		return nil, fmt.Errorf("failed to create carousel post: %v", err)
-	}
-
-	ctxLog.Debug("Successfully posted carousel", "post_id", post.ID)
-	return post, nil
-}
-
-// postChunk posts a single chunk of 1..maxImagesPerPost image URLs and returns
-// the resulting post. text is attached to the post (use "" for image-only
-// replies). replyToID, when non-empty, makes this a reply to that post.
-func (p *Poster) postChunk(ctx context.Context, imageURLs []string, text, replyToID string) (*threads.Post, error) {
-	switch n := len(imageURLs); {
-	case n == 1:
-		return p.postSingleImage(ctx, imageURLs[0], text, replyToID)
-	case n >= 2 && n <= maxImagesPerPost:
-		return p.postCarousel(ctx, imageURLs, text, replyToID)
-	default:
-		// Unreachable from Post (chunkURLs guarantees 1..maxImagesPerPost);
-		// retained as defense for any future direct caller.
-		return nil, fmt.Errorf("invalid chunk size: %d (must be 1..%d)", n, maxImagesPerPost)
+		ctxLog.Error("Failed to shorten URL", "error", err)
+		ctxLog.Warn("Continuing without shortened URL")
+		return ""
 	}
+	return shortenedURL
 }
 
-// formatPostText formats the text for a post
-func (p *Poster) formatPostText(ctx context.Context, title string, publishTime time.Time, documentURL, aiSummary string) (string, error) {
-	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "formatPostText")
-
-	// Shorten the document URL if provided
-	var shortenedURL string
-	var err error
-
-	if documentURL != "" {
-		ctxLog.Debug("Shortening document URL")
-		shortenedURL, err = p.ShortenerClient.ShortenURL(ctx, documentURL)
-		if err != nil {
-			ctxLog.Error("Failed to shorten URL", "error", err)
-			// Continue without the shortened URL
-			ctxLog.Warn("Continuing without shortened URL")
-		}
-	}
-
+// formatPostText formats the text for a post of at most limit characters.
+// link is the shortened document URL, left out when empty.
+func formatPostText(title string, publishTime time.Time, link, aiSummary string, limit int) string {
 	// Create the base text with or without the shortened URL
 	var baseText string
-	if shortenedURL != "" {
+	if link != "" {
 		baseText = fmt.Sprintf("New document: %s\nPublished on: %s\nLink: %s",
-			title, publishTime.Format("02-01-2006 15:04 MST"), shortenedURL)
+			title, publishTime.Format("02-01-2006 15:04 MST"), link)
 	} else {
 		baseText = fmt.Sprintf("New document: %s\nPublished on: %s",
 			title, publishTime.Format("02-01-2006 15:04 MST"))
@@ -331,7 +409,7 @@ func (p *Poster) formatPostText(ctx context.Context, title string, publishTime t
 	// is room for it — the final truncation below would otherwise cut the
 	// text mid-label.
 	const summaryLabel = "\n\nAI Summary: "
-	remainingChars := maxCharacterLimit - utf8.RuneCountInString(baseText) - utf8.RuneCountInString(summaryLabel)
+	remainingChars := limit - utf8.RuneCountInString(baseText) - utf8.RuneCountInString(summaryLabel)
 
 	text := baseText
 	if aiSummary != "" && remainingChars > 0 {
@@ -340,11 +418,31 @@ func (p *Poster) formatPostText(ctx context.Context, title string, publishTime t
 
 	// Final guard: an unusually long title can push baseText itself past the
 	// limit, so truncate the assembled text as a whole.
-	return truncateText(text, maxCharacterLimit), nil
+	return truncateText(text, limit)
+}
+
+// formatReplyText formats a translated summary reply with the labels of its
+// language. The title is kept in full where it fits and the summary cut to
+// the room left, as in formatPostText.
+func formatReplyText(language, title, summary string, limit int) string {
+	labels, ok := replyTemplates[language]
+	if !ok {
+		labels = replyTemplate{document: "Document", summary: "AI Summary"}
+	}
+
+	baseText := labels.document + ": " + title
+	summaryLabel := "\n\n" + labels.summary + ": "
+	remainingChars := limit - utf8.RuneCountInString(baseText) - utf8.RuneCountInString(summaryLabel)
+
+	text := baseText
+	if remainingChars > 0 {
+		text += summaryLabel + truncateText(summary, remainingChars)
+	}
+	return truncateText(text, limit)
 }
 
 // truncateText truncates text to the specified limit (counted in runes, since
-// the Threads limit is characters, not bytes), adding an ellipsis.
+// platform limits are characters, not bytes), adding an ellipsis.
 func truncateText(text string, limit int) string {
 	runes := []rune(text)
 	if len(runes) <= limit {
@@ -368,16 +466,6 @@ func truncateText(text string, limit int) string {
 	return truncated[:lastSpace] + ellipsis
 }
 
-// topicTagForReply returns the topic tag to apply to a post: TopicTag for the
-// root post (empty replyToID), and "" for replies — the Threads API only
-// allows topic tags on root posts, not on replies.
-func topicTagForReply(replyToID string) string {
-	if replyToID == "" {
-		return TopicTag
-	}
-	return ""
-}
-
 // chunkURLs partitions urls into consecutive slices of length ≤ size.
 // Returns nil for an empty input or non-positive size. The last chunk may be
 // shorter than size.
@@ -1,9 +1,14 @@
 package poster
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func TestChunkURLs(t *testing.T) {
@@ -77,3 +82,125 @@ func makeURLsFrom(start, end int) []string {
 	}
 	return out
 }
+
+// fakePublisher records the posts it is given
+type fakePublisher struct {
+	platform string
+	caps     Capabilities
+	err      error
+
+	mu    sync.Mutex
+	posts []Post
+}
+
+func (f *fakePublisher) Platform() string           { return f.platform }
+func (f *fakePublisher) Capabilities() Capabilities { return f.caps }
+
+func (f *fakePublisher) Publish(ctx context.Context, post Post) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", f.err
+	}
+	f.posts = append(f.posts, post)
+	return f.platform + "-" + strconv.Itoa(len(f.posts)), nil
+}
+
+func TestDocumentPost(t *testing.T) {
+	doc := document{
+		title:        "Doc 12 - Car 44",
+		published:    time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC),
+		link:         "https://short.example/a",
+		summary:      "Steward summary text.",
+		imageURLs:    makeURLs(21),
+		translations: []Translation{{Language: "es", Text: "Resumen."}, {Language: "fr"}},
+	}
+
+	threads := (&Threads{}).Capabilities()
+	post := documentPost(threads, doc)
+	if len(post.Root.ImageURLs) != 20 || len(post.Chain) != 1 || !reflect.DeepEqual(post.Chain[0].ImageURLs, []string{"url20"}) {
+		t.Errorf("images = %d in the root and chain %+v, want 20 and 1", len(post.Root.ImageURLs), post.Chain)
+	}
+	if post.Chain[0].Text != "" {
+		t.Errorf("chain text = %q, want image-only", post.Chain[0].Text)
+	}
+	if len(post.Root.AltTexts) != 20 || post.Chain[0].AltTexts[0] != "Page 21 of 21 of FIA document: Doc 12 - Car 44" {
+		t.Errorf("alt texts = %q and %q", post.Root.AltTexts, post.Chain[0].AltTexts)
+	}
+	if len(post.Replies) != 1 || post.Replies[0].Text != "Documento: Doc 12 - Car 44\n\nResumen IA: Resumen." {
+		t.Errorf("replies = %+v, want the Spanish translation only", post.Replies)
+	}
+
+	small := Capabilities{MaxChars: 100, MaxImages: 4}
+	post = documentPost(small, doc)
+	if len(post.Root.ImageURLs) != 4 || post.Chain != nil || post.Replies != nil || post.Root.AltTexts != nil {
+		t.Errorf("post = %+v, want 4 images without chain, replies or alt texts", post)
+	}
+	if n := utf8.RuneCountInString(post.Root.Text); n > small.MaxChars {
+		t.Errorf("text is %d runes, want <= %d", n, small.MaxChars)
+	}
+
+	doc.imageURLs = nil
+	if post := documentPost(threads, doc); post.Root.Text == "" || len(post.Root.ImageURLs) != 0 || len(post.Chain) != 0 {
+		t.Errorf("post without images = %+v, want a text root", post)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	threads := &fakePublisher{platform: "threads", caps: (&Threads{}).Capabilities()}
+	failing := &fakePublisher{platform: "failing", caps: Capabilities{MaxChars: 300, MaxImages: 4}, err: errors.New("unavailable")}
+	done := &fakePublisher{platform: "done", caps: Capabilities{MaxChars: 300, MaxImages: 4}}
+	p := &Poster{publishers: []Publisher{threads, failing, done}}
+
+	prepared := &PreparedPost{posts: map[string]Post{
+		"threads": {Root: Message{Text: "threads"}},
+		"failing": {Root: Message{Text: "failing"}},
+		"done":    {Root: Message{Text: "done"}},
+	}}
+	results := p.Publish(context.Background(), prepared, map[string]string{"done": "done-0"})
+	if len(results) != 2 || results[0].Platform != "threads" || results[0].PostID != "threads-1" || results[0].Err != nil ||
+		results[1].Platform != "failing" || results[1].Err == nil {
+		t.Errorf("results = %+v, want threads published and failing failed", results)
+	}
+	if len(done.posts) != 0 {
+		t.Error("published again on a platform that already had the document")
+	}
+	if len(threads.posts) != 1 || threads.posts[0].Root.Text != "threads" {
+		t.Errorf("threads posts = %+v, want its own post", threads.posts)
+	}
+
+	if results := p.Publish(context.Background(), &PreparedPost{}, nil); results != nil {
+		t.Errorf("empty post results = %+v, want nil", results)
+	}
+	if !reflect.DeepEqual(p.Platforms(), []string{"threads", "failing", "done"}) {
+		t.Errorf("Platforms() = %v", p.Platforms())
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewDryRun("mastodon"); err == nil {
+		t.Error("NewDryRun accepted an unknown platform")
+	}
+
+	p, err := NewDryRun(PlatformThreads)
+	if err != nil {
+		t.Fatalf("NewDryRun: %v", err)
+	}
+	published := time.Date(2026, 5, 24, 12, 0, 0, 0, time.UTC)
+	prepared, err := p.Prepare(ctx, [][]byte{{1}, {2}}, "Doc 12 - Decision - Car 4", published, "https://fia.example/doc12.pdf", "Car 4 reprimanded.", nil)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if images := prepared.Images(); len(images) != 0 {
+		t.Errorf("dry run uploaded %d images", len(images))
+	}
+
+	results := p.Publish(ctx, prepared, map[string]string{})
+	if len(results) != 1 || results[0].Err != nil || results[0].PostID != "dry-run-threads-1" {
+		t.Errorf("results = %+v, want one made-up post ID", results)
+	}
+	if post := prepared.posts[PlatformThreads]; len(post.Root.ImageURLs) != 2 {
+		t.Errorf("root images = %v, want placeholders for both pages", post.Root.ImageURLs)
+	}
+}
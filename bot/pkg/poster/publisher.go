@@ -0,0 +1,45 @@
+package poster
+
+import "context"
+
+// Capabilities describe what a platform's posts can hold. The Poster shapes
+// each platform's post to them: text is cut to MaxChars, images beyond
+// MaxImages go into a reply chain or, without reply chains, are left out.
+type Capabilities struct {
+	MaxChars    int  // characters per post
+	MaxImages   int  // images per post
+	ReplyChains bool // posts can reply to posts; translations are replies
+	AltText     bool // images can carry a description
+	LinkCards   bool // a text post's link can be shown as a preview card
+}
+
+// Message is a single post
+type Message struct {
+	Text      string
+	ImageURLs []string
+	AltTexts  []string // descriptions of ImageURLs, on platforms with AltText
+	Link      string   // attached as a card to text posts, on platforms with LinkCards
+}
+
+// Post is what a publisher posts for a document: the root post, a chain of
+// posts each replying to the one before, and replies to the root
+type Post struct {
+	Root    Message
+	Chain   []Message
+	Replies []Message
+}
+
+// Publisher posts to a social platform
+type Publisher interface {
+	// Platform names the platform, e.g. "threads"
+	Platform() string
+
+	// Capabilities returns what the platform's posts can hold
+	Capabilities() Capabilities
+
+	// Publish posts post and returns the root post ID. Only a failed root
+	// post is an error: once the root is public, a chain failure drops the
+	// rest of the chain and a reply failure that reply, and both are only
+	// logged so the document is not posted twice.
+	Publish(ctx context.Context, post Post) (string, error)
+}
@@ -0,0 +1,214 @@
+package poster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tirthpatell/threads-go"
+)
+
+const (
+	PlatformThreads = "threads"
+	TopicTag        = "F1Threads"
+
+	threadsCharacterLimit = 500
+	threadsImagesPerPost  = 20
+)
+
+// Threads publishes to Threads
+type Threads struct {
+	Client *threads.Client
+}
+
+// NewThreads creates a Threads publisher with an existing access token
+func NewThreads(accessToken, clientID, clientSecret, redirectURI string) (*Threads, error) {
+	ctxLog := log.WithContext("method", "NewThreads")
+
+	client, err := threads.NewClientWithToken(accessToken, &threads.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+	})
+	if err != nil {
+		ctxLog.Error("Failed to create threads client", "error", err)
+		return nil, fmt.Errorf("failed to create threads client: %w", err)
+	}
+	ctxLog.Info("Threads client initialized successfully")
+	return &Threads{Client: client}, nil
+}
+
+// Platform is PlatformThreads
+func (t *Threads) Platform() string {
+	return PlatformThreads
+}
+
+// threadsCapabilities are carousels of up to 20 images with alt text,
+// reply chains, and link cards on text posts
+var threadsCapabilities = Capabilities{
+	MaxChars:    threadsCharacterLimit,
+	MaxImages:   threadsImagesPerPost,
+	ReplyChains: true,
+	AltText:     true,
+	LinkCards:   true,
+}
+
+// Capabilities of Threads
+func (t *Threads) Capabilities() Capabilities {
+	return threadsCapabilities
+}
+
+// Publish posts the root, then the chain, each post replying to the one
+// before, then the replies to the root. The topic tag goes on the root only.
+func (t *Threads) Publish(ctx context.Context, post Post) (string, error) {
+	start := time.Now()
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "Publish").
+		WithContext("platform", PlatformThreads)
+
+	root, err := t.postMessage(ctx, post.Root, "")
+	if err != nil {
+		ctxLog.ErrorWithType("Failed to post root to Threads", err,
+			"images", len(post.Root.ImageURLs),
+			"total_duration_ms", time.Since(start).Milliseconds())
+		return "", err
+	}
+	ctxLog.Info("Root post published", "post_id", root.ID, "images", len(post.Root.ImageURLs))
+
+	// chain_index in logs is 1-based; the root post is 1, its first reply 2
+	prevID := root.ID
+	for i, msg := range post.Chain {
+		reply, err := t.postMessage(ctx, msg, prevID)
+		if err != nil {
+			ctxLog.ErrorWithType("Failed to post chain reply; rest of the chain dropped", err,
+				"root_post_id", root.ID,
+				"chain_index", i+2,
+				"chain_length", len(post.Chain)+1,
+				"dropped", len(post.Chain)-i)
+			break
+		}
+		ctxLog.Info("Chain reply published",
+			"post_id", reply.ID,
+			"reply_to", prevID,
+			"chain_index", i+2,
+			"chain_length", len(post.Chain)+1,
+			"images", len(msg.ImageURLs))
+		prevID = reply.ID
+	}
+
+	for _, msg := range post.Replies {
+		reply, err := t.postMessage(ctx, msg, root.ID)
+		if err != nil {
+			ctxLog.ErrorWithType("Failed to post reply", err, "root_post_id", root.ID)
+			continue
+		}
+		ctxLog.Info("Reply published", "post_id", reply.ID, "reply_to", root.ID)
+	}
+
+	ctxLog.Info("Post to Threads completed",
+		"chain_length", len(post.Chain)+1,
+		"replies", len(post.Replies),
+		"posting_duration_ms", time.Since(start).Milliseconds())
+	return root.ID, nil
+}
+
+// postMessage posts msg as a text, single image or carousel post. If
+// replyToID is non-empty, the post is created as a reply to that post.
+func (t *Threads) postMessage(ctx context.Context, msg Message, replyToID string) (*threads.Post, error) {
+	switch n := len(msg.ImageURLs); {
+	case n == 0:
+		post, err := t.Client.CreateTextPost(ctx, &threads.TextPostContent{
+			Text:           msg.Text,
+			LinkAttachment: msg.Link,
+			ReplyTo:        replyToID,
+			TopicTag:       topicTagForReply(replyToID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create text post: %v", err)
+		}
+		return post, nil
+	case n == 1:
+		return t.postSingleImage(ctx, msg.ImageURLs[0], altText(msg.AltTexts, 0), msg.Text, replyToID)
+	case n <= threadsImagesPerPost:
+		return t.postCarousel(ctx, msg.ImageURLs, msg.AltTexts, msg.Text, replyToID)
+	default:
+		// Unreachable from the Poster, which chunks images by MaxImages;
+		// retained as defense for any future direct caller.
+		return nil, fmt.Errorf("invalid image count: %d (must be at most %d)", n, threadsImagesPerPost)
+	}
+}
+
+// postSingleImage posts a single image to Threads
+func (t *Threads) postSingleImage(ctx context.Context, imageURL, alt, postText, replyToID string) (*threads.Post, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "postSingleImage")
+
+	ctxLog.Debug("Creating single image post", "url", imageURL, "reply_to", replyToID)
+
+	post, err := t.Client.CreateImagePost(ctx, &threads.ImagePostContent{
+		Text:     postText,
+		ImageURL: imageURL,
+		AltText:  alt,
+		ReplyTo:  replyToID,
+		TopicTag: topicTagForReply(replyToID),
+	})
+	if err != nil {
+		ctxLog.Error("Failed to create image post", "error", err)
+		return nil, fmt.Errorf("failed to create image post: %v", err)
+	}
+
+	ctxLog.Debug("Successfully posted single image", "post_id", post.ID)
+	return post, nil
+}
+
+// postCarousel posts multiple images as a carousel to Threads
+func (t *Threads) postCarousel(ctx context.Context, imageURLs, altTexts []string, postText, replyToID string) (*threads.Post, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "postCarousel").
+		WithContext("imageCount", len(imageURLs))
+
+	var containerIDs []string
+
+	for i, imageURL := range imageURLs {
+		ctxLog.Debug("Creating media container for carousel image", "index", i+1)
+		containerID, err := t.Client.CreateMediaContainer(ctx, threads.MediaTypeImage, imageURL, altText(altTexts, i))
+		if err != nil {
+			ctxLog.Error("Failed to create media container", "index", i+1, "error", err)
+			return nil, fmt.Errorf("failed to create media container: %v", err)
+		}
+		containerIDs = append(containerIDs, string(containerID))
+	}
+
+	ctxLog.Debug("Creating carousel post", "itemCount", len(containerIDs), "reply_to", replyToID)
+	post, err := t.Client.CreateCarouselPost(ctx, &threads.CarouselPostContent{
+		Text:     postText,
+		Children: containerIDs,
+		ReplyTo:  replyToID,
+		TopicTag: topicTagForReply(replyToID),
+	})
+	if err != nil {
+		ctxLog.Error("Failed to create carousel post", "error", err)
+		return nil, fmt.Errorf("failed to create carousel post: %v", err)
+	}
+
+	ctxLog.Debug("Successfully posted carousel", "post_id", post.ID)
+	return post, nil
+}
+
+// altText returns the i-th alt text, or "" if there is none
+func altText(altTexts []string, i int) string {
+	if i < len(altTexts) {
+		return altTexts[i]
+	}
+	return ""
+}
+
+// topicTagForReply returns the topic tag to apply to a post: TopicTag for the
+// root post (empty replyToID), and "" for replies — the Threads API only
+// allows topic tags on root posts, not on replies.
+func topicTagForReply(replyToID string) string {
+	if replyToID == "" {
+		return TopicTag
+	}
+	return ""
+}
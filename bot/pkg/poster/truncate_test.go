@@ -1,7 +1,6 @@
 package poster
 
 import (
-	"context"
 	"strings"
 	"testing"
 	"time"
@@ -37,10 +36,9 @@ func TestTruncateText(t *testing.T) {
 	}
 }
 
-// formatPostText must never produce text over the Threads character limit,
-// even when a very long title leaves no room for the summary section.
+// formatPostText must never produce text over the character limit, even when
+// a very long title leaves no room for the summary section.
 func TestFormatPostTextRespectsCharacterLimit(t *testing.T) {
-	p := &Poster{} // documentURL is empty in all cases, so no clients are used
 	publishTime := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
 
 	tests := []struct {
@@ -56,12 +54,9 @@ func TestFormatPostTextRespectsCharacterLimit(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := p.formatPostText(context.Background(), tt.title, publishTime, "", tt.summary)
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
-			}
-			if n := utf8.RuneCountInString(got); n > maxCharacterLimit {
-				t.Errorf("post text is %d runes, want <= %d", n, maxCharacterLimit)
+			got := formatPostText(tt.title, publishTime, "", tt.summary, threadsCharacterLimit)
+			if n := utf8.RuneCountInString(got); n > threadsCharacterLimit {
+				t.Errorf("post text is %d runes, want <= %d", n, threadsCharacterLimit)
 			}
 			if strings.HasSuffix(got, "AI Summary: ") {
 				t.Error("dangling AI Summary label")
@@ -69,3 +64,21 @@ func TestFormatPostTextRespectsCharacterLimit(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatReplyText(t *testing.T) {
+	got := formatReplyText("es", "Doc 12 - Car 44", "Resumen.", threadsCharacterLimit)
+	if got != "Documento: Doc 12 - Car 44\n\nResumen IA: Resumen." {
+		t.Errorf("formatReplyText = %q", got)
+	}
+	if got := formatReplyText("xx", "Doc 12", "Summary.", threadsCharacterLimit); got != "Document: Doc 12\n\nAI Summary: Summary." {
+		t.Errorf("unknown language: formatReplyText = %q, want the English labels", got)
+	}
+
+	got = formatReplyText("pt", "Doc 12", strings.Repeat("palavra ", 100), threadsCharacterLimit)
+	if n := utf8.RuneCountInString(got); n > threadsCharacterLimit || !strings.HasSuffix(got, ellipsis) {
+		t.Errorf("long summary: %d runes, want a truncated reply within %d", n, threadsCharacterLimit)
+	}
+	if got := formatReplyText("it", strings.Repeat("x", 520), "Riepilogo.", threadsCharacterLimit); utf8.RuneCountInString(got) > threadsCharacterLimit {
+		t.Errorf("long title: %d runes, want <= %d", utf8.RuneCountInString(got), threadsCharacterLimit)
+	}
+}
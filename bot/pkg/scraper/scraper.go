@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,9 +23,9 @@ import (
 var log = logger.Package("scraper")
 
 type Document struct {
-	Title     string
-	URL       string
-	Published time.Time
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
 }
 
 type Scraper struct {
@@ -269,6 +271,81 @@ func (s *Scraper) IsRecalledDocument(doc Document) bool {
 		strings.Contains(strings.ToLower(doc.Title), "recalled -")
 }
 
+// docNumberPattern matches the "Doc 12" prefix of FIA document titles
+var docNumberPattern = regexp.MustCompile(`(?i)\bdoc(?:ument)?\s*(\d+)\b`)
+
+// DocumentNumber returns the FIA document number from a title such as
+// "Doc 12 - Summons - Car 4", or false if the title carries none.
+func DocumentNumber(title string) (int, bool) {
+	m := docNumberPattern.FindStringSubmatch(title)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Document types, as classified from the title by DocumentType
+const (
+	TypeSummons        = "summons"
+	TypeDecision       = "decision"
+	TypeTechnical      = "technical"
+	TypeClassification = "classification"
+	TypeOther          = "other"
+)
+
+// documentTypeKeywords classifies titles; the first type with a matching
+// keyword wins, so a "Decision - Technical infringement" is a decision.
+var documentTypeKeywords = []struct {
+	docType  string
+	keywords []string
+}{
+	{TypeSummons, []string{"summons"}},
+	{TypeDecision, []string{"decision", "offence", "infringement", "penalty", "reprimand"}},
+	{TypeTechnical, []string{"technical", "scrutineering", "parc ferme", "parc fermé", "homologation", "power unit"}},
+	{TypeClassification, []string{"classification", "grid", "timing", "lap times", "lap chart", "results", "standings"}},
+}
+
+// DocumentType returns the type of a document from its title, such as
+// TypeDecision for "Doc 12 - Decision - Car 4 - Impeding", or TypeOther.
+func DocumentType(title string) string {
+	lower := strings.ToLower(title)
+	for _, t := range documentTypeKeywords {
+		for _, keyword := range t.keywords {
+			if strings.Contains(lower, keyword) {
+				return t.docType
+			}
+		}
+	}
+	return TypeOther
+}
+
+// seriesPatterns maps championship slugs of FIA document pages to series
+var seriesPatterns = []struct {
+	slug   string
+	series string
+}{
+	{"formula-one", "f1"},
+	{"formula-2", "f2"},
+	{"formula-3", "f3"},
+	{"f1-academy", "f1-academy"},
+}
+
+// Series returns the championship of the FIA documents page the scraper
+// reads, e.g. "f1", or "" when the URL names none
+func (s *Scraper) Series() string {
+	lower := strings.ToLower(s.baseURL)
+	for _, p := range seriesPatterns {
+		if strings.Contains(lower, p.slug) {
+			return p.series
+		}
+	}
+	return ""
+}
+
 // verifyPDF checks if a file is a valid PDF
 func (s *Scraper) verifyPDF(filePath string) error {
 	// Open the file
@@ -0,0 +1,70 @@
+package scraper
+
+import "testing"
+
+func TestDocumentNumber(t *testing.T) {
+	tests := []struct {
+		title  string
+		want   int
+		wantOK bool
+	}{
+		{"Doc 12 - Summons - Car 4 - Impeding", 12, true},
+		{"Doc 3 - Decision - Car 16", 3, true},
+		{"Recalled - Doc 7 - Infringement", 7, true},
+		{"Document 21 - Final Classification", 21, true},
+		{"Race Director's Event Notes", 0, false},
+		{"Docket notes", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got, ok := DocumentNumber(tt.title)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DocumentNumber(%q) = %d, %v; want %d, %v", tt.title, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDocumentType(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Doc 12 - Summons - Car 4 - Impeding", TypeSummons},
+		{"Doc 13 - Decision - Car 4 - Impeding", TypeDecision},
+		{"Doc 14 - Offence - Car 16 - Unsafe release", TypeDecision},
+		{"Doc 20 - Decision - Car 44 - Technical infringement", TypeDecision},
+		{"Doc 8 - Technical Delegate's Report", TypeTechnical},
+		{"Doc 2 - Scrutineering Schedule", TypeTechnical},
+		{"Doc 30 - Final Race Classification", TypeClassification},
+		{"Doc 25 - Provisional Starting Grid", TypeClassification},
+		{"Race Director's Event Notes", TypeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := DocumentType(tt.title); got != tt.want {
+				t.Errorf("DocumentType(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeries(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.fia.com/documents/championships/fia-formula-one-world-championship-14/season/season-2026-2072", "f1"},
+		{"https://www.fia.com/documents/championships/fia-formula-2-championship-44/season/season-2026-2072", "f2"},
+		{"https://www.fia.com/documents/championships/fia-formula-3-championship-1012/season/season-2026-2072", "f3"},
+		{"https://example.com/documents", ""},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.url).Series(); got != tt.want {
+			t.Errorf("Series(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
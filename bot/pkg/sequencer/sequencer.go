@@ -0,0 +1,143 @@
+package sequencer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bot/pkg/logger"
+)
+
+// Package logger
+var log = logger.Package("sequencer")
+
+// Key orders documents: by Major, then Minor. Callers choose what they hold,
+// e.g. the publication time and job ID, or the FIA document number.
+type Key struct {
+	Major int64
+	Minor int64
+}
+
+// Less reports whether k sorts before other
+func (k Key) Less(other Key) bool {
+	if k.Major != other.Major {
+		return k.Major < other.Major
+	}
+	return k.Minor < other.Minor
+}
+
+// Sequencer is an ordered commit stage. Documents enter when a worker picks
+// them up and are prepared concurrently; before publishing, each waits until
+// every earlier document still in flight has published or given up. A
+// per-document timeout keeps one stuck document from blocking the rest.
+//
+// A nil *Sequencer is valid and imposes no order.
+type Sequencer struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	inflight map[*Ticket]struct{}
+	changed  chan struct{} // closed and replaced whenever a ticket leaves
+}
+
+// Ticket is a document's place in the sequence
+type Ticket struct {
+	seq *Sequencer
+	key Key
+}
+
+// New creates a sequencer; a document waits at most timeout for its
+// predecessors before publishing anyway
+func New(timeout time.Duration) *Sequencer {
+	return &Sequencer{
+		timeout:  timeout,
+		inflight: make(map[*Ticket]struct{}),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Enter registers a document that is about to be prepared
+func (s *Sequencer) Enter(key Key) *Ticket {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &Ticket{seq: s, key: key}
+	s.inflight[t] = struct{}{}
+	return t
+}
+
+// InFlight returns the number of documents in the sequence
+func (s *Sequencer) InFlight() int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.inflight)
+}
+
+// blocked reports whether an earlier ticket is in flight, and returns the
+// channel that is closed on the next change
+func (s *Sequencer) blocked(t *Ticket) (bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for other := range s.inflight {
+		if other != t && other.key.Less(t.key) {
+			return true, s.changed
+		}
+	}
+	return false, nil
+}
+
+// Wait blocks until the ticket's turn to publish. It returns true when every
+// predecessor finished, false when the timeout elapsed first; either way the
+// caller publishes. ctx cancellation returns its error.
+func (t *Ticket) Wait(ctx context.Context) (bool, error) {
+	if t == nil {
+		return true, nil
+	}
+
+	timer := time.NewTimer(t.seq.timeout)
+	defer timer.Stop()
+
+	for {
+		blocked, changed := t.seq.blocked(t)
+		if !blocked {
+			return true, nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			log.WithRequestContext(ctx).
+				WithContext("method", "Wait").
+				Warn("Timed out waiting for earlier documents; publishing out of order",
+					"timeout", t.seq.timeout)
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// Done removes the ticket from the sequence, after publishing or on failure,
+// releasing any later document waiting on it. Calling Done twice is harmless.
+func (t *Ticket) Done() {
+	if t == nil {
+		return
+	}
+
+	s := t.seq
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.inflight[t]; !ok {
+		return
+	}
+	delete(s.inflight, t)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
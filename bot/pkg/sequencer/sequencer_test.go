@@ -0,0 +1,86 @@
+package sequencer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequencerPublishesInOrder(t *testing.T) {
+	seq := New(5 * time.Second)
+	ctx := context.Background()
+
+	// Enter in order, then finish preparing in reverse order
+	tickets := make([]*Ticket, 4)
+	for i := range tickets {
+		tickets[i] = seq.Enter(Key{Major: int64(i)})
+	}
+
+	var mu sync.Mutex
+	var published []int
+	var wg sync.WaitGroup
+	for i := len(tickets) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inOrder, err := tickets[i].Wait(ctx)
+			if err != nil || !inOrder {
+				t.Errorf("ticket %d: Wait = %v, %v", i, inOrder, err)
+			}
+			mu.Lock()
+			published = append(published, i)
+			mu.Unlock()
+			tickets[i].Done()
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	for i, got := range published {
+		if got != i {
+			t.Fatalf("published order = %v, want ascending", published)
+		}
+	}
+	if n := seq.InFlight(); n != 0 {
+		t.Errorf("InFlight = %d after all Done, want 0", n)
+	}
+}
+
+func TestSequencerTimeout(t *testing.T) {
+	seq := New(20 * time.Millisecond)
+	stuck := seq.Enter(Key{Major: 1})
+	defer stuck.Done()
+	later := seq.Enter(Key{Major: 2})
+
+	start := time.Now()
+	inOrder, err := later.Wait(context.Background())
+	if err != nil || inOrder {
+		t.Fatalf("Wait = %v, %v; want timeout", inOrder, err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Wait returned before the timeout")
+	}
+}
+
+func TestSequencerCancel(t *testing.T) {
+	seq := New(time.Hour)
+	stuck := seq.Enter(Key{Major: 1})
+	defer stuck.Done()
+	later := seq.Enter(Key{Major: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := later.Wait(ctx); err == nil {
+		t.Fatal("Wait succeeded on a cancelled context")
+	}
+}
+
+func TestNilSequencer(t *testing.T) {
+	var seq *Sequencer
+	ticket := seq.Enter(Key{Major: 1})
+	if inOrder, err := ticket.Wait(context.Background()); err != nil || !inOrder {
+		t.Fatalf("Wait = %v, %v; want immediate", inOrder, err)
+	}
+	ticket.Done()
+}
@@ -0,0 +1,1089 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+// MemoryStorage implements the StorageInterface in process memory, with the
+// same semantics as the SQL backends: documents are deduplicated on
+// title/url and conflicting inserts are no-ops. It backs dry runs and tests
+// of the main loop; nothing survives a restart.
+type MemoryStorage struct {
+	mu        sync.Mutex
+	processed map[string]ProcessedDocument
+	failures  map[string]*DocumentFailure
+	jobs      map[string]*memoryJob
+	images    map[string]HostedImage
+	summaries map[string]memorySummary
+	reviews   map[string]SummaryReview
+	usage     map[string]SummaryUsage
+	shadows   []ShadowSummary
+	posts     map[string][]PlatformPost
+	baselines []Baseline
+	nextJobID int64
+	connErr   error
+}
+
+// memoryJob is a Job plus its lease bookkeeping
+type memoryJob struct {
+	Job
+	lockedBy string
+	lockedAt time.Time
+}
+
+// memorySummary is a cached summary and when it was cached
+type memorySummary struct {
+	summary   string
+	createdAt time.Time
+}
+
+// NewMemory creates an empty in-memory storage
+func NewMemory() *MemoryStorage {
+	ctxLog := log.WithContext("method", "NewMemory")
+	ctxLog.Info("In-memory storage initialized; state is lost on exit")
+
+	return &MemoryStorage{
+		processed: make(map[string]ProcessedDocument),
+		failures:  make(map[string]*DocumentFailure),
+		jobs:      make(map[string]*memoryJob),
+		images:    make(map[string]HostedImage),
+		summaries: make(map[string]memorySummary),
+		reviews:   make(map[string]SummaryReview),
+		usage:     make(map[string]SummaryUsage),
+		posts:     make(map[string][]PlatformPost),
+	}
+}
+
+// SetConnectionError simulates a database outage: while err is non-nil every
+// method fails with it. Pass nil to restore the connection.
+func (m *MemoryStorage) SetConnectionError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connErr = err
+}
+
+// CheckConnection returns the simulated connection error, if any
+func (m *MemoryStorage) CheckConnection(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connErr
+}
+
+// Close is a no-op
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// TryAcquireLeaderLock always succeeds: memory is private to one instance
+func (m *MemoryStorage) TryAcquireLeaderLock(ctx context.Context) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return false, m.connErr
+	}
+	return true, nil
+}
+
+// ReleaseLeaderLock is a no-op, see TryAcquireLeaderLock
+func (m *MemoryStorage) ReleaseLeaderLock(ctx context.Context) error {
+	return nil
+}
+
+// AddProcessedDocument adds a document to the processed documents list.
+// Re-adding an already processed document is a no-op.
+func (m *MemoryStorage) AddProcessedDocument(ctx context.Context, doc ProcessedDocument) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error inserting document: %v", m.connErr)
+	}
+
+	key := DocKey(doc.Title, doc.URL)
+	if _, ok := m.processed[key]; !ok {
+		m.processed[key] = doc
+	}
+	return nil
+}
+
+// FilterProcessed returns the set of already-processed documents among docs
+func (m *MemoryStorage) FilterProcessed(ctx context.Context, docs []*scraper.Document) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying processed documents: %v", m.connErr)
+	}
+
+	processed := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		key := DocKey(doc.Title, doc.URL)
+		if _, ok := m.processed[key]; ok {
+			processed[key] = true
+		}
+	}
+	return processed, nil
+}
+
+// RecentDocuments returns up to limit posted documents published since
+// since, newest first
+func (m *MemoryStorage) RecentDocuments(ctx context.Context, since time.Time, limit int) ([]ProcessedDocument, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying recent documents: %v", m.connErr)
+	}
+
+	var docs []ProcessedDocument
+	for _, doc := range m.processed {
+		if doc.PostID != "" && !doc.Timestamp.Before(since) {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Timestamp.After(docs[j].Timestamp)
+	})
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// CountProcessed returns the number of processed documents
+func (m *MemoryStorage) CountProcessed(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error counting processed documents: %v", m.connErr)
+	}
+	return len(m.processed), nil
+}
+
+// ExportDocuments calls fn for every processed document, oldest first
+func (m *MemoryStorage) ExportDocuments(ctx context.Context, fn func(ProcessedDocument) error) error {
+	m.mu.Lock()
+	if m.connErr != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error querying processed documents: %v", m.connErr)
+	}
+	docs := make([]ProcessedDocument, 0, len(m.processed))
+	for _, doc := range m.processed {
+		docs = append(docs, doc)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(docs, func(i, j int) bool {
+		if !docs[i].Timestamp.Equal(docs[j].Timestamp) {
+			return docs[i].Timestamp.Before(docs[j].Timestamp)
+		}
+		return DocKey(docs[i].Title, docs[i].URL) < DocKey(docs[j].Title, docs[j].URL)
+	})
+	for _, doc := range docs {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportDocuments inserts docs and fills in empty fields of existing ones
+func (m *MemoryStorage) ImportDocuments(ctx context.Context, docs []ProcessedDocument) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error importing documents: %v", m.connErr)
+	}
+
+	changed := 0
+	for _, doc := range docs {
+		key := DocKey(doc.Title, doc.URL)
+		existing, ok := m.processed[key]
+		if !ok {
+			m.processed[key] = doc
+			changed++
+			continue
+		}
+
+		// Fill in the empty fields; RelatedDocuments follows the summary
+		// input like the token counts
+		updated, changedFields := existing, false
+		fill := func(field *string, value string) {
+			if *field == "" && value != "" {
+				*field, changedFields = value, true
+			}
+		}
+		fill(&updated.PostID, doc.PostID)
+		fill(&updated.PDFHash, doc.PDFHash)
+		fill(&updated.Summary, doc.Summary)
+		fill(&updated.PageText, doc.PageText)
+		fill(&updated.PromptVersion, doc.PromptVersion)
+		if updated.SummaryInput == "" && doc.SummaryInput != "" {
+			updated.SummaryInput, updated.InputTokens, updated.OutputTokens = doc.SummaryInput, doc.InputTokens, doc.OutputTokens
+			updated.RelatedDocuments = doc.RelatedDocuments
+			changedFields = true
+		}
+		if changedFields {
+			m.processed[key] = updated
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// ExportPlatformPosts calls fn for every platform post, oldest first
+func (m *MemoryStorage) ExportPlatformPosts(ctx context.Context, fn func(PlatformPost) error) error {
+	m.mu.Lock()
+	if m.connErr != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error querying platform posts: %v", m.connErr)
+	}
+	var posts []PlatformPost
+	for _, p := range m.posts {
+		posts = append(posts, p...)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PostedAt.Equal(posts[j].PostedAt) {
+			return posts[i].PostedAt.Before(posts[j].PostedAt)
+		}
+		if ki, kj := DocKey(posts[i].Title, posts[i].URL), DocKey(posts[j].Title, posts[j].URL); ki != kj {
+			return ki < kj
+		}
+		return posts[i].Platform < posts[j].Platform
+	})
+	for _, p := range posts {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportPlatformPosts inserts posts, keeping known ones
+func (m *MemoryStorage) ImportPlatformPosts(ctx context.Context, posts []PlatformPost) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error importing platform posts: %v", m.connErr)
+	}
+
+	changed := 0
+	for _, post := range posts {
+		key := DocKey(post.Title, post.URL)
+		if slices.ContainsFunc(m.posts[key], func(p PlatformPost) bool { return p.Platform == post.Platform }) {
+			continue
+		}
+		post.PostedAt = post.PostedAt.UTC()
+		m.posts[key] = append(m.posts[key], post)
+		changed++
+	}
+	return changed, nil
+}
+
+// ExportHostedImages calls fn for every hosted image, oldest first
+func (m *MemoryStorage) ExportHostedImages(ctx context.Context, fn func(HostedImage) error) error {
+	m.mu.Lock()
+	if m.connErr != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error querying hosted images: %v", m.connErr)
+	}
+	images := make([]HostedImage, 0, len(m.images))
+	for _, img := range m.images {
+		images = append(images, img)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(images, func(i, j int) bool {
+		if !images[i].UploadedAt.Equal(images[j].UploadedAt) {
+			return images[i].UploadedAt.Before(images[j].UploadedAt)
+		}
+		return images[i].ImageID < images[j].ImageID
+	})
+	for _, img := range images {
+		if err := fn(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportHostedImages inserts images, keeping known ones
+func (m *MemoryStorage) ImportHostedImages(ctx context.Context, images []HostedImage) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error importing hosted images: %v", m.connErr)
+	}
+
+	changed := 0
+	for _, img := range images {
+		if _, ok := m.images[img.ImageID]; ok {
+			continue
+		}
+		img.UploadedAt = img.UploadedAt.UTC()
+		m.images[img.ImageID] = img
+		changed++
+	}
+	return changed, nil
+}
+
+// ExportJobs calls fn for every job that is not done, oldest first.
+// Running jobs are exported as pending.
+func (m *MemoryStorage) ExportJobs(ctx context.Context, fn func(Job) error) error {
+	m.mu.Lock()
+	if m.connErr != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error querying jobs: %v", m.connErr)
+	}
+	var jobs []Job
+	for _, job := range m.jobs {
+		if job.Status == JobDone {
+			continue
+		}
+		exported := job.Job
+		exported.ID = 0
+		if exported.Status == JobRunning {
+			exported.Status = JobPending
+		}
+		exported.Digest = slices.Clone(job.Digest)
+		jobs = append(jobs, exported)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return DocKey(jobs[i].Title, jobs[i].URL) < DocKey(jobs[j].Title, jobs[j].URL)
+	})
+	for _, job := range jobs {
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportJobs inserts jobs, keeping documents that already have one
+func (m *MemoryStorage) ImportJobs(ctx context.Context, jobs []Job) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error importing jobs: %v", m.connErr)
+	}
+
+	changed := 0
+	for _, j := range jobs {
+		if _, ok := m.jobs[DocKey(j.Title, j.URL)]; ok {
+			continue
+		}
+		job := m.newJob(j.Title, j.URL, j.Published, j.CreatedAt.UTC())
+		job.Status = j.Status
+		job.RunAfter = j.RunAfter.UTC()
+		job.Digest = slices.Clone(j.Digest)
+		changed++
+	}
+	return changed, nil
+}
+
+// ExportFailures calls fn for every failure record, oldest first
+func (m *MemoryStorage) ExportFailures(ctx context.Context, fn func(DocumentFailure) error) error {
+	m.mu.Lock()
+	if m.connErr != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("error querying document failures: %v", m.connErr)
+	}
+	failures := make([]DocumentFailure, 0, len(m.failures))
+	for _, f := range m.failures {
+		failures = append(failures, *f)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(failures, func(i, j int) bool {
+		if !failures[i].UpdatedAt.Equal(failures[j].UpdatedAt) {
+			return failures[i].UpdatedAt.Before(failures[j].UpdatedAt)
+		}
+		return DocKey(failures[i].Title, failures[i].URL) < DocKey(failures[j].Title, failures[j].URL)
+	})
+	for _, f := range failures {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportFailures inserts failure records, keeping known ones
+func (m *MemoryStorage) ImportFailures(ctx context.Context, failures []DocumentFailure) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error importing document failures: %v", m.connErr)
+	}
+
+	changed := 0
+	for _, f := range failures {
+		key := DocKey(f.Title, f.URL)
+		if _, ok := m.failures[key]; ok {
+			continue
+		}
+		f.NextAttemptAt, f.UpdatedAt = f.NextAttemptAt.UTC(), f.UpdatedAt.UTC()
+		m.failures[key] = &f
+		changed++
+	}
+	return changed, nil
+}
+
+// PrunePageText clears the page text of documents published before before
+func (m *MemoryStorage) PrunePageText(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error pruning page text: %v", m.connErr)
+	}
+
+	pruned := 0
+	for key, doc := range m.processed {
+		if doc.PageText != "" && doc.Timestamp.Before(before) {
+			doc.PageText = ""
+			m.processed[key] = doc
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// PruneJobs deletes done digest jobs and done jobs of processed documents
+// queued before before
+func (m *MemoryStorage) PruneJobs(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error pruning jobs: %v", m.connErr)
+	}
+
+	pruned := 0
+	for key, job := range m.jobs {
+		_, processed := m.processed[key]
+		if (processed || job.Digest != nil) && job.Status == JobDone && job.CreatedAt.Before(before) {
+			delete(m.jobs, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// AddHostedImages records uploaded images, leaving known images untouched
+func (m *MemoryStorage) AddHostedImages(ctx context.Context, images []HostedImage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error recording hosted image: %v", m.connErr)
+	}
+
+	for _, img := range images {
+		if _, ok := m.images[img.ImageID]; !ok {
+			m.images[img.ImageID] = img
+		}
+	}
+	return nil
+}
+
+// ListHostedImages returns up to limit images uploaded before before, oldest
+// first
+func (m *MemoryStorage) ListHostedImages(ctx context.Context, before time.Time, limit int) ([]HostedImage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying hosted images: %v", m.connErr)
+	}
+
+	var images []HostedImage
+	for _, img := range m.images {
+		if img.UploadedAt.Before(before) {
+			images = append(images, img)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if !images[i].UploadedAt.Equal(images[j].UploadedAt) {
+			return images[i].UploadedAt.Before(images[j].UploadedAt)
+		}
+		return images[i].ImageID < images[j].ImageID
+	})
+	if len(images) > limit {
+		images = images[:limit]
+	}
+	return images, nil
+}
+
+// DeleteHostedImage forgets a deleted image
+func (m *MemoryStorage) DeleteHostedImage(ctx context.Context, imageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error deleting hosted image: %v", m.connErr)
+	}
+
+	delete(m.images, imageID)
+	return nil
+}
+
+// GetSummary returns a cached summary
+func (m *MemoryStorage) GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return "", false, fmt.Errorf("error querying summary cache: %v", m.connErr)
+	}
+
+	cached, ok := m.summaries[pdfHash+"\x00"+model+"\x00"+promptVersion]
+	return cached.summary, ok, nil
+}
+
+// PutSummary caches a summary, keeping an existing entry
+func (m *MemoryStorage) PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error caching summary: %v", m.connErr)
+	}
+
+	key := pdfHash + "\x00" + model + "\x00" + promptVersion
+	if _, ok := m.summaries[key]; !ok {
+		m.summaries[key] = memorySummary{summary: summary, createdAt: time.Now().UTC()}
+	}
+	return nil
+}
+
+// PruneSummaries deletes summaries cached before before
+func (m *MemoryStorage) PruneSummaries(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error pruning summary cache: %v", m.connErr)
+	}
+
+	pruned := 0
+	for key, cached := range m.summaries {
+		if cached.createdAt.Before(before) {
+			delete(m.summaries, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// AddSummaryReview holds a rejected summary for review
+func (m *MemoryStorage) AddSummaryReview(ctx context.Context, review SummaryReview) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding summary review: %v", m.connErr)
+	}
+
+	m.reviews[DocKey(review.Title, review.URL)] = review
+	return nil
+}
+
+// ListSummaryReviews returns the held summaries, most recent first
+func (m *MemoryStorage) ListSummaryReviews(ctx context.Context) ([]SummaryReview, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying summary reviews: %v", m.connErr)
+	}
+
+	var reviews []SummaryReview
+	for _, r := range m.reviews {
+		reviews = append(reviews, r)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+	})
+	return reviews, nil
+}
+
+// ResolveSummaryReview removes a held summary
+func (m *MemoryStorage) ResolveSummaryReview(ctx context.Context, title, url string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return false, fmt.Errorf("error resolving summary review: %v", m.connErr)
+	}
+
+	key := DocKey(title, url)
+	if _, ok := m.reviews[key]; !ok {
+		return false, nil
+	}
+	delete(m.reviews, key)
+	return true, nil
+}
+
+// AddSummaryUsage adds usage to the totals of its day, document and model
+func (m *MemoryStorage) AddSummaryUsage(ctx context.Context, usage SummaryUsage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding summary usage: %v", m.connErr)
+	}
+
+	key := usage.Day + "\x00" + DocKey(usage.Title, usage.URL) + "\x00" + usage.Model
+	m.usage[key] = addUsage(m.usage[key], usage)
+	return nil
+}
+
+// DailySummaryUsage returns the usage per day and model since the given day
+func (m *MemoryStorage) DailySummaryUsage(ctx context.Context, since string) ([]SummaryUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying summary usage: %v", m.connErr)
+	}
+
+	totals := make(map[string]SummaryUsage)
+	for _, u := range m.usage {
+		if u.Day < since {
+			continue
+		}
+		key := u.Day + "\x00" + u.Model
+		totals[key] = addUsage(totals[key], SummaryUsage{Day: u.Day, Model: u.Model,
+			Calls: u.Calls, InputTokens: u.InputTokens, OutputTokens: u.OutputTokens,
+			ThinkingTokens: u.ThinkingTokens, Cost: u.Cost})
+	}
+	var usage []SummaryUsage
+	for _, u := range totals {
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Day != usage[j].Day {
+			return usage[i].Day > usage[j].Day
+		}
+		return usage[i].Model < usage[j].Model
+	})
+	return usage, nil
+}
+
+// addUsage adds u's counts to total, taking u's day, document and model
+func addUsage(total, u SummaryUsage) SummaryUsage {
+	u.Calls += total.Calls
+	u.InputTokens += total.InputTokens
+	u.OutputTokens += total.OutputTokens
+	u.ThinkingTokens += total.ThinkingTokens
+	u.Cost += total.Cost
+	return u
+}
+
+// AddShadowSummaries records the summaries of one document
+func (m *MemoryStorage) AddShadowSummaries(ctx context.Context, summaries []ShadowSummary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding shadow summaries: %v", m.connErr)
+	}
+
+	m.shadows = append(m.shadows, summaries...)
+	return nil
+}
+
+// ListShadowSummaries returns the summaries of the most recently summarized
+// documents
+func (m *MemoryStorage) ListShadowSummaries(ctx context.Context, limit int) ([]ShadowSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying shadow summaries: %v", m.connErr)
+	}
+
+	latest := make(map[string]time.Time)
+	var keys []string
+	for _, sh := range m.shadows {
+		key := DocKey(sh.Title, sh.URL)
+		if t, ok := latest[key]; !ok || sh.CreatedAt.After(t) {
+			if !ok {
+				keys = append(keys, key)
+			}
+			latest[key] = sh.CreatedAt
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return latest[keys[i]].After(latest[keys[j]]) })
+	keys = keys[:min(len(keys), limit)]
+
+	var summaries []ShadowSummary
+	for _, key := range keys {
+		for _, sh := range m.shadows {
+			if DocKey(sh.Title, sh.URL) == key {
+				summaries = append(summaries, sh)
+			}
+		}
+	}
+	return summaries, nil
+}
+
+// AddBaseline records a baseline
+func (m *MemoryStorage) AddBaseline(ctx context.Context, b Baseline) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding baseline: %v", m.connErr)
+	}
+
+	b.CreatedAt = b.CreatedAt.UTC()
+	m.baselines = append(m.baselines, b)
+	return nil
+}
+
+// ApplyBaseline marks docs as processed and records b
+func (m *MemoryStorage) ApplyBaseline(ctx context.Context, b Baseline, docs []*scraper.Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding baseline: %v", m.connErr)
+	}
+
+	for _, doc := range docs {
+		key := DocKey(doc.Title, doc.URL)
+		if _, ok := m.processed[key]; !ok {
+			m.processed[key] = ProcessedDocument{Title: doc.Title, URL: doc.URL, Timestamp: doc.Published}
+		}
+	}
+	b.CreatedAt = b.CreatedAt.UTC()
+	m.baselines = append(m.baselines, b)
+	return nil
+}
+
+// LastBaseline returns the latest baseline of the given mode
+func (m *MemoryStorage) LastBaseline(ctx context.Context, mode string) (*Baseline, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying baseline: %v", m.connErr)
+	}
+
+	var last *Baseline
+	for i, b := range m.baselines {
+		if b.Mode == mode && (last == nil || !b.CreatedAt.Before(last.CreatedAt)) {
+			last = &m.baselines[i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	found := *last
+	return &found, nil
+}
+
+// AddPlatformPost records a document's post on a platform
+func (m *MemoryStorage) AddPlatformPost(ctx context.Context, post PlatformPost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error adding platform post: %v", m.connErr)
+	}
+
+	key := DocKey(post.Title, post.URL)
+	for _, p := range m.posts[key] {
+		if p.Platform == post.Platform {
+			return nil
+		}
+	}
+	m.posts[key] = append(m.posts[key], post)
+	return nil
+}
+
+// ListPlatformPosts returns the platforms a document was published on
+func (m *MemoryStorage) ListPlatformPosts(ctx context.Context, title, url string) ([]PlatformPost, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying platform posts: %v", m.connErr)
+	}
+
+	posts := slices.Clone(m.posts[DocKey(title, url)])
+	sort.SliceStable(posts, func(i, j int) bool {
+		if !posts[i].PostedAt.Equal(posts[j].PostedAt) {
+			return posts[i].PostedAt.Before(posts[j].PostedAt)
+		}
+		return posts[i].Platform < posts[j].Platform
+	})
+	return posts, nil
+}
+
+// RecordFailure increments the document's attempt count and schedules its
+// next attempt
+func (m *MemoryStorage) RecordFailure(ctx context.Context, doc ProcessedDocument, errMsg string, policy RetryPolicy) (DocumentFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return DocumentFailure{}, fmt.Errorf("error recording failure: %v", m.connErr)
+	}
+
+	key := DocKey(doc.Title, doc.URL)
+	failure, ok := m.failures[key]
+	if !ok {
+		failure = &DocumentFailure{
+			Title:     doc.Title,
+			URL:       doc.URL,
+			Published: doc.Timestamp,
+		}
+		m.failures[key] = failure
+	}
+
+	now := time.Now().UTC()
+	failure.Attempts++
+	failure.LastError = errMsg
+	failure.UpdatedAt = now
+	failure.DeadLettered = failure.Attempts >= policy.MaxAttempts
+	failure.NextAttemptAt = now.Add(policy.Backoff(failure.Attempts))
+	return *failure, nil
+}
+
+// ClearFailure removes the failure record of a document
+func (m *MemoryStorage) ClearFailure(ctx context.Context, title, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("error clearing document failure: %v", m.connErr)
+	}
+
+	delete(m.failures, DocKey(title, url))
+	return nil
+}
+
+// ListDeadLetters returns all dead-lettered documents, most recent first
+func (m *MemoryStorage) ListDeadLetters(ctx context.Context) ([]DocumentFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying dead letters: %v", m.connErr)
+	}
+
+	var dead []DocumentFailure
+	for _, f := range m.failures {
+		if f.DeadLettered {
+			dead = append(dead, *f)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].UpdatedAt.After(dead[j].UpdatedAt)
+	})
+	return dead, nil
+}
+
+// ReplayDeadLetter resets a dead letter's attempts and re-queues its job
+func (m *MemoryStorage) ReplayDeadLetter(ctx context.Context, title, url string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return false, fmt.Errorf("error replaying dead letter: %v", m.connErr)
+	}
+
+	key := DocKey(title, url)
+	failure, ok := m.failures[key]
+	if !ok || !failure.DeadLettered {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	failure.Attempts = 0
+	failure.DeadLettered = false
+	failure.NextAttemptAt = now
+	failure.UpdatedAt = now
+
+	job, ok := m.jobs[key]
+	if !ok {
+		job = m.newJob(title, url, failure.Published, now)
+	}
+	job.Status = JobPending
+	job.RunAfter = now
+	job.lockedBy = ""
+	job.lockedAt = time.Time{}
+	return true, nil
+}
+
+// EnqueueJobs queues a pending job per document, leaving documents that are
+// already queued (in any status) untouched
+func (m *MemoryStorage) EnqueueJobs(ctx context.Context, docs []*scraper.Document) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return 0, fmt.Errorf("error enqueueing jobs: %v", m.connErr)
+	}
+
+	now := time.Now().UTC()
+	added := 0
+	for _, doc := range docs {
+		if _, ok := m.jobs[DocKey(doc.Title, doc.URL)]; ok {
+			continue
+		}
+		m.newJob(doc.Title, doc.URL, doc.Published, now)
+		added++
+	}
+	return added, nil
+}
+
+// EnqueueDigest queues the digest job and marks its documents processed
+// under one lock, so they land together
+func (m *MemoryStorage) EnqueueDigest(ctx context.Context, digest *scraper.Document, docs []*scraper.Document) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return false, fmt.Errorf("error enqueueing digest: %v", m.connErr)
+	}
+
+	if _, ok := m.jobs[DocKey(digest.Title, digest.URL)]; ok {
+		return false, nil
+	}
+	job := m.newJob(digest.Title, digest.URL, digest.Published, time.Now().UTC())
+	job.Digest = slices.Clone(docs)
+	for _, doc := range docs {
+		key := DocKey(doc.Title, doc.URL)
+		if _, ok := m.processed[key]; !ok {
+			m.processed[key] = ProcessedDocument{Title: doc.Title, URL: doc.URL, Timestamp: doc.Published}
+		}
+	}
+	return true, nil
+}
+
+// FilterQueued returns the set of documents among docs that already have a
+// job in any status
+func (m *MemoryStorage) FilterQueued(ctx context.Context, docs []*scraper.Document) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error querying queued documents: %v", m.connErr)
+	}
+
+	queued := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		key := DocKey(doc.Title, doc.URL)
+		if _, ok := m.jobs[key]; ok {
+			queued[key] = true
+		}
+	}
+	return queued, nil
+}
+
+// newJob registers a pending job; the caller holds m.mu
+func (m *MemoryStorage) newJob(title, url string, published, now time.Time) *memoryJob {
+	m.nextJobID++
+	job := &memoryJob{Job: Job{
+		ID:        m.nextJobID,
+		Title:     title,
+		URL:       url,
+		Published: published,
+		Status:    JobPending,
+		RunAfter:  now,
+		CreatedAt: now,
+	}}
+	m.jobs[DocKey(title, url)] = job
+	return job
+}
+
+// ClaimJob hands the oldest due job to workerID, or returns nil when none is
+// due
+func (m *MemoryStorage) ClaimJob(ctx context.Context, workerID string, lease time.Duration) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error claiming job: %v", m.connErr)
+	}
+
+	now := time.Now().UTC()
+	var next *memoryJob
+	for _, job := range m.jobs {
+		due := (job.Status == JobPending && !job.RunAfter.After(now)) ||
+			(job.Status == JobRunning && job.lockedAt.Before(now.Add(-lease)))
+		if !due {
+			continue
+		}
+		if next == nil || job.Published.Before(next.Published) ||
+			(job.Published.Equal(next.Published) && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = JobRunning
+	next.lockedBy = workerID
+	next.lockedAt = now
+	claimed := next.Job
+	return &claimed, nil
+}
+
+// RenewJob extends the lease of a running job held by workerID
+func (m *MemoryStorage) RenewJob(ctx context.Context, id int64, workerID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return false, fmt.Errorf("error renewing job lease: %v", m.connErr)
+	}
+
+	for _, job := range m.jobs {
+		if job.ID == id && job.Status == JobRunning && job.lockedBy == workerID {
+			job.lockedAt = time.Now().UTC()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CompleteJob marks a job done
+func (m *MemoryStorage) CompleteJob(ctx context.Context, id int64) error {
+	return m.updateJob(id, "error completing job", func(job *memoryJob) {
+		job.Status = JobDone
+	})
+}
+
+// FailJob reschedules a job, or parks it as dead
+func (m *MemoryStorage) FailJob(ctx context.Context, id int64, runAfter time.Time, dead bool) error {
+	return m.updateJob(id, "error failing job", func(job *memoryJob) {
+		job.Status = JobPending
+		if dead {
+			job.Status = JobDead
+		}
+		job.RunAfter = runAfter.UTC()
+	})
+}
+
+// updateJob applies update to the job with the given id and releases its
+// lease. An unknown id is a no-op, like an UPDATE matching no rows.
+func (m *MemoryStorage) updateJob(id int64, errPrefix string, update func(job *memoryJob)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return fmt.Errorf("%s: %v", errPrefix, m.connErr)
+	}
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			update(job)
+			job.lockedBy = ""
+			job.lockedAt = time.Time{}
+			return nil
+		}
+	}
+	return nil
+}
+
+// CountJobs returns the number of jobs in each status
+func (m *MemoryStorage) CountJobs(ctx context.Context) (map[JobStatus]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connErr != nil {
+		return nil, fmt.Errorf("error counting jobs: %v", m.connErr)
+	}
+
+	counts := make(map[JobStatus]int)
+	for _, job := range m.jobs {
+		counts[job.Status]++
+	}
+	return counts, nil
+}
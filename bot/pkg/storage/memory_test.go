@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+func TestMemoryProcessedDocuments(t *testing.T) {
+	testProcessedDocuments(t, NewMemory())
+}
+
+func TestMemoryJobQueue(t *testing.T) {
+	testJobQueue(t, NewMemory())
+}
+
+func TestMemoryFailureCounting(t *testing.T) {
+	testFailureCounting(t, NewMemory())
+}
+
+func TestMemoryDeadLetterReplay(t *testing.T) {
+	testDeadLetterReplay(t, NewMemory())
+}
+
+func TestMemoryConnectionError(t *testing.T) {
+	store := NewMemory()
+	ctx := context.Background()
+	outage := errors.New("connection refused")
+
+	store.SetConnectionError(outage)
+	if err := store.CheckConnection(ctx); !errors.Is(err, outage) {
+		t.Errorf("CheckConnection = %v, want %v", err, outage)
+	}
+	doc := ProcessedDocument{Title: "Doc 1", URL: "u1", Timestamp: time.Now()}
+	if err := store.AddProcessedDocument(ctx, doc); err == nil {
+		t.Error("AddProcessedDocument succeeded during outage")
+	}
+	if _, err := store.EnqueueJobs(ctx, []*scraper.Document{{Title: "Doc 1", URL: "u1"}}); err == nil {
+		t.Error("EnqueueJobs succeeded during outage")
+	}
+
+	store.SetConnectionError(nil)
+	if err := store.CheckConnection(ctx); err != nil {
+		t.Errorf("CheckConnection after recovery = %v", err)
+	}
+	if err := store.AddProcessedDocument(ctx, doc); err != nil {
+		t.Errorf("AddProcessedDocument after recovery = %v", err)
+	}
+}
+
+func TestMemoryExportImport(t *testing.T) {
+	testExportImport(t, NewMemory(), NewMemory())
+}
+
+func TestMemoryExportImportState(t *testing.T) {
+	testExportImportState(t, NewMemory(), NewMemory())
+}
+
+func TestMemoryRetention(t *testing.T) {
+	testRetention(t, NewMemory())
+}
+
+func TestMemorySummaryCache(t *testing.T) {
+	testSummaryCache(t, NewMemory())
+}
+
+func TestMemorySummaryReviews(t *testing.T) {
+	testSummaryReviews(t, NewMemory())
+}
+
+func TestMemorySummaryUsage(t *testing.T) {
+	testSummaryUsage(t, NewMemory())
+}
+
+func TestMemoryRecentDocuments(t *testing.T) {
+	testRecentDocuments(t, NewMemory())
+}
+
+func TestMemoryShadowSummaries(t *testing.T) {
+	testShadowSummaries(t, NewMemory())
+}
+
+func TestMemoryPlatformPosts(t *testing.T) {
+	testPlatformPosts(t, NewMemory())
+}
+
+func TestMemoryBaselines(t *testing.T) {
+	testBaselines(t, NewMemory())
+}
+
+func TestMemoryDigestJobs(t *testing.T) {
+	testDigestJobs(t, NewMemory())
+}
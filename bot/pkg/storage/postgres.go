@@ -3,8 +3,10 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"bot/pkg/logger"
@@ -16,11 +18,161 @@ import (
 // Package logger
 var log = logger.Package("storage")
 
+// leaderLockKey is the pg_advisory_lock key all bot instances sharing a
+// database contend for.
+const leaderLockKey int64 = 0x4649_4131 // "FIA1"
+
 // PostgresStorage implements the StorageInterface using PostgreSQL.
 // sql.DB is a self-healing connection pool: dropped connections are
 // re-established transparently, so there is no explicit reconnect logic.
 type PostgresStorage struct {
 	db *sql.DB
+
+	// leaderConn is the session holding the advisory leader lock. Advisory
+	// locks belong to a session, not the pool, so the connection is pinned
+	// for as long as this instance is the leader.
+	leaderMu   sync.Mutex
+	leaderConn *sql.Conn
+}
+
+// postgresSchema lists idempotent statements for the tables that sit
+// alongside processed_documents. They run in order on every start, so a new
+// table or column only needs a new entry here.
+var postgresSchema = []struct {
+	name string
+	stmt string
+}{
+	{"processed_documents.post_id", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS post_id TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.pdf_hash", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS pdf_hash TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.summary", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.page_text", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS page_text TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.prompt_version", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS prompt_version TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.summary_input", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS summary_input TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.input_tokens", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS input_tokens INTEGER NOT NULL DEFAULT 0`},
+	{"processed_documents.output_tokens", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS output_tokens INTEGER NOT NULL DEFAULT 0`},
+	{"processed_documents.related_documents", `
+		ALTER TABLE processed_documents ADD COLUMN IF NOT EXISTS related_documents TEXT NOT NULL DEFAULT ''`},
+	{"document_failures", `
+		CREATE TABLE IF NOT EXISTS document_failures (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			published TIMESTAMP NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			next_attempt_at TIMESTAMP NOT NULL,
+			dead_lettered BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url)
+		)`},
+	{"document_jobs", `
+		CREATE TABLE IF NOT EXISTS document_jobs (
+			id BIGSERIAL PRIMARY KEY,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			published TIMESTAMP NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			run_after TIMESTAMP NOT NULL,
+			locked_by TEXT,
+			locked_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			UNIQUE(title, url)
+		)`},
+	{"document_jobs_due_idx", `
+		CREATE INDEX IF NOT EXISTS document_jobs_due_idx
+		ON document_jobs (status, run_after)`},
+	{"document_jobs.digest", `
+		ALTER TABLE document_jobs ADD COLUMN IF NOT EXISTS digest TEXT NOT NULL DEFAULT ''`},
+	{"hosted_images", `
+		CREATE TABLE IF NOT EXISTS hosted_images (
+			image_id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			delete_key TEXT NOT NULL,
+			title TEXT NOT NULL,
+			doc_url TEXT NOT NULL,
+			uploaded_at TIMESTAMP NOT NULL
+		)`},
+	{"hosted_images_uploaded_idx", `
+		CREATE INDEX IF NOT EXISTS hosted_images_uploaded_idx
+		ON hosted_images (uploaded_at)`},
+	{"summary_cache", `
+		CREATE TABLE IF NOT EXISTS summary_cache (
+			pdf_hash TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (pdf_hash, model, prompt_version)
+		)`},
+	{"summary_reviews", `
+		CREATE TABLE IF NOT EXISTS summary_reviews (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			post_id TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url)
+		)`},
+	{"summary_usage", `
+		CREATE TABLE IF NOT EXISTS summary_usage (
+			day TEXT NOT NULL,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			model TEXT NOT NULL,
+			calls INTEGER NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			thinking_tokens INTEGER NOT NULL,
+			cost DOUBLE PRECISION NOT NULL,
+			PRIMARY KEY (day, title, url, model)
+		)`},
+	{"summary_shadows", `
+		CREATE TABLE IF NOT EXISTS summary_shadows (
+			id BIGSERIAL PRIMARY KEY,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			candidate TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			error TEXT NOT NULL,
+			latency_ms BIGINT NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			thinking_tokens INTEGER NOT NULL,
+			cost DOUBLE PRECISION NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)`},
+	{"summary_shadows_created_idx", `
+		CREATE INDEX IF NOT EXISTS summary_shadows_created_idx
+		ON summary_shadows (created_at)`},
+	{"platform_posts", `
+		CREATE TABLE IF NOT EXISTS platform_posts (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			platform TEXT NOT NULL,
+			post_id TEXT NOT NULL,
+			posted_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url, platform)
+		)`},
+	{"baselines", `
+		CREATE TABLE IF NOT EXISTS baselines (
+			id SERIAL PRIMARY KEY,
+			mode TEXT NOT NULL,
+			seen INTEGER NOT NULL,
+			posted INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)`},
 }
 
 // NewPostgres creates a new PostgreSQL storage
@@ -160,6 +312,14 @@ func NewPostgres(host, port, user, password, dbname, sslmode string) (StorageInt
 		return nil, fmt.Errorf("error ensuring unique index on (title, url): %v", err)
 	}
 
+	// Tables added after processed_documents are created idempotently
+	for _, m := range postgresSchema {
+		if _, err := db.Exec(m.stmt); err != nil {
+			ctxLog.Error("Error applying schema", "table", m.name, "error", err)
+			return nil, fmt.Errorf("error applying schema for %s: %v", m.name, err)
+		}
+	}
+
 	ctxLog.Info("PostgreSQL storage initialized successfully")
 	return &PostgresStorage{
 		db: db,
@@ -187,6 +347,76 @@ func (s *PostgresStorage) Close() error {
 	return s.db.Close()
 }
 
+// TryAcquireLeaderLock takes the advisory leader lock on a dedicated
+// session, or confirms the session already holding it is still alive. When
+// the leader's session dies Postgres drops the lock, so a standby's next
+// call acquires it.
+func (s *PostgresStorage) TryAcquireLeaderLock(ctx context.Context) (bool, error) {
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "TryAcquireLeaderLock")
+
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+
+	if s.leaderConn != nil {
+		_, err := s.leaderConn.ExecContext(ctx, "SELECT 1")
+		if err == nil {
+			return true, nil
+		}
+		ctxLog.Warn("Leader session lost", "error", err)
+		discardConn(s.leaderConn)
+		s.leaderConn = nil
+		return false, nil
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error getting connection for leader lock: %v", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil {
+		discardConn(conn)
+		return false, fmt.Errorf("error acquiring leader lock: %v", err)
+	}
+	if !acquired {
+		if err := conn.Close(); err != nil {
+			ctxLog.Warn("Error returning connection to pool", "error", err)
+		}
+		ctxLog.Debug("Leader lock held by another instance")
+		return false, nil
+	}
+
+	s.leaderConn = conn
+	return true, nil
+}
+
+// ReleaseLeaderLock unlocks the advisory lock and closes its session.
+func (s *PostgresStorage) ReleaseLeaderLock(ctx context.Context) error {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+
+	if s.leaderConn == nil {
+		return nil
+	}
+
+	_, err := s.leaderConn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	// Discard rather than pool the session: if the unlock failed the lock
+	// would otherwise stay held by an idle pooled connection.
+	discardConn(s.leaderConn)
+	s.leaderConn = nil
+	if err != nil {
+		return fmt.Errorf("error releasing leader lock: %v", err)
+	}
+	return nil
+}
+
+// discardConn closes conn's underlying session instead of returning it to
+// the pool. Returning driver.ErrBadConn from Raw makes database/sql drop it.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
 // AddProcessedDocument adds a document to the processed documents list.
 // The insert is atomic: the UNIQUE(title, url) constraint plus ON CONFLICT
 // DO NOTHING makes re-adding an already processed document a no-op.
@@ -198,8 +428,11 @@ func (s *PostgresStorage) AddProcessedDocument(ctx context.Context, doc Processe
 
 	ctxLog.Info(fmt.Sprintf("Adding document to processed list: %s", doc.Title))
 	res, err := s.db.ExecContext(ctx,
-		"INSERT INTO processed_documents (title, url, timestamp) VALUES ($1, $2, $3) ON CONFLICT (title, url) DO NOTHING",
-		doc.Title, doc.URL, doc.Timestamp,
+		`INSERT INTO processed_documents (title, url, timestamp, post_id, pdf_hash, summary, page_text, prompt_version,
+			summary_input, input_tokens, output_tokens, related_documents)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (title, url) DO NOTHING`,
+		doc.Title, doc.URL, doc.Timestamp, doc.PostID, doc.PDFHash, doc.Summary, doc.PageText, doc.PromptVersion,
+		doc.SummaryInput, doc.InputTokens, doc.OutputTokens, joinRelated(doc.RelatedDocuments),
 	)
 	duration := time.Since(start)
 
@@ -273,3 +506,12 @@ func (s *PostgresStorage) FilterProcessed(ctx context.Context, docs []*scraper.D
 
 	return processed, nil
 }
+
+// CountProcessed returns the number of processed documents
+func (s *PostgresStorage) CountProcessed(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_documents").Scan(&n); err != nil {
+		return 0, fmt.Errorf("error counting processed documents: %v", err)
+	}
+	return n, nil
+}
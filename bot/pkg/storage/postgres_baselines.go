@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bot/pkg/scraper"
+)
+
+// Baseline statements shared with SQLite; %[1]s is the placeholder prefix
+// ("$" or "?")
+const (
+	insertBaseline = `
+		INSERT INTO baselines (mode, seen, posted, created_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4)`
+
+	selectLastBaseline = `
+		SELECT mode, seen, posted, created_at
+		FROM baselines WHERE mode = %[1]s1
+		ORDER BY created_at DESC, id DESC LIMIT 1`
+)
+
+// AddBaseline records a baseline
+func (s *PostgresStorage) AddBaseline(ctx context.Context, b Baseline) error {
+	return execAddBaseline(ctx, s.db, "$", b)
+}
+
+// ApplyBaseline marks docs as processed and records b in a single
+// transaction
+func (s *PostgresStorage) ApplyBaseline(ctx context.Context, b Baseline, docs []*scraper.Document) error {
+	return execApplyBaseline(ctx, s.db, "$", b, docs)
+}
+
+// LastBaseline returns the latest baseline of the given mode
+func (s *PostgresStorage) LastBaseline(ctx context.Context, mode string) (*Baseline, error) {
+	return queryLastBaseline(ctx, s.db, "$", mode)
+}
+
+// execAddBaseline runs insertBaseline with the given placeholder prefix
+func execAddBaseline(ctx context.Context, db *sql.DB, prefix string, b Baseline) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(insertBaseline, prefix),
+		b.Mode, b.Seen, b.Posted, b.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error adding baseline: %v", err)
+	}
+	return nil
+}
+
+// execApplyBaseline runs insertSeenDocument and insertBaseline with the
+// given placeholder prefix
+func execApplyBaseline(ctx context.Context, db *sql.DB, prefix string, b Baseline, docs []*scraper.Document) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, doc := range docs {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(insertSeenDocument, prefix),
+			doc.Title, doc.URL, doc.Published.UTC()); err != nil {
+			return fmt.Errorf("error marking %q as processed: %v", doc.Title, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(insertBaseline, prefix),
+		b.Mode, b.Seen, b.Posted, b.CreatedAt.UTC()); err != nil {
+		return fmt.Errorf("error adding baseline: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing baseline: %v", err)
+	}
+	return nil
+}
+
+// queryLastBaseline runs selectLastBaseline with the given placeholder
+// prefix
+func queryLastBaseline(ctx context.Context, db *sql.DB, prefix, mode string) (*Baseline, error) {
+	var b Baseline
+	err := db.QueryRowContext(ctx, fmt.Sprintf(selectLastBaseline, prefix), mode).
+		Scan(&b.Mode, &b.Seen, &b.Posted, &b.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying baseline: %v", err)
+	}
+	return &b, nil
+}
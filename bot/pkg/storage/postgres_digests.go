@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+// Digest statements shared with SQLite; %[1]s is the placeholder prefix
+// ("$" or "?")
+const (
+	insertDigestJob = `
+		INSERT INTO document_jobs (title, url, published, status, run_after, created_at, digest)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, 'pending', %[1]s4, %[1]s4, %[1]s5)
+		ON CONFLICT (title, url) DO NOTHING`
+
+	// insertSeenDocument marks a document processed without posting it; also
+	// used by baselines
+	insertSeenDocument = `
+		INSERT INTO processed_documents (title, url, timestamp)
+		VALUES (%[1]s1, %[1]s2, %[1]s3)
+		ON CONFLICT (title, url) DO NOTHING`
+)
+
+// digestEntry is a document covered by a digest job, as stored in
+// document_jobs.digest
+type digestEntry struct {
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
+}
+
+// EnqueueDigest queues the digest job and marks its documents processed in
+// a single transaction
+func (s *PostgresStorage) EnqueueDigest(ctx context.Context, digest *scraper.Document, docs []*scraper.Document) (bool, error) {
+	return execEnqueueDigest(ctx, s.db, "$", digest, docs)
+}
+
+// execEnqueueDigest runs insertDigestJob and insertSeenDocument with the
+// given placeholder prefix
+func execEnqueueDigest(ctx context.Context, db *sql.DB, prefix string, digest *scraper.Document, docs []*scraper.Document) (bool, error) {
+	encoded, err := encodeDigest(docs)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(insertDigestJob, prefix),
+		digest.Title, digest.URL, digest.Published.UTC(), time.Now().UTC(), encoded)
+	if err != nil {
+		return false, fmt.Errorf("error enqueueing digest: %v", err)
+	}
+	added, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error enqueueing digest: %v", err)
+	}
+
+	for _, doc := range docs {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(insertSeenDocument, prefix),
+			doc.Title, doc.URL, doc.Published.UTC()); err != nil {
+			return false, fmt.Errorf("error marking %q as processed: %v", doc.Title, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing digest: %v", err)
+	}
+	return added > 0, nil
+}
+
+// encodeDigest stores the documents of a digest job as JSON
+func encodeDigest(docs []*scraper.Document) (string, error) {
+	entries := make([]digestEntry, 0, len(docs))
+	for _, doc := range docs {
+		entries = append(entries, digestEntry{Title: doc.Title, URL: doc.URL, Published: doc.Published.UTC()})
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("error encoding digest: %v", err)
+	}
+	return string(b), nil
+}
+
+// decodeDigest reverses encodeDigest; an empty column is a document job
+func decodeDigest(s string) ([]*scraper.Document, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var entries []digestEntry
+	if err := json.Unmarshal([]byte(s), &entries); err != nil {
+		return nil, fmt.Errorf("error decoding digest: %v", err)
+	}
+	docs := make([]*scraper.Document, 0, len(entries))
+	for _, e := range entries {
+		docs = append(docs, &scraper.Document{Title: e.Title, URL: e.URL, Published: e.Published})
+	}
+	return docs, nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+// EnqueueJobs inserts a pending job per document in a single statement.
+// ON CONFLICT DO NOTHING leaves documents that are already queued, running,
+// done or dead untouched, so discovery can enqueue its whole listing.
+func (s *PostgresStorage) EnqueueJobs(ctx context.Context, docs []*scraper.Document) (int, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "EnqueueJobs")
+
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	placeholders := make([]string, 0, len(docs))
+	args := make([]any, 0, len(docs)*3+1)
+	args = append(args, now)
+	for i, doc := range docs {
+		placeholders = append(placeholders,
+			fmt.Sprintf("($%d, $%d, $%d, 'pending', $1, $1)", i*3+2, i*3+3, i*3+4))
+		args = append(args, doc.Title, doc.URL, doc.Published)
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO document_jobs (title, url, published, status, run_after, created_at) VALUES "+
+			strings.Join(placeholders, ", ")+" ON CONFLICT (title, url) DO NOTHING",
+		args...)
+	if err != nil {
+		ctxLog.ErrorWithType("Error enqueueing jobs", err)
+		return 0, fmt.Errorf("error enqueueing jobs: %v", err)
+	}
+
+	added, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error enqueueing jobs: %v", err)
+	}
+	return int(added), nil
+}
+
+// FilterQueued returns the set of documents among docs that already have a
+// job in any status, keyed by DocKey(title, url)
+func (s *PostgresStorage) FilterQueued(ctx context.Context, docs []*scraper.Document) (map[string]bool, error) {
+	queued := make(map[string]bool, len(docs))
+	if len(docs) == 0 {
+		return queued, nil
+	}
+
+	placeholders := make([]string, 0, len(docs))
+	args := make([]any, 0, len(docs)*2)
+	for i, doc := range docs {
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
+		args = append(args, doc.Title, doc.URL)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT title, url FROM document_jobs WHERE (title, url) IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying queued documents: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var title, url string
+		if err := rows.Scan(&title, &url); err != nil {
+			return nil, fmt.Errorf("error scanning queued document: %v", err)
+		}
+		queued[DocKey(title, url)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating queued documents: %v", err)
+	}
+	return queued, nil
+}
+
+// ClaimJob locks the oldest due job with FOR UPDATE SKIP LOCKED, so workers
+// on any number of instances never claim the same job twice.
+func (s *PostgresStorage) ClaimJob(ctx context.Context, workerID string, lease time.Duration) (*Job, error) {
+	now := time.Now().UTC()
+
+	var job Job
+	var digest string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE document_jobs SET status = 'running', locked_by = $1, locked_at = $2
+		WHERE id = (
+			SELECT id FROM document_jobs
+			WHERE (status = 'pending' AND run_after <= $2)
+			   OR (status = 'running' AND locked_at < $3)
+			ORDER BY published, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, title, url, published, status, run_after, created_at, digest`,
+		workerID, now, now.Add(-lease),
+	).Scan(&job.ID, &job.Title, &job.URL, &job.Published, &job.Status, &job.RunAfter, &job.CreatedAt, &digest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming job: %v", err)
+	}
+	if job.Digest, err = decodeDigest(digest); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RenewJob extends the lease of a running job held by workerID
+func (s *PostgresStorage) RenewJob(ctx context.Context, id int64, workerID string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE document_jobs SET locked_at = $3 WHERE id = $1 AND status = 'running' AND locked_by = $2",
+		id, workerID, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("error renewing job lease: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error renewing job lease: %v", err)
+	}
+	return n > 0, nil
+}
+
+// CompleteJob marks a job done
+func (s *PostgresStorage) CompleteJob(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE document_jobs SET status = 'done', locked_by = NULL, locked_at = NULL WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error completing job: %v", err)
+	}
+	return nil
+}
+
+// FailJob reschedules a job, or parks it as dead
+func (s *PostgresStorage) FailJob(ctx context.Context, id int64, runAfter time.Time, dead bool) error {
+	status := JobPending
+	if dead {
+		status = JobDead
+	}
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE document_jobs SET status = $2, run_after = $3, locked_by = NULL, locked_at = NULL WHERE id = $1",
+		id, status, runAfter.UTC())
+	if err != nil {
+		return fmt.Errorf("error failing job: %v", err)
+	}
+	return nil
+}
+
+// CountJobs returns the number of jobs in each status
+func (s *PostgresStorage) CountJobs(ctx context.Context) (map[JobStatus]int, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT status, COUNT(*) FROM document_jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("error counting jobs: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[JobStatus]int)
+	for rows.Next() {
+		var status JobStatus
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("error scanning job count: %v", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job counts: %v", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Platform post statements shared with SQLite; %[1]s is the placeholder
+// prefix ("$" or "?")
+const (
+	insertPlatformPost = `
+		INSERT INTO platform_posts (title, url, platform, post_id, posted_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5)
+		ON CONFLICT (title, url, platform) DO NOTHING`
+
+	selectPlatformPosts = `
+		SELECT title, url, platform, post_id, posted_at
+		FROM platform_posts WHERE title = %[1]s1 AND url = %[1]s2
+		ORDER BY posted_at, platform`
+)
+
+// AddPlatformPost records a document's post on a platform
+func (s *PostgresStorage) AddPlatformPost(ctx context.Context, post PlatformPost) error {
+	return execAddPlatformPost(ctx, s.db, "$", post)
+}
+
+// ListPlatformPosts returns the platforms a document was published on
+func (s *PostgresStorage) ListPlatformPosts(ctx context.Context, title, url string) ([]PlatformPost, error) {
+	return queryPlatformPosts(ctx, s.db, "$", title, url)
+}
+
+// execAddPlatformPost runs insertPlatformPost with the given placeholder
+// prefix
+func execAddPlatformPost(ctx context.Context, db *sql.DB, prefix string, p PlatformPost) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(insertPlatformPost, prefix),
+		p.Title, p.URL, p.Platform, p.PostID, p.PostedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error adding platform post: %v", err)
+	}
+	return nil
+}
+
+// queryPlatformPosts runs selectPlatformPosts with the given placeholder
+// prefix
+func queryPlatformPosts(ctx context.Context, db *sql.DB, prefix, title, url string) ([]PlatformPost, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(selectPlatformPosts, prefix), title, url)
+	if err != nil {
+		return nil, fmt.Errorf("error querying platform posts: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var posts []PlatformPost
+	for rows.Next() {
+		var p PlatformPost
+		if err := rows.Scan(&p.Title, &p.URL, &p.Platform, &p.PostID, &p.PostedAt); err != nil {
+			return nil, fmt.Errorf("error scanning platform post: %v", err)
+		}
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating platform posts: %v", err)
+	}
+	return posts, nil
+}
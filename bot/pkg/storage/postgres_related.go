@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// selectRecentDocuments selects posted documents for related-document
+// context, shared with SQLite; %[1]s is the placeholder prefix ("$" or "?")
+const selectRecentDocuments = processedColumns + `
+	WHERE post_id <> '' AND timestamp >= %[1]s1
+	ORDER BY timestamp DESC, id DESC LIMIT %[1]s2`
+
+// RecentDocuments returns up to limit posted documents published since since
+func (s *PostgresStorage) RecentDocuments(ctx context.Context, since time.Time, limit int) ([]ProcessedDocument, error) {
+	return queryRecentDocuments(ctx, s.db, "$", since, limit)
+}
+
+// queryRecentDocuments runs selectRecentDocuments with the given placeholder
+// prefix
+func queryRecentDocuments(ctx context.Context, db *sql.DB, prefix string, since time.Time, limit int) ([]ProcessedDocument, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(selectRecentDocuments, prefix), since.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying recent documents: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var docs []ProcessedDocument
+	for rows.Next() {
+		doc, err := scanProcessed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning processed document: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recent documents: %v", err)
+	}
+	return docs, nil
+}
+
+// scanProcessed scans a row selected with processedColumns
+func scanProcessed(rows *sql.Rows) (ProcessedDocument, error) {
+	var doc ProcessedDocument
+	var related string
+	err := rows.Scan(&doc.Title, &doc.URL, &doc.Timestamp, &doc.PostID, &doc.PDFHash, &doc.Summary, &doc.PageText, &doc.PromptVersion,
+		&doc.SummaryInput, &doc.InputTokens, &doc.OutputTokens, &related)
+	doc.RelatedDocuments = splitRelated(related)
+	return doc, err
+}
+
+// joinRelated stores related document URLs one per line
+func joinRelated(urls []string) string {
+	return strings.Join(urls, "\n")
+}
+
+// splitRelated reverses joinRelated
+func splitRelated(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Retention statements shared with SQLite; %[1]s is the placeholder prefix
+// ("$" or "?")
+const (
+	prunePageText = `
+		UPDATE processed_documents SET page_text = ''
+		WHERE timestamp < %[1]s1 AND page_text <> ''`
+
+	insertHostedImage = `
+		INSERT INTO hosted_images (image_id, url, delete_key, title, doc_url, uploaded_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6)
+		ON CONFLICT (image_id) DO NOTHING`
+
+	selectHostedImages = `
+		SELECT image_id, url, delete_key, title, doc_url, uploaded_at
+		FROM hosted_images
+		WHERE uploaded_at < %[1]s1
+		ORDER BY uploaded_at, image_id
+		LIMIT %[1]s2`
+
+	deleteHostedImage = `DELETE FROM hosted_images WHERE image_id = %[1]s1`
+
+	pruneJobs = `
+		DELETE FROM document_jobs
+		WHERE status = 'done' AND created_at < %[1]s1
+		AND (digest <> '' OR EXISTS (
+			SELECT 1 FROM processed_documents p
+			WHERE p.title = document_jobs.title AND p.url = document_jobs.url
+		))`
+
+	pruneSummaries = `DELETE FROM summary_cache WHERE created_at < %[1]s1`
+)
+
+// PrunePageText clears the page text of documents published before before
+func (s *PostgresStorage) PrunePageText(ctx context.Context, before time.Time) (int, error) {
+	return execPrunePageText(ctx, s.db, "$", before)
+}
+
+// AddHostedImages records uploaded images in a single transaction
+func (s *PostgresStorage) AddHostedImages(ctx context.Context, images []HostedImage) error {
+	return execAddHostedImages(ctx, s.db, "$", images)
+}
+
+// ListHostedImages returns up to limit images uploaded before before
+func (s *PostgresStorage) ListHostedImages(ctx context.Context, before time.Time, limit int) ([]HostedImage, error) {
+	return queryHostedImages(ctx, s.db, "$", before, limit)
+}
+
+// DeleteHostedImage forgets a deleted image
+func (s *PostgresStorage) DeleteHostedImage(ctx context.Context, imageID string) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(deleteHostedImage, "$"), imageID); err != nil {
+		return fmt.Errorf("error deleting hosted image: %v", err)
+	}
+	return nil
+}
+
+// PruneJobs deletes done digest jobs and done jobs of processed documents
+// queued before before
+func (s *PostgresStorage) PruneJobs(ctx context.Context, before time.Time) (int, error) {
+	return execPruneJobs(ctx, s.db, "$", before)
+}
+
+// PruneSummaries deletes summaries cached before before
+func (s *PostgresStorage) PruneSummaries(ctx context.Context, before time.Time) (int, error) {
+	return execPruneSummaries(ctx, s.db, "$", before)
+}
+
+// execPrunePageText runs prunePageText with the given placeholder prefix
+func execPrunePageText(ctx context.Context, db *sql.DB, prefix string, before time.Time) (int, error) {
+	res, err := db.ExecContext(ctx, fmt.Sprintf(prunePageText, prefix), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning page text: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error pruning page text: %v", err)
+	}
+	return int(n), nil
+}
+
+// execPruneJobs runs pruneJobs with the given placeholder prefix
+func execPruneJobs(ctx context.Context, db *sql.DB, prefix string, before time.Time) (int, error) {
+	res, err := db.ExecContext(ctx, fmt.Sprintf(pruneJobs, prefix), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning jobs: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error pruning jobs: %v", err)
+	}
+	return int(n), nil
+}
+
+// execPruneSummaries runs pruneSummaries with the given placeholder prefix
+func execPruneSummaries(ctx context.Context, db *sql.DB, prefix string, before time.Time) (int, error) {
+	res, err := db.ExecContext(ctx, fmt.Sprintf(pruneSummaries, prefix), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning summary cache: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error pruning summary cache: %v", err)
+	}
+	return int(n), nil
+}
+
+// execAddHostedImages inserts images in one transaction with the given
+// placeholder prefix
+func execAddHostedImages(ctx context.Context, db *sql.DB, prefix string, images []HostedImage) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(insertHostedImage, prefix))
+	if err != nil {
+		return fmt.Errorf("error preparing hosted image insert: %v", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, img := range images {
+		if _, err := stmt.ExecContext(ctx, img.ImageID, img.URL, img.DeleteKey, img.Title, img.DocURL, img.UploadedAt.UTC()); err != nil {
+			return fmt.Errorf("error recording hosted image: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing hosted images: %v", err)
+	}
+	return nil
+}
+
+// queryHostedImages runs selectHostedImages with the given placeholder
+// prefix
+func queryHostedImages(ctx context.Context, db *sql.DB, prefix string, before time.Time, limit int) ([]HostedImage, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(selectHostedImages, prefix), before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying hosted images: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var images []HostedImage
+	for rows.Next() {
+		var img HostedImage
+		if err := rows.Scan(&img.ImageID, &img.URL, &img.DeleteKey, &img.Title, &img.DocURL, &img.UploadedAt); err != nil {
+			return nil, fmt.Errorf("error scanning hosted image: %v", err)
+		}
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hosted images: %v", err)
+	}
+	return images, nil
+}
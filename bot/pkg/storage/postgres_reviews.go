@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Summary review statements shared with SQLite; %[1]s is the placeholder
+// prefix ("$" or "?")
+const (
+	upsertSummaryReview = `
+		INSERT INTO summary_reviews (title, url, post_id, model, prompt_version, summary, reason, created_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7, %[1]s8)
+		ON CONFLICT (title, url) DO UPDATE SET
+			post_id = excluded.post_id,
+			model = excluded.model,
+			prompt_version = excluded.prompt_version,
+			summary = excluded.summary,
+			reason = excluded.reason,
+			created_at = excluded.created_at`
+
+	selectSummaryReviews = `
+		SELECT title, url, post_id, model, prompt_version, summary, reason, created_at
+		FROM summary_reviews ORDER BY created_at DESC`
+
+	deleteSummaryReview = `
+		DELETE FROM summary_reviews WHERE title = %[1]s1 AND url = %[1]s2`
+)
+
+// AddSummaryReview holds a rejected summary for review
+func (s *PostgresStorage) AddSummaryReview(ctx context.Context, review SummaryReview) error {
+	return execAddSummaryReview(ctx, s.db, "$", review)
+}
+
+// ListSummaryReviews returns the held summaries, most recent first
+func (s *PostgresStorage) ListSummaryReviews(ctx context.Context) ([]SummaryReview, error) {
+	return querySummaryReviews(ctx, s.db)
+}
+
+// ResolveSummaryReview removes a held summary
+func (s *PostgresStorage) ResolveSummaryReview(ctx context.Context, title, url string) (bool, error) {
+	return execResolveSummaryReview(ctx, s.db, "$", title, url)
+}
+
+// execAddSummaryReview runs upsertSummaryReview with the given placeholder
+// prefix
+func execAddSummaryReview(ctx context.Context, db *sql.DB, prefix string, r SummaryReview) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(upsertSummaryReview, prefix),
+		r.Title, r.URL, r.PostID, r.Model, r.PromptVersion, r.Summary, r.Reason, r.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("error adding summary review: %v", err)
+	}
+	return nil
+}
+
+// querySummaryReviews runs selectSummaryReviews
+func querySummaryReviews(ctx context.Context, db *sql.DB) ([]SummaryReview, error) {
+	rows, err := db.QueryContext(ctx, selectSummaryReviews)
+	if err != nil {
+		return nil, fmt.Errorf("error querying summary reviews: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var reviews []SummaryReview
+	for rows.Next() {
+		var r SummaryReview
+		if err := rows.Scan(&r.Title, &r.URL, &r.PostID, &r.Model, &r.PromptVersion,
+			&r.Summary, &r.Reason, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning summary review: %v", err)
+		}
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating summary reviews: %v", err)
+	}
+	return reviews, nil
+}
+
+// execResolveSummaryReview runs deleteSummaryReview with the given
+// placeholder prefix
+func execResolveSummaryReview(ctx context.Context, db *sql.DB, prefix, title, url string) (bool, error) {
+	res, err := db.ExecContext(ctx, fmt.Sprintf(deleteSummaryReview, prefix), title, url)
+	if err != nil {
+		return false, fmt.Errorf("error resolving summary review: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error resolving summary review: %v", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Shadow summary statements shared with SQLite; %[1]s is the placeholder
+// prefix ("$" or "?")
+const (
+	insertShadowSummary = `
+		INSERT INTO summary_shadows (title, url, candidate, model, prompt_version, summary, error,
+			latency_ms, input_tokens, output_tokens, thinking_tokens, cost, created_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7, %[1]s8, %[1]s9, %[1]s10, %[1]s11, %[1]s12, %[1]s13)`
+
+	selectShadowSummaries = `
+		SELECT s.title, s.url, s.candidate, s.model, s.prompt_version, s.summary, s.error,
+			s.latency_ms, s.input_tokens, s.output_tokens, s.thinking_tokens, s.cost, s.created_at
+		FROM summary_shadows s
+		JOIN (
+			SELECT title, url, MAX(created_at) AS latest FROM summary_shadows
+			GROUP BY title, url ORDER BY latest DESC LIMIT %[1]s1
+		) d ON s.title = d.title AND s.url = d.url
+		ORDER BY d.latest DESC, s.title, s.url, s.id`
+)
+
+// AddShadowSummaries records the summaries of one document
+func (s *PostgresStorage) AddShadowSummaries(ctx context.Context, summaries []ShadowSummary) error {
+	return execAddShadowSummaries(ctx, s.db, "$", summaries)
+}
+
+// ListShadowSummaries returns the summaries of the most recently summarized
+// documents
+func (s *PostgresStorage) ListShadowSummaries(ctx context.Context, limit int) ([]ShadowSummary, error) {
+	return queryShadowSummaries(ctx, s.db, "$", limit)
+}
+
+// execAddShadowSummaries inserts summaries in one transaction with the given
+// placeholder prefix
+func execAddShadowSummaries(ctx context.Context, db *sql.DB, prefix string, summaries []ShadowSummary) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error adding shadow summaries: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := fmt.Sprintf(insertShadowSummary, prefix)
+	for _, sh := range summaries {
+		_, err := tx.ExecContext(ctx, query, sh.Title, sh.URL, sh.Candidate, sh.Model, sh.PromptVersion,
+			sh.Summary, sh.Error, sh.LatencyMS, sh.InputTokens, sh.OutputTokens, sh.ThinkingTokens, sh.Cost,
+			sh.CreatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("error adding shadow summary: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing shadow summaries: %v", err)
+	}
+	return nil
+}
+
+// queryShadowSummaries runs selectShadowSummaries with the given placeholder
+// prefix
+func queryShadowSummaries(ctx context.Context, db *sql.DB, prefix string, limit int) ([]ShadowSummary, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(selectShadowSummaries, prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying shadow summaries: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var summaries []ShadowSummary
+	for rows.Next() {
+		var sh ShadowSummary
+		if err := rows.Scan(&sh.Title, &sh.URL, &sh.Candidate, &sh.Model, &sh.PromptVersion, &sh.Summary,
+			&sh.Error, &sh.LatencyMS, &sh.InputTokens, &sh.OutputTokens, &sh.ThinkingTokens, &sh.Cost,
+			&sh.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning shadow summary: %v", err)
+		}
+		summaries = append(summaries, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadow summaries: %v", err)
+	}
+	return summaries, nil
+}
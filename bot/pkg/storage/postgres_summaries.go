@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Summary cache statements shared with SQLite; %[1]s is the placeholder
+// prefix ("$" or "?")
+const (
+	selectSummary = `
+		SELECT summary FROM summary_cache
+		WHERE pdf_hash = %[1]s1 AND model = %[1]s2 AND prompt_version = %[1]s3`
+
+	insertSummary = `
+		INSERT INTO summary_cache (pdf_hash, model, prompt_version, summary, created_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5)
+		ON CONFLICT (pdf_hash, model, prompt_version) DO NOTHING`
+)
+
+// GetSummary returns the cached summary for the key, if any
+func (s *PostgresStorage) GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error) {
+	return querySummary(ctx, s.db, "$", pdfHash, model, promptVersion)
+}
+
+// PutSummary caches a summary, keeping an existing entry
+func (s *PostgresStorage) PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error {
+	return execPutSummary(ctx, s.db, "$", pdfHash, model, promptVersion, summary)
+}
+
+// querySummary runs selectSummary with the given placeholder prefix
+func querySummary(ctx context.Context, db *sql.DB, prefix, pdfHash, model, promptVersion string) (string, bool, error) {
+	var summary string
+	err := db.QueryRowContext(ctx, fmt.Sprintf(selectSummary, prefix), pdfHash, model, promptVersion).Scan(&summary)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error querying summary cache: %v", err)
+	}
+	return summary, true, nil
+}
+
+// execPutSummary runs insertSummary with the given placeholder prefix
+func execPutSummary(ctx context.Context, db *sql.DB, prefix, pdfHash, model, promptVersion, summary string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(insertSummary, prefix),
+		pdfHash, model, promptVersion, summary, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error caching summary: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// processedColumns selects processed documents in ProcessedDocument order
+const processedColumns = `
+	SELECT title, url, timestamp, post_id, pdf_hash, summary, page_text, prompt_version,
+		summary_input, input_tokens, output_tokens, related_documents
+	FROM processed_documents`
+
+// importUpsert inserts a processed document or fills in the empty fields of
+// an existing one; the WHERE clause turns an import of known data into a
+// no-op, so RowsAffected counts only real changes. The summary usage fields
+// and related documents are filled in together, when the input is unknown.
+const importUpsert = `
+	INSERT INTO processed_documents (title, url, timestamp, post_id, pdf_hash, summary, page_text, prompt_version,
+		summary_input, input_tokens, output_tokens, related_documents)
+	VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7, %[1]s8, %[1]s9, %[1]s10, %[1]s11, %[1]s12)
+	ON CONFLICT (title, url) DO UPDATE SET
+		post_id = CASE WHEN processed_documents.post_id = '' THEN excluded.post_id ELSE processed_documents.post_id END,
+		pdf_hash = CASE WHEN processed_documents.pdf_hash = '' THEN excluded.pdf_hash ELSE processed_documents.pdf_hash END,
+		summary = CASE WHEN processed_documents.summary = '' THEN excluded.summary ELSE processed_documents.summary END,
+		page_text = CASE WHEN processed_documents.page_text = '' THEN excluded.page_text ELSE processed_documents.page_text END,
+		prompt_version = CASE WHEN processed_documents.prompt_version = '' THEN excluded.prompt_version ELSE processed_documents.prompt_version END,
+		summary_input = CASE WHEN processed_documents.summary_input = '' THEN excluded.summary_input ELSE processed_documents.summary_input END,
+		input_tokens = CASE WHEN processed_documents.summary_input = '' THEN excluded.input_tokens ELSE processed_documents.input_tokens END,
+		output_tokens = CASE WHEN processed_documents.summary_input = '' THEN excluded.output_tokens ELSE processed_documents.output_tokens END,
+		related_documents = CASE WHEN processed_documents.summary_input = '' THEN excluded.related_documents ELSE processed_documents.related_documents END
+	WHERE (processed_documents.post_id = '' AND excluded.post_id <> '')
+	   OR (processed_documents.pdf_hash = '' AND excluded.pdf_hash <> '')
+	   OR (processed_documents.summary = '' AND excluded.summary <> '')
+	   OR (processed_documents.page_text = '' AND excluded.page_text <> '')
+	   OR (processed_documents.prompt_version = '' AND excluded.prompt_version <> '')
+	   OR (processed_documents.summary_input = '' AND excluded.summary_input <> '')`
+
+// ExportDocuments streams every processed document, oldest first
+func (s *PostgresStorage) ExportDocuments(ctx context.Context, fn func(ProcessedDocument) error) error {
+	rows, err := s.db.QueryContext(ctx, processedColumns+" ORDER BY timestamp, id")
+	if err != nil {
+		return fmt.Errorf("error querying processed documents: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		doc, err := scanProcessed(rows)
+		if err != nil {
+			return fmt.Errorf("error scanning processed document: %v", err)
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating processed documents: %v", err)
+	}
+	return nil
+}
+
+// ImportDocuments upserts docs in a single transaction
+func (s *PostgresStorage) ImportDocuments(ctx context.Context, docs []ProcessedDocument) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(importUpsert, "$"))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing import: %v", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	changed := 0
+	for _, doc := range docs {
+		res, err := stmt.ExecContext(ctx, doc.Title, doc.URL, doc.Timestamp.UTC(), doc.PostID, doc.PDFHash, doc.Summary, doc.PageText, doc.PromptVersion,
+			doc.SummaryInput, doc.InputTokens, doc.OutputTokens, joinRelated(doc.RelatedDocuments))
+		if err != nil {
+			return 0, fmt.Errorf("error importing %q: %v", doc.Title, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			changed += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing import: %v", err)
+	}
+	return changed, nil
+}
+
+// Transfer statements for the state kept next to processed documents,
+// shared with SQLite; %[1]s is the placeholder prefix ("$" or "?"). Imports
+// insert with ON CONFLICT DO NOTHING, so known rows are kept as they are and
+// RowsAffected counts only new ones.
+const (
+	exportPlatformPosts = `
+		SELECT title, url, platform, post_id, posted_at
+		FROM platform_posts ORDER BY posted_at, title, url, platform`
+
+	exportHostedImages = `
+		SELECT image_id, url, delete_key, title, doc_url, uploaded_at
+		FROM hosted_images ORDER BY uploaded_at, image_id`
+
+	// Running jobs are exported as pending: their lease belongs to a worker
+	// of the old deployment
+	exportJobs = `
+		SELECT title, url, published, CASE WHEN status = 'running' THEN 'pending' ELSE status END,
+			run_after, created_at, digest
+		FROM document_jobs WHERE status <> 'done' ORDER BY created_at, id`
+
+	importJob = `
+		INSERT INTO document_jobs (title, url, published, status, run_after, created_at, digest)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7)
+		ON CONFLICT (title, url) DO NOTHING`
+
+	exportFailures = `
+		SELECT title, url, published, attempts, last_error, next_attempt_at, dead_lettered, updated_at
+		FROM document_failures ORDER BY updated_at, title, url`
+
+	importFailure = `
+		INSERT INTO document_failures (title, url, published, attempts, last_error, next_attempt_at, dead_lettered, updated_at)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7, %[1]s8)
+		ON CONFLICT (title, url) DO NOTHING`
+)
+
+// ExportPlatformPosts streams every platform post, oldest first
+func (s *PostgresStorage) ExportPlatformPosts(ctx context.Context, fn func(PlatformPost) error) error {
+	return queryExportPlatformPosts(ctx, s.db, fn)
+}
+
+// ImportPlatformPosts inserts posts in a single transaction
+func (s *PostgresStorage) ImportPlatformPosts(ctx context.Context, posts []PlatformPost) (int, error) {
+	return execImportPlatformPosts(ctx, s.db, "$", posts)
+}
+
+// ExportHostedImages streams every hosted image, oldest first
+func (s *PostgresStorage) ExportHostedImages(ctx context.Context, fn func(HostedImage) error) error {
+	return queryExportHostedImages(ctx, s.db, fn)
+}
+
+// ImportHostedImages inserts images in a single transaction
+func (s *PostgresStorage) ImportHostedImages(ctx context.Context, images []HostedImage) (int, error) {
+	return execImportHostedImages(ctx, s.db, "$", images)
+}
+
+// ExportJobs streams every job that is not done, oldest first
+func (s *PostgresStorage) ExportJobs(ctx context.Context, fn func(Job) error) error {
+	return queryExportJobs(ctx, s.db, fn)
+}
+
+// ImportJobs inserts jobs in a single transaction
+func (s *PostgresStorage) ImportJobs(ctx context.Context, jobs []Job) (int, error) {
+	return execImportJobs(ctx, s.db, "$", jobs)
+}
+
+// ExportFailures streams every failure record, oldest first
+func (s *PostgresStorage) ExportFailures(ctx context.Context, fn func(DocumentFailure) error) error {
+	return queryExportFailures(ctx, s.db, fn)
+}
+
+// ImportFailures inserts failure records in a single transaction
+func (s *PostgresStorage) ImportFailures(ctx context.Context, failures []DocumentFailure) (int, error) {
+	return execImportFailures(ctx, s.db, "$", failures)
+}
+
+// queryExportPlatformPosts runs exportPlatformPosts. Rows are collected
+// before fn is called, as SQLite's single pooled connection is busy while
+// rows are open; the table holds a few rows per document.
+func queryExportPlatformPosts(ctx context.Context, db *sql.DB, fn func(PlatformPost) error) error {
+	rows, err := db.QueryContext(ctx, exportPlatformPosts)
+	if err != nil {
+		return fmt.Errorf("error querying platform posts: %v", err)
+	}
+	var posts []PlatformPost
+	for rows.Next() {
+		var p PlatformPost
+		if err := rows.Scan(&p.Title, &p.URL, &p.Platform, &p.PostID, &p.PostedAt); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning platform post: %v", err)
+		}
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("error iterating platform posts: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, p := range posts {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryExportHostedImages runs exportHostedImages, collecting the rows
+// before calling fn like queryExportPlatformPosts
+func queryExportHostedImages(ctx context.Context, db *sql.DB, fn func(HostedImage) error) error {
+	rows, err := db.QueryContext(ctx, exportHostedImages)
+	if err != nil {
+		return fmt.Errorf("error querying hosted images: %v", err)
+	}
+	var images []HostedImage
+	for rows.Next() {
+		var img HostedImage
+		if err := rows.Scan(&img.ImageID, &img.URL, &img.DeleteKey, &img.Title, &img.DocURL, &img.UploadedAt); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning hosted image: %v", err)
+		}
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("error iterating hosted images: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, img := range images {
+		if err := fn(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryExportJobs runs exportJobs, collecting the rows before calling fn
+// like queryExportPlatformPosts
+func queryExportJobs(ctx context.Context, db *sql.DB, fn func(Job) error) error {
+	rows, err := db.QueryContext(ctx, exportJobs)
+	if err != nil {
+		return fmt.Errorf("error querying jobs: %v", err)
+	}
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		var digest string
+		if err := rows.Scan(&job.Title, &job.URL, &job.Published, &job.Status, &job.RunAfter, &job.CreatedAt, &digest); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning job: %v", err)
+		}
+		if job.Digest, err = decodeDigest(digest); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("error iterating jobs: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, job := range jobs {
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryExportFailures runs exportFailures, collecting the rows before
+// calling fn like queryExportPlatformPosts
+func queryExportFailures(ctx context.Context, db *sql.DB, fn func(DocumentFailure) error) error {
+	rows, err := db.QueryContext(ctx, exportFailures)
+	if err != nil {
+		return fmt.Errorf("error querying document failures: %v", err)
+	}
+	var failures []DocumentFailure
+	for rows.Next() {
+		var f DocumentFailure
+		if err := rows.Scan(&f.Title, &f.URL, &f.Published, &f.Attempts, &f.LastError, &f.NextAttemptAt, &f.DeadLettered, &f.UpdatedAt); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning document failure: %v", err)
+		}
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("error iterating document failures: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, f := range failures {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execImportPlatformPosts runs insertPlatformPost for each post with the
+// given placeholder prefix
+func execImportPlatformPosts(ctx context.Context, db *sql.DB, prefix string, posts []PlatformPost) (int, error) {
+	args := make([][]any, 0, len(posts))
+	for _, p := range posts {
+		args = append(args, []any{p.Title, p.URL, p.Platform, p.PostID, p.PostedAt.UTC()})
+	}
+	return execImport(ctx, db, fmt.Sprintf(insertPlatformPost, prefix), "platform posts", args)
+}
+
+// execImportHostedImages runs insertHostedImage for each image with the
+// given placeholder prefix
+func execImportHostedImages(ctx context.Context, db *sql.DB, prefix string, images []HostedImage) (int, error) {
+	args := make([][]any, 0, len(images))
+	for _, img := range images {
+		args = append(args, []any{img.ImageID, img.URL, img.DeleteKey, img.Title, img.DocURL, img.UploadedAt.UTC()})
+	}
+	return execImport(ctx, db, fmt.Sprintf(insertHostedImage, prefix), "hosted images", args)
+}
+
+// execImportJobs runs importJob for each job with the given placeholder
+// prefix
+func execImportJobs(ctx context.Context, db *sql.DB, prefix string, jobs []Job) (int, error) {
+	args := make([][]any, 0, len(jobs))
+	for _, job := range jobs {
+		digest := ""
+		if job.Digest != nil {
+			var err error
+			if digest, err = encodeDigest(job.Digest); err != nil {
+				return 0, err
+			}
+		}
+		args = append(args, []any{job.Title, job.URL, job.Published.UTC(), string(job.Status),
+			job.RunAfter.UTC(), job.CreatedAt.UTC(), digest})
+	}
+	return execImport(ctx, db, fmt.Sprintf(importJob, prefix), "jobs", args)
+}
+
+// execImportFailures runs importFailure for each failure record with the
+// given placeholder prefix
+func execImportFailures(ctx context.Context, db *sql.DB, prefix string, failures []DocumentFailure) (int, error) {
+	args := make([][]any, 0, len(failures))
+	for _, f := range failures {
+		args = append(args, []any{f.Title, f.URL, f.Published.UTC(), f.Attempts, f.LastError,
+			f.NextAttemptAt.UTC(), f.DeadLettered, f.UpdatedAt.UTC()})
+	}
+	return execImport(ctx, db, fmt.Sprintf(importFailure, prefix), "document failures", args)
+}
+
+// execImport runs stmt once per argument list in a single transaction and
+// returns the rows changed
+func execImport(ctx context.Context, db *sql.DB, stmt, what string, args [][]any) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	prepared, err := tx.PrepareContext(ctx, stmt)
+	if err != nil {
+		return 0, fmt.Errorf("error preparing import of %s: %v", what, err)
+	}
+	defer func() { _ = prepared.Close() }()
+
+	changed := 0
+	for _, a := range args {
+		res, err := prepared.ExecContext(ctx, a...)
+		if err != nil {
+			return 0, fmt.Errorf("error importing %s: %v", what, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			changed += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing import of %s: %v", what, err)
+	}
+	return changed, nil
+}
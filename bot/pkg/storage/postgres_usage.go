@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Summary usage statements shared with SQLite; %[1]s is the placeholder
+// prefix ("$" or "?")
+const (
+	upsertSummaryUsage = `
+		INSERT INTO summary_usage (day, title, url, model, calls, input_tokens, output_tokens, thinking_tokens, cost)
+		VALUES (%[1]s1, %[1]s2, %[1]s3, %[1]s4, %[1]s5, %[1]s6, %[1]s7, %[1]s8, %[1]s9)
+		ON CONFLICT (day, title, url, model) DO UPDATE SET
+			calls = summary_usage.calls + excluded.calls,
+			input_tokens = summary_usage.input_tokens + excluded.input_tokens,
+			output_tokens = summary_usage.output_tokens + excluded.output_tokens,
+			thinking_tokens = summary_usage.thinking_tokens + excluded.thinking_tokens,
+			cost = summary_usage.cost + excluded.cost`
+
+	selectDailySummaryUsage = `
+		SELECT day, model, SUM(calls), SUM(input_tokens), SUM(output_tokens), SUM(thinking_tokens), SUM(cost)
+		FROM summary_usage WHERE day >= %[1]s1
+		GROUP BY day, model ORDER BY day DESC, model`
+)
+
+// AddSummaryUsage adds usage to the totals of its day, document and model
+func (s *PostgresStorage) AddSummaryUsage(ctx context.Context, usage SummaryUsage) error {
+	return execAddSummaryUsage(ctx, s.db, "$", usage)
+}
+
+// DailySummaryUsage returns the usage per day and model since the given day
+func (s *PostgresStorage) DailySummaryUsage(ctx context.Context, since string) ([]SummaryUsage, error) {
+	return queryDailySummaryUsage(ctx, s.db, "$", since)
+}
+
+// execAddSummaryUsage runs upsertSummaryUsage with the given placeholder
+// prefix
+func execAddSummaryUsage(ctx context.Context, db *sql.DB, prefix string, u SummaryUsage) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(upsertSummaryUsage, prefix),
+		u.Day, u.Title, u.URL, u.Model, u.Calls, u.InputTokens, u.OutputTokens, u.ThinkingTokens, u.Cost)
+	if err != nil {
+		return fmt.Errorf("error adding summary usage: %v", err)
+	}
+	return nil
+}
+
+// queryDailySummaryUsage runs selectDailySummaryUsage with the given
+// placeholder prefix
+func queryDailySummaryUsage(ctx context.Context, db *sql.DB, prefix, since string) ([]SummaryUsage, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(selectDailySummaryUsage, prefix), since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying summary usage: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var usage []SummaryUsage
+	for rows.Next() {
+		var u SummaryUsage
+		if err := rows.Scan(&u.Day, &u.Model, &u.Calls, &u.InputTokens, &u.OutputTokens,
+			&u.ThinkingTokens, &u.Cost); err != nil {
+			return nil, fmt.Errorf("error scanning summary usage: %v", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating summary usage: %v", err)
+	}
+	return usage, nil
+}
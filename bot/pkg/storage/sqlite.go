@@ -0,0 +1,346 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bot/pkg/scraper"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStorage implements the StorageInterface on an embedded SQLite file,
+// for hobby deployments and local development without a Postgres server.
+// The pure-Go driver keeps the build free of extra C dependencies.
+//
+// An SQLite file serves a single bot instance: leader election always
+// succeeds and job claims rely on SQLite serialising writers instead of
+// row locks.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// sqliteSchema mirrors the Postgres tables (see postgresSchema) with SQLite
+// column types. Statements run in order on every start; SQLite has no ADD
+// COLUMN IF NOT EXISTS, so entries named table.column are skipped once the
+// column exists.
+var sqliteSchema = []struct {
+	name string
+	stmt string
+}{
+	{"processed_documents", `
+		CREATE TABLE IF NOT EXISTS processed_documents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			timestamp TIMESTAMP NOT NULL,
+			UNIQUE(title, url)
+		)`},
+	{"processed_documents.post_id", `
+		ALTER TABLE processed_documents ADD COLUMN post_id TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.pdf_hash", `
+		ALTER TABLE processed_documents ADD COLUMN pdf_hash TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.summary", `
+		ALTER TABLE processed_documents ADD COLUMN summary TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.page_text", `
+		ALTER TABLE processed_documents ADD COLUMN page_text TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.prompt_version", `
+		ALTER TABLE processed_documents ADD COLUMN prompt_version TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.summary_input", `
+		ALTER TABLE processed_documents ADD COLUMN summary_input TEXT NOT NULL DEFAULT ''`},
+	{"processed_documents.input_tokens", `
+		ALTER TABLE processed_documents ADD COLUMN input_tokens INTEGER NOT NULL DEFAULT 0`},
+	{"processed_documents.output_tokens", `
+		ALTER TABLE processed_documents ADD COLUMN output_tokens INTEGER NOT NULL DEFAULT 0`},
+	{"processed_documents.related_documents", `
+		ALTER TABLE processed_documents ADD COLUMN related_documents TEXT NOT NULL DEFAULT ''`},
+	{"document_failures", `
+		CREATE TABLE IF NOT EXISTS document_failures (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			published TIMESTAMP NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			next_attempt_at TIMESTAMP NOT NULL,
+			dead_lettered BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url)
+		)`},
+	{"document_jobs", `
+		CREATE TABLE IF NOT EXISTS document_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			published TIMESTAMP NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			run_after TIMESTAMP NOT NULL,
+			locked_by TEXT,
+			locked_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			UNIQUE(title, url)
+		)`},
+	{"document_jobs_due_idx", `
+		CREATE INDEX IF NOT EXISTS document_jobs_due_idx
+		ON document_jobs (status, run_after)`},
+	{"document_jobs.digest", `
+		ALTER TABLE document_jobs ADD COLUMN digest TEXT NOT NULL DEFAULT ''`},
+	{"hosted_images", `
+		CREATE TABLE IF NOT EXISTS hosted_images (
+			image_id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			delete_key TEXT NOT NULL,
+			title TEXT NOT NULL,
+			doc_url TEXT NOT NULL,
+			uploaded_at TIMESTAMP NOT NULL
+		)`},
+	{"hosted_images_uploaded_idx", `
+		CREATE INDEX IF NOT EXISTS hosted_images_uploaded_idx
+		ON hosted_images (uploaded_at)`},
+	{"summary_cache", `
+		CREATE TABLE IF NOT EXISTS summary_cache (
+			pdf_hash TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (pdf_hash, model, prompt_version)
+		)`},
+	{"summary_reviews", `
+		CREATE TABLE IF NOT EXISTS summary_reviews (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			post_id TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url)
+		)`},
+	{"summary_usage", `
+		CREATE TABLE IF NOT EXISTS summary_usage (
+			day TEXT NOT NULL,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			model TEXT NOT NULL,
+			calls INTEGER NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			thinking_tokens INTEGER NOT NULL,
+			cost REAL NOT NULL,
+			PRIMARY KEY (day, title, url, model)
+		)`},
+	{"summary_shadows", `
+		CREATE TABLE IF NOT EXISTS summary_shadows (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			candidate TEXT NOT NULL,
+			model TEXT NOT NULL,
+			prompt_version TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			error TEXT NOT NULL,
+			latency_ms BIGINT NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			thinking_tokens INTEGER NOT NULL,
+			cost REAL NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)`},
+	{"summary_shadows_created_idx", `
+		CREATE INDEX IF NOT EXISTS summary_shadows_created_idx
+		ON summary_shadows (created_at)`},
+	{"platform_posts", `
+		CREATE TABLE IF NOT EXISTS platform_posts (
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			platform TEXT NOT NULL,
+			post_id TEXT NOT NULL,
+			posted_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (title, url, platform)
+		)`},
+	{"baselines", `
+		CREATE TABLE IF NOT EXISTS baselines (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			mode TEXT NOT NULL,
+			seen INTEGER NOT NULL,
+			posted INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)`},
+}
+
+// NewSQLite opens (creating if needed) the SQLite database at path
+func NewSQLite(path string) (StorageInterface, error) {
+	ctxLog := log.WithContext("method", "NewSQLite")
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			ctxLog.Error("Error creating database directory", "error", err)
+			return nil, fmt.Errorf("error creating database directory: %v", err)
+		}
+	}
+
+	// WAL lets readers proceed during a write; busy_timeout makes a writer
+	// wait for the lock instead of failing with SQLITE_BUSY.
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+	ctxLog.Info("Opening SQLite database", "path", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		ctxLog.Error("Error opening database", "error", err)
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+
+	// SQLite allows one writer at a time; a single connection serialises
+	// access in the pool instead of surfacing lock errors to callers.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		ctxLog.Error("Error pinging database", "error", err)
+		return nil, fmt.Errorf("error pinging database: %v", err)
+	}
+
+	for _, m := range sqliteSchema {
+		if table, column, ok := strings.Cut(m.name, "."); ok {
+			exists, err := sqliteColumnExists(db, table, column)
+			if err != nil {
+				ctxLog.Error("Error inspecting schema", "table", table, "error", err)
+				return nil, fmt.Errorf("error inspecting schema for %s: %v", m.name, err)
+			}
+			if exists {
+				continue
+			}
+		}
+		if _, err := db.Exec(m.stmt); err != nil {
+			ctxLog.Error("Error applying schema", "table", m.name, "error", err)
+			return nil, fmt.Errorf("error applying schema for %s: %v", m.name, err)
+		}
+	}
+
+	ctxLog.Info("SQLite storage initialized successfully")
+	return &SQLiteStorage{
+		db: db,
+	}, nil
+}
+
+// sqliteColumnExists reports whether table has the given column
+func sqliteColumnExists(db *sql.DB, table, column string) (bool, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?1) WHERE name = ?2", table, column).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CheckConnection checks if the database is still usable
+func (s *SQLiteStorage) CheckConnection(ctx context.Context) error {
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "CheckConnection")
+
+	err := s.db.PingContext(ctx)
+	if err != nil {
+		ctxLog.Error("Database connection check failed", "error", err)
+	}
+	return err
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	ctxLog := log.WithContext("method", "Close")
+
+	ctxLog.Info("Closing SQLite database")
+	return s.db.Close()
+}
+
+// TryAcquireLeaderLock always succeeds: an SQLite file serves one instance
+func (s *SQLiteStorage) TryAcquireLeaderLock(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// ReleaseLeaderLock is a no-op, see TryAcquireLeaderLock
+func (s *SQLiteStorage) ReleaseLeaderLock(ctx context.Context) error {
+	return nil
+}
+
+// AddProcessedDocument adds a document to the processed documents list.
+// Re-adding an already processed document is a no-op, as with Postgres.
+func (s *SQLiteStorage) AddProcessedDocument(ctx context.Context, doc ProcessedDocument) error {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "AddProcessedDocument").
+		WithContext("url", doc.URL)
+
+	ctxLog.Info(fmt.Sprintf("Adding document to processed list: %s", doc.Title))
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO processed_documents (title, url, timestamp, post_id, pdf_hash, summary, page_text, prompt_version,
+			summary_input, input_tokens, output_tokens, related_documents)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12) ON CONFLICT (title, url) DO NOTHING`,
+		doc.Title, doc.URL, doc.Timestamp.UTC(), doc.PostID, doc.PDFHash, doc.Summary, doc.PageText, doc.PromptVersion,
+		doc.SummaryInput, doc.InputTokens, doc.OutputTokens, joinRelated(doc.RelatedDocuments),
+	)
+	if err != nil {
+		ctxLog.ErrorWithType("Error inserting document", err)
+		return fmt.Errorf("error inserting document: %v", err)
+	}
+
+	if rows, raErr := res.RowsAffected(); raErr == nil && rows == 0 {
+		ctxLog.Info("Document already processed, skipping")
+		return nil
+	}
+
+	ctxLog.Info("Document added to processed list successfully")
+	return nil
+}
+
+// FilterProcessed returns the set of already-processed documents among docs
+// in a single query, keyed by DocKey(title, url).
+func (s *SQLiteStorage) FilterProcessed(ctx context.Context, docs []*scraper.Document) (map[string]bool, error) {
+	processed := make(map[string]bool, len(docs))
+	if len(docs) == 0 {
+		return processed, nil
+	}
+
+	placeholders, args := sqliteDocPairs(docs)
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT title, url FROM processed_documents WHERE (title, url) IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying processed documents: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var title, url string
+		if err := rows.Scan(&title, &url); err != nil {
+			return nil, fmt.Errorf("error scanning processed document: %v", err)
+		}
+		processed[DocKey(title, url)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating processed documents: %v", err)
+	}
+	return processed, nil
+}
+
+// CountProcessed returns the number of processed documents
+func (s *SQLiteStorage) CountProcessed(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_documents").Scan(&n); err != nil {
+		return 0, fmt.Errorf("error counting processed documents: %v", err)
+	}
+	return n, nil
+}
+
+// sqliteDocPairs builds "(?1, ?2), (?3, ?4), ..." and the matching
+// title/url arguments for a row-value IN clause.
+func sqliteDocPairs(docs []*scraper.Document) (string, []any) {
+	placeholders := make([]string, 0, len(docs))
+	args := make([]any, 0, len(docs)*2)
+	for i, doc := range docs {
+		placeholders = append(placeholders, fmt.Sprintf("(?%d, ?%d)", i*2+1, i*2+2))
+		args = append(args, doc.Title, doc.URL)
+	}
+	return strings.Join(placeholders, ", "), args
+}
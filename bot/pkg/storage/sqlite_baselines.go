@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+
+	"bot/pkg/scraper"
+)
+
+// AddBaseline records a baseline
+func (s *SQLiteStorage) AddBaseline(ctx context.Context, b Baseline) error {
+	return execAddBaseline(ctx, s.db, "?", b)
+}
+
+// ApplyBaseline marks docs as processed and records b in a single
+// transaction
+func (s *SQLiteStorage) ApplyBaseline(ctx context.Context, b Baseline, docs []*scraper.Document) error {
+	return execApplyBaseline(ctx, s.db, "?", b, docs)
+}
+
+// LastBaseline returns the latest baseline of the given mode
+func (s *SQLiteStorage) LastBaseline(ctx context.Context, mode string) (*Baseline, error) {
+	return queryLastBaseline(ctx, s.db, "?", mode)
+}
@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"context"
+
+	"bot/pkg/scraper"
+)
+
+// EnqueueDigest queues the digest job and marks its documents processed in
+// a single transaction
+func (s *SQLiteStorage) EnqueueDigest(ctx context.Context, digest *scraper.Document, docs []*scraper.Document) (bool, error) {
+	return execEnqueueDigest(ctx, s.db, "?", digest, docs)
+}
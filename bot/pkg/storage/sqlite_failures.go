@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordFailure increments the document's attempt count and schedules its
+// next attempt, in one transaction (see PostgresStorage.RecordFailure).
+func (s *SQLiteStorage) RecordFailure(ctx context.Context, doc ProcessedDocument, errMsg string, policy RetryPolicy) (DocumentFailure, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return DocumentFailure{}, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+	failure := DocumentFailure{
+		Title:     doc.Title,
+		URL:       doc.URL,
+		Published: doc.Timestamp,
+		LastError: errMsg,
+		UpdatedAt: now,
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO document_failures (title, url, published, attempts, last_error, next_attempt_at, updated_at)
+		VALUES (?1, ?2, ?3, 1, ?4, ?5, ?5)
+		ON CONFLICT (title, url) DO UPDATE SET
+			attempts = document_failures.attempts + 1,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at`,
+		doc.Title, doc.URL, doc.Timestamp.UTC(), errMsg, now,
+	)
+	if err != nil {
+		return DocumentFailure{}, fmt.Errorf("error recording failure: %v", err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT attempts FROM document_failures WHERE title = ?1 AND url = ?2",
+		doc.Title, doc.URL,
+	).Scan(&failure.Attempts)
+	if err != nil {
+		return DocumentFailure{}, fmt.Errorf("error reading failure count: %v", err)
+	}
+
+	failure.DeadLettered = failure.Attempts >= policy.MaxAttempts
+	failure.NextAttemptAt = now.Add(policy.Backoff(failure.Attempts))
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE document_failures SET next_attempt_at = ?3, dead_lettered = ?4 WHERE title = ?1 AND url = ?2",
+		doc.Title, doc.URL, failure.NextAttemptAt, failure.DeadLettered,
+	)
+	if err != nil {
+		return DocumentFailure{}, fmt.Errorf("error scheduling retry: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return DocumentFailure{}, fmt.Errorf("error committing failure: %v", err)
+	}
+	return failure, nil
+}
+
+// ClearFailure removes the failure record of a document
+func (s *SQLiteStorage) ClearFailure(ctx context.Context, title, url string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM document_failures WHERE title = ?1 AND url = ?2", title, url)
+	if err != nil {
+		return fmt.Errorf("error clearing document failure: %v", err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns all dead-lettered documents, most recent first
+func (s *SQLiteStorage) ListDeadLetters(ctx context.Context) ([]DocumentFailure, error) {
+	rows, err := s.db.QueryContext(ctx,
+		failureColumns+" WHERE dead_lettered ORDER BY updated_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead letters: %v", err)
+	}
+	return scanFailures(rows)
+}
+
+// ReplayDeadLetter resets a dead letter's attempts and re-queues its job
+func (s *SQLiteStorage) ReplayDeadLetter(ctx context.Context, title, url string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+		UPDATE document_failures
+		SET attempts = 0, dead_lettered = FALSE, next_attempt_at = ?3, updated_at = ?3
+		WHERE title = ?1 AND url = ?2 AND dead_lettered`,
+		title, url, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error replaying dead letter: %v", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error replaying dead letter: %v", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO document_jobs (title, url, published, status, run_after, created_at)
+		SELECT title, url, published, 'pending', ?3, ?3 FROM document_failures
+		WHERE title = ?1 AND url = ?2
+		ON CONFLICT (title, url) DO UPDATE SET
+			status = 'pending', run_after = excluded.run_after, locked_by = NULL, locked_at = NULL`,
+		title, url, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error re-queueing dead letter: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing replay: %v", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "context"
+
+// AddPlatformPost records a document's post on a platform
+func (s *SQLiteStorage) AddPlatformPost(ctx context.Context, post PlatformPost) error {
+	return execAddPlatformPost(ctx, s.db, "?", post)
+}
+
+// ListPlatformPosts returns the platforms a document was published on
+func (s *SQLiteStorage) ListPlatformPosts(ctx context.Context, title, url string) ([]PlatformPost, error) {
+	return queryPlatformPosts(ctx, s.db, "?", title, url)
+}
@@ -0,0 +1,11 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// RecentDocuments returns up to limit posted documents published since since
+func (s *SQLiteStorage) RecentDocuments(ctx context.Context, since time.Time, limit int) ([]ProcessedDocument, error) {
+	return queryRecentDocuments(ctx, s.db, "?", since, limit)
+}
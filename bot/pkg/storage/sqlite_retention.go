@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PrunePageText clears the page text of documents published before before
+func (s *SQLiteStorage) PrunePageText(ctx context.Context, before time.Time) (int, error) {
+	return execPrunePageText(ctx, s.db, "?", before)
+}
+
+// PruneJobs deletes done digest jobs and done jobs of processed documents
+// queued before before
+func (s *SQLiteStorage) PruneJobs(ctx context.Context, before time.Time) (int, error) {
+	return execPruneJobs(ctx, s.db, "?", before)
+}
+
+// PruneSummaries deletes summaries cached before before
+func (s *SQLiteStorage) PruneSummaries(ctx context.Context, before time.Time) (int, error) {
+	return execPruneSummaries(ctx, s.db, "?", before)
+}
+
+// AddHostedImages records uploaded images in a single transaction
+func (s *SQLiteStorage) AddHostedImages(ctx context.Context, images []HostedImage) error {
+	return execAddHostedImages(ctx, s.db, "?", images)
+}
+
+// ListHostedImages returns up to limit images uploaded before before
+func (s *SQLiteStorage) ListHostedImages(ctx context.Context, before time.Time, limit int) ([]HostedImage, error) {
+	return queryHostedImages(ctx, s.db, "?", before, limit)
+}
+
+// DeleteHostedImage forgets a deleted image
+func (s *SQLiteStorage) DeleteHostedImage(ctx context.Context, imageID string) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(deleteHostedImage, "?"), imageID); err != nil {
+		return fmt.Errorf("error deleting hosted image: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import "context"
+
+// AddSummaryReview holds a rejected summary for review
+func (s *SQLiteStorage) AddSummaryReview(ctx context.Context, review SummaryReview) error {
+	return execAddSummaryReview(ctx, s.db, "?", review)
+}
+
+// ListSummaryReviews returns the held summaries, most recent first
+func (s *SQLiteStorage) ListSummaryReviews(ctx context.Context) ([]SummaryReview, error) {
+	return querySummaryReviews(ctx, s.db)
+}
+
+// ResolveSummaryReview removes a held summary
+func (s *SQLiteStorage) ResolveSummaryReview(ctx context.Context, title, url string) (bool, error) {
+	return execResolveSummaryReview(ctx, s.db, "?", title, url)
+}
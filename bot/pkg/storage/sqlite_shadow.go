@@ -0,0 +1,14 @@
+package storage
+
+import "context"
+
+// AddShadowSummaries records the summaries of one document
+func (s *SQLiteStorage) AddShadowSummaries(ctx context.Context, summaries []ShadowSummary) error {
+	return execAddShadowSummaries(ctx, s.db, "?", summaries)
+}
+
+// ListShadowSummaries returns the summaries of the most recently summarized
+// documents
+func (s *SQLiteStorage) ListShadowSummaries(ctx context.Context, limit int) ([]ShadowSummary, error) {
+	return queryShadowSummaries(ctx, s.db, "?", limit)
+}
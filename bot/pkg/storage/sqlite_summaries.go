@@ -0,0 +1,13 @@
+package storage
+
+import "context"
+
+// GetSummary returns the cached summary for the key, if any
+func (s *SQLiteStorage) GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error) {
+	return querySummary(ctx, s.db, "?", pdfHash, model, promptVersion)
+}
+
+// PutSummary caches a summary, keeping an existing entry
+func (s *SQLiteStorage) PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error {
+	return execPutSummary(ctx, s.db, "?", pdfHash, model, promptVersion, summary)
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+func newTestSQLite(t *testing.T) StorageInterface {
+	t.Helper()
+	store, err := NewSQLite(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestSQLiteProcessedDocuments(t *testing.T) {
+	testProcessedDocuments(t, newTestSQLite(t))
+}
+
+func TestSQLiteJobQueue(t *testing.T) {
+	testJobQueue(t, newTestSQLite(t))
+}
+
+func TestSQLiteFailureCounting(t *testing.T) {
+	testFailureCounting(t, newTestSQLite(t))
+}
+
+func TestSQLiteDeadLetterReplay(t *testing.T) {
+	testDeadLetterReplay(t, newTestSQLite(t))
+}
+
+func TestSQLiteExportImport(t *testing.T) {
+	testExportImport(t, newTestSQLite(t), newTestSQLite(t))
+}
+
+func TestSQLiteExportImportState(t *testing.T) {
+	testExportImportState(t, newTestSQLite(t), newTestSQLite(t))
+}
+
+func TestSQLiteRetention(t *testing.T) {
+	testRetention(t, newTestSQLite(t))
+}
+
+func TestSQLiteSummaryCache(t *testing.T) {
+	testSummaryCache(t, newTestSQLite(t))
+}
+
+func TestSQLiteSummaryReviews(t *testing.T) {
+	testSummaryReviews(t, newTestSQLite(t))
+}
+
+func TestSQLiteSummaryUsage(t *testing.T) {
+	testSummaryUsage(t, newTestSQLite(t))
+}
+
+func TestSQLiteRecentDocuments(t *testing.T) {
+	testRecentDocuments(t, newTestSQLite(t))
+}
+
+func TestSQLiteShadowSummaries(t *testing.T) {
+	testShadowSummaries(t, newTestSQLite(t))
+}
+
+func TestSQLitePlatformPosts(t *testing.T) {
+	testPlatformPosts(t, newTestSQLite(t))
+}
+
+func TestSQLiteBaselines(t *testing.T) {
+	testBaselines(t, newTestSQLite(t))
+}
+
+func TestSQLiteApplyBaselineRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLite(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	// Fail midway, on the second document
+	if _, err := store.(*SQLiteStorage).db.ExecContext(ctx, `
+		CREATE TRIGGER fail_doc_2 BEFORE INSERT ON processed_documents
+		WHEN NEW.title = 'Doc 2'
+		BEGIN SELECT RAISE(ABORT, 'injected failure'); END`); err != nil {
+		t.Fatal(err)
+	}
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: time.Now()},
+		{Title: "Doc 2", URL: "u2", Published: time.Now()},
+		{Title: "Doc 3", URL: "u3", Published: time.Now()},
+	}
+	if err := store.ApplyBaseline(ctx, Baseline{Mode: "auto", Seen: 3, CreatedAt: time.Now()}, docs); err == nil {
+		t.Fatal("ApplyBaseline succeeded despite the failing insert")
+	}
+
+	// Nothing is kept, so auto mode still sees an empty database
+	if n, err := store.CountProcessed(ctx); err != nil || n != 0 {
+		t.Errorf("CountProcessed = %d, %v; want 0", n, err)
+	}
+	if b, err := store.LastBaseline(ctx, "auto"); err != nil || b != nil {
+		t.Errorf("LastBaseline = %+v, %v; want none", b, err)
+	}
+}
+
+func TestSQLiteDigestJobs(t *testing.T) {
+	testDigestJobs(t, newTestSQLite(t))
+}
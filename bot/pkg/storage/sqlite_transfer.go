@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExportDocuments streams every processed document, oldest first
+func (s *SQLiteStorage) ExportDocuments(ctx context.Context, fn func(ProcessedDocument) error) error {
+	rows, err := s.db.QueryContext(ctx, processedColumns+" ORDER BY timestamp, id")
+	if err != nil {
+		return fmt.Errorf("error querying processed documents: %v", err)
+	}
+
+	// Collect before calling fn: the single pooled connection is busy while
+	// rows are open, so fn could not touch the database otherwise
+	var docs []ProcessedDocument
+	for rows.Next() {
+		doc, err := scanProcessed(rows)
+		if err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning processed document: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("error iterating processed documents: %v", err)
+	}
+	_ = rows.Close()
+
+	for _, doc := range docs {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportDocuments upserts docs in a single transaction
+func (s *SQLiteStorage) ImportDocuments(ctx context.Context, docs []ProcessedDocument) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(importUpsert, "?"))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing import: %v", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	changed := 0
+	for _, doc := range docs {
+		res, err := stmt.ExecContext(ctx, doc.Title, doc.URL, doc.Timestamp.UTC(), doc.PostID, doc.PDFHash, doc.Summary, doc.PageText, doc.PromptVersion,
+			doc.SummaryInput, doc.InputTokens, doc.OutputTokens, joinRelated(doc.RelatedDocuments))
+		if err != nil {
+			return 0, fmt.Errorf("error importing %q: %v", doc.Title, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			changed += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing import: %v", err)
+	}
+	return changed, nil
+}
+
+// ExportPlatformPosts streams every platform post, oldest first
+func (s *SQLiteStorage) ExportPlatformPosts(ctx context.Context, fn func(PlatformPost) error) error {
+	return queryExportPlatformPosts(ctx, s.db, fn)
+}
+
+// ImportPlatformPosts inserts posts in a single transaction
+func (s *SQLiteStorage) ImportPlatformPosts(ctx context.Context, posts []PlatformPost) (int, error) {
+	return execImportPlatformPosts(ctx, s.db, "?", posts)
+}
+
+// ExportHostedImages streams every hosted image, oldest first
+func (s *SQLiteStorage) ExportHostedImages(ctx context.Context, fn func(HostedImage) error) error {
+	return queryExportHostedImages(ctx, s.db, fn)
+}
+
+// ImportHostedImages inserts images in a single transaction
+func (s *SQLiteStorage) ImportHostedImages(ctx context.Context, images []HostedImage) (int, error) {
+	return execImportHostedImages(ctx, s.db, "?", images)
+}
+
+// ExportJobs streams every job that is not done, oldest first
+func (s *SQLiteStorage) ExportJobs(ctx context.Context, fn func(Job) error) error {
+	return queryExportJobs(ctx, s.db, fn)
+}
+
+// ImportJobs inserts jobs in a single transaction
+func (s *SQLiteStorage) ImportJobs(ctx context.Context, jobs []Job) (int, error) {
+	return execImportJobs(ctx, s.db, "?", jobs)
+}
+
+// ExportFailures streams every failure record, oldest first
+func (s *SQLiteStorage) ExportFailures(ctx context.Context, fn func(DocumentFailure) error) error {
+	return queryExportFailures(ctx, s.db, fn)
+}
+
+// ImportFailures inserts failure records in a single transaction
+func (s *SQLiteStorage) ImportFailures(ctx context.Context, failures []DocumentFailure) (int, error) {
+	return execImportFailures(ctx, s.db, "?", failures)
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "context"
+
+// AddSummaryUsage adds usage to the totals of its day, document and model
+func (s *SQLiteStorage) AddSummaryUsage(ctx context.Context, usage SummaryUsage) error {
+	return execAddSummaryUsage(ctx, s.db, "?", usage)
+}
+
+// DailySummaryUsage returns the usage per day and model since the given day
+func (s *SQLiteStorage) DailySummaryUsage(ctx context.Context, since string) ([]SummaryUsage, error) {
+	return queryDailySummaryUsage(ctx, s.db, "?", since)
+}
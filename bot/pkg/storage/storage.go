@@ -6,11 +6,180 @@ import (
 	"time"
 )
 
-// ProcessedDocument represents a document that has been processed by the bot
+// ProcessedDocument represents a document that has been processed by the bot.
+// PostID, PDFHash, Summary and PageText are empty for documents that were
+// marked seen without being posted (baseline, catch-up) and for rows
+// predating them. PageText is also cleared once past retention.
+// PromptVersion names the prompt template the summary was generated with.
+// SummaryInput records what the model was given ("text", "pdf", or "cache"
+// when the summary was reused) and InputTokens and OutputTokens what the
+// summary cost. RelatedDocuments are the URLs of the earlier documents the
+// model was given as context.
 type ProcessedDocument struct {
-	Title     string
-	URL       string
-	Timestamp time.Time
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+	PostID    string    `json:"post_id,omitempty"`
+	PDFHash   string    `json:"pdf_hash,omitempty"`
+	Summary   string    `json:"summary,omitempty"`
+	PageText  string    `json:"page_text,omitempty"`
+
+	PromptVersion string `json:"prompt_version,omitempty"`
+	SummaryInput  string `json:"summary_input,omitempty"`
+	InputTokens   int    `json:"input_tokens,omitempty"`
+	OutputTokens  int    `json:"output_tokens,omitempty"`
+
+	RelatedDocuments []string `json:"related_documents,omitempty"`
+}
+
+// HostedImage is a page image uploaded to Picsur for a document, kept with
+// its delete key so it can be removed once past retention
+type HostedImage struct {
+	ImageID    string    `json:"image_id"`
+	URL        string    `json:"url"`
+	DeleteKey  string    `json:"delete_key"`
+	Title      string    `json:"title"`
+	DocURL     string    `json:"doc_url"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// SummaryReview is a generated summary that failed the guardrails. The
+// document is posted without it and the summary is held here until an
+// operator resolves it.
+type SummaryReview struct {
+	Title         string
+	URL           string
+	PostID        string
+	Model         string
+	PromptVersion string
+	Summary       string
+	Reason        string
+	CreatedAt     time.Time
+}
+
+// SummaryUsage is the tokens and cost of summarization calls on one UTC day
+// (formatted "2006-01-02"). Recorded usage is per document and model;
+// daily totals leave Title and URL empty.
+type SummaryUsage struct {
+	Day            string
+	Title          string
+	URL            string
+	Model          string
+	Calls          int
+	InputTokens    int
+	OutputTokens   int // including thinking tokens
+	ThinkingTokens int
+	Cost           float64 // US dollars
+}
+
+// ShadowSummary is a summary made for comparison only: a shadow candidate's
+// summary of a live document, which is never posted, or the production
+// summary it is compared with
+type ShadowSummary struct {
+	Title          string
+	URL            string
+	Candidate      string // ShadowProduction for the posted summary
+	Model          string
+	PromptVersion  string
+	Summary        string
+	Error          string
+	LatencyMS      int64
+	InputTokens    int
+	OutputTokens   int
+	ThinkingTokens int
+	Cost           float64
+	CreatedAt      time.Time
+}
+
+// ShadowProduction is the candidate name of production summaries
+const ShadowProduction = "production"
+
+// PlatformPost records that a document was published on one platform.
+// Documents go out to every enabled platform; one that failed is retried on
+// the platforms without a record only.
+type PlatformPost struct {
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	Platform string    `json:"platform"` // publisher name, e.g. "threads"
+	PostID   string    `json:"post_id"`
+	PostedAt time.Time `json:"posted_at"`
+}
+
+// Baseline records a discovery cycle that marked the listing as seen
+// without posting it
+type Baseline struct {
+	Mode      string // BASELINE mode that triggered it: auto or force
+	Seen      int    // documents marked as seen without posting
+	Posted    int    // newest documents queued anyway (BASELINE_POST_NEWEST)
+	CreatedAt time.Time
+}
+
+// DocumentFailure tracks repeated processing failures of a single document.
+// A document whose attempts reach RetryPolicy.MaxAttempts is dead-lettered:
+// it is skipped until replayed by an operator.
+type DocumentFailure struct {
+	Title         string    `json:"title"`
+	URL           string    `json:"url"`
+	Published     time.Time `json:"published"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	DeadLettered  bool      `json:"dead_lettered"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RetryPolicy controls per-document retry backoff and dead-lettering
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before retrying a document that has failed
+// attempts times: BaseDelay doubled per failure, capped at MaxDelay.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// JobStatus is the lifecycle state of a document job
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending" // waiting for a worker (possibly backing off until RunAfter)
+	JobRunning JobStatus = "running" // claimed by a worker
+	JobDone    JobStatus = "done"    // processed; pruned by retention
+	JobDead    JobStatus = "dead"    // dead-lettered; waits for a replay
+)
+
+// Job is a queued document waiting to be processed. Discovery enqueues jobs
+// and workers claim them, so processing survives restarts and a slow
+// document never delays scraping.
+type Job struct {
+	ID        int64     `json:"-"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
+	Status    JobStatus `json:"status"`
+	RunAfter  time.Time `json:"run_after"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Digest lists the documents of a catch-up digest job, which posts them
+	// all as one thread; nil for a document job. Title and URL then name the
+	// digest itself.
+	Digest []*scraper.Document `json:"digest,omitempty"`
+}
+
+// Document returns the scraped document the job was created from
+func (j *Job) Document() *scraper.Document {
+	return &scraper.Document{
+		Title:     j.Title,
+		URL:       j.URL,
+		Published: j.Published,
+	}
 }
 
 // StorageInterface defines the interface for storage implementations
@@ -22,6 +191,194 @@ type StorageInterface interface {
 	// docs, keyed by DocKey. Documents absent from the map are unprocessed.
 	FilterProcessed(ctx context.Context, docs []*scraper.Document) (map[string]bool, error)
 
+	// RecentDocuments returns up to limit posted documents published since
+	// since, newest first
+	RecentDocuments(ctx context.Context, since time.Time, limit int) ([]ProcessedDocument, error)
+
+	// CountProcessed returns the number of processed documents
+	CountProcessed(ctx context.Context) (int, error)
+
+	// ExportDocuments calls fn for every processed document, oldest first,
+	// stopping at the first error
+	ExportDocuments(ctx context.Context, fn func(ProcessedDocument) error) error
+
+	// ImportDocuments inserts docs, filling in empty post IDs, hashes,
+	// summaries, page text, prompt versions and summary usage of documents
+	// that already exist. Importing the same docs twice changes nothing.
+	// Returns the number of rows inserted or updated.
+	ImportDocuments(ctx context.Context, docs []ProcessedDocument) (int, error)
+
+	// ExportPlatformPosts calls fn for every platform post, oldest first,
+	// stopping at the first error
+	ExportPlatformPosts(ctx context.Context, fn func(PlatformPost) error) error
+
+	// ImportPlatformPosts inserts posts; a record for the same document and
+	// platform is kept. Returns the number of posts inserted.
+	ImportPlatformPosts(ctx context.Context, posts []PlatformPost) (int, error)
+
+	// ExportHostedImages calls fn for every hosted image, oldest first,
+	// stopping at the first error
+	ExportHostedImages(ctx context.Context, fn func(HostedImage) error) error
+
+	// ImportHostedImages inserts images; known images are kept. Returns the
+	// number of images inserted.
+	ImportHostedImages(ctx context.Context, images []HostedImage) (int, error)
+
+	// ExportJobs calls fn for every pending, running or dead job, oldest
+	// first, stopping at the first error. Running jobs are exported as
+	// pending, since their lease belongs to a worker of this deployment.
+	ExportJobs(ctx context.Context, fn func(Job) error) error
+
+	// ImportJobs inserts jobs; documents that already have a job keep it.
+	// Returns the number of jobs inserted.
+	ImportJobs(ctx context.Context, jobs []Job) (int, error)
+
+	// ExportFailures calls fn for every document failure record, oldest
+	// first, stopping at the first error
+	ExportFailures(ctx context.Context, fn func(DocumentFailure) error) error
+
+	// ImportFailures inserts failure records; known documents keep theirs.
+	// Returns the number of records inserted.
+	ImportFailures(ctx context.Context, failures []DocumentFailure) (int, error)
+
+	// PrunePageText clears the page text of documents published before
+	// before and returns how many were cleared. The documents themselves are
+	// kept so they are never posted again.
+	PrunePageText(ctx context.Context, before time.Time) (int, error)
+
+	// AddHostedImages records uploaded images; known images are left as they
+	// are
+	AddHostedImages(ctx context.Context, images []HostedImage) error
+
+	// ListHostedImages returns up to limit images uploaded before before,
+	// oldest first
+	ListHostedImages(ctx context.Context, before time.Time, limit int) ([]HostedImage, error)
+
+	// DeleteHostedImage forgets an image once it is deleted from Picsur
+	DeleteHostedImage(ctx context.Context, imageID string) error
+
+	// PruneJobs deletes done jobs queued before before whose document is
+	// recorded as processed, and done digest jobs, and returns how many were
+	// deleted. The processed documents keep them from being queued again.
+	PruneJobs(ctx context.Context, before time.Time) (int, error)
+
+	// GetSummary returns the cached summary of the PDF with the given hash
+	// by model under promptVersion, if any
+	GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error)
+
+	// PutSummary caches a summary; an existing entry for the same key is kept
+	// so retries see the same summary
+	PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error
+
+	// PruneSummaries deletes summaries cached before before and returns how
+	// many were deleted. A pruned summary is generated again if its PDF is
+	// ever summarized again.
+	PruneSummaries(ctx context.Context, before time.Time) (int, error)
+
+	// AddSummaryReview holds a rejected summary for review, replacing an
+	// earlier one for the same document
+	AddSummaryReview(ctx context.Context, review SummaryReview) error
+
+	// ListSummaryReviews returns the summaries held for review, most recent
+	// first
+	ListSummaryReviews(ctx context.Context) ([]SummaryReview, error)
+
+	// ResolveSummaryReview removes a held summary. Returns false if there is
+	// none for the document.
+	ResolveSummaryReview(ctx context.Context, title, url string) (bool, error)
+
+	// AddSummaryUsage adds usage to the totals of its day, document and
+	// model
+	AddSummaryUsage(ctx context.Context, usage SummaryUsage) error
+
+	// DailySummaryUsage returns the usage per day and model from the day
+	// since (inclusive) on, most recent day first
+	DailySummaryUsage(ctx context.Context, since string) ([]SummaryUsage, error)
+
+	// AddShadowSummaries records the summaries of one document
+	AddShadowSummaries(ctx context.Context, summaries []ShadowSummary) error
+
+	// ListShadowSummaries returns the summaries of the limit documents
+	// summarized most recently, newest document first and each document's
+	// summaries in the order they were added
+	ListShadowSummaries(ctx context.Context, limit int) ([]ShadowSummary, error)
+
+	// AddBaseline records a baseline
+	AddBaseline(ctx context.Context, b Baseline) error
+
+	// ApplyBaseline marks docs as processed without posting them and
+	// records b, all or nothing, so a failure midway leaves the database
+	// as empty as before
+	ApplyBaseline(ctx context.Context, b Baseline, docs []*scraper.Document) error
+
+	// LastBaseline returns the latest baseline of the given mode, or nil if
+	// there was none
+	LastBaseline(ctx context.Context, mode string) (*Baseline, error)
+
+	// AddPlatformPost records a document's post on a platform; an existing
+	// record for the same document and platform is kept
+	AddPlatformPost(ctx context.Context, post PlatformPost) error
+
+	// ListPlatformPosts returns the platforms a document was published on,
+	// in the order it was
+	ListPlatformPosts(ctx context.Context, title, url string) ([]PlatformPost, error)
+
+	// RecordFailure increments the failure count of a document, schedules its
+	// next attempt according to policy and dead-letters it once
+	// policy.MaxAttempts is reached. Returns the updated record.
+	RecordFailure(ctx context.Context, doc ProcessedDocument, errMsg string, policy RetryPolicy) (DocumentFailure, error)
+
+	// ClearFailure removes the failure record of a document (after success)
+	ClearFailure(ctx context.Context, title, url string) error
+
+	// ListDeadLetters returns all dead-lettered documents, most recent first
+	ListDeadLetters(ctx context.Context) ([]DocumentFailure, error)
+
+	// ReplayDeadLetter resets a dead-lettered document and re-queues its job
+	// so it is retried right away. Returns false if no such dead letter exists.
+	ReplayDeadLetter(ctx context.Context, title, url string) (bool, error)
+
+	// EnqueueJobs queues a pending job for each document not already queued
+	// (in any status) and returns how many were added
+	EnqueueJobs(ctx context.Context, docs []*scraper.Document) (int, error)
+
+	// EnqueueDigest queues a pending digest job named by digest for docs and
+	// marks docs as processed in the same transaction, so the documents are
+	// either covered by the digest or left untouched. Returns false if the
+	// digest was already queued.
+	EnqueueDigest(ctx context.Context, digest *scraper.Document, docs []*scraper.Document) (bool, error)
+
+	// FilterQueued returns the set of documents among docs that already have
+	// a job (in any status), keyed by DocKey
+	FilterQueued(ctx context.Context, docs []*scraper.Document) (map[string]bool, error)
+
+	// ClaimJob hands the oldest due job to workerID, or returns nil when none
+	// is due. Running jobs whose lease has expired (their worker died) are
+	// claimable again.
+	ClaimJob(ctx context.Context, workerID string, lease time.Duration) (*Job, error)
+
+	// RenewJob extends the lease of a running job held by workerID. Returns
+	// false if the job is no longer held by workerID (its lease expired and
+	// another worker reclaimed it).
+	RenewJob(ctx context.Context, id int64, workerID string) (bool, error)
+
+	// CompleteJob marks a job done
+	CompleteJob(ctx context.Context, id int64) error
+
+	// FailJob returns a job to the queue to run after runAfter, or parks it
+	// as dead when dead is true
+	FailJob(ctx context.Context, id int64, runAfter time.Time, dead bool) error
+
+	// CountJobs returns the number of jobs in each status
+	CountJobs(ctx context.Context) (map[JobStatus]int, error)
+
+	// TryAcquireLeaderLock attempts to take the cluster-wide leader lock, or
+	// confirms it is still held. Returns false once the lock has been lost.
+	TryAcquireLeaderLock(ctx context.Context) (bool, error)
+
+	// ReleaseLeaderLock gives up the leader lock if this instance holds it
+	ReleaseLeaderLock(ctx context.Context) error
+
 	// CheckConnection checks if the database connection is still active
 	CheckConnection(ctx context.Context) error
 
@@ -0,0 +1,796 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 6,
+		BaseDelay:   time.Minute,
+		MaxDelay:    10 * time.Minute,
+	}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute}, // capped
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// Behaviour shared by every StorageInterface implementation
+
+// testProcessedDocuments checks title/url dedupe and FilterProcessed
+func testProcessedDocuments(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+
+	doc := ProcessedDocument{Title: "Doc 1 - Decision", URL: "https://example.com/1.pdf", Timestamp: time.Now()}
+	if err := store.AddProcessedDocument(ctx, doc); err != nil {
+		t.Fatalf("AddProcessedDocument: %v", err)
+	}
+	// Re-adding is a no-op, not an error
+	if err := store.AddProcessedDocument(ctx, doc); err != nil {
+		t.Fatalf("AddProcessedDocument (duplicate): %v", err)
+	}
+
+	processed, err := store.FilterProcessed(ctx, []*scraper.Document{
+		{Title: doc.Title, URL: doc.URL},
+		{Title: doc.Title, URL: "https://example.com/other.pdf"},
+	})
+	if err != nil {
+		t.Fatalf("FilterProcessed: %v", err)
+	}
+	if !processed[DocKey(doc.Title, doc.URL)] || len(processed) != 1 {
+		t.Errorf("FilterProcessed = %v, want only the added document", processed)
+	}
+
+	if n, err := store.CountProcessed(ctx); err != nil || n != 1 {
+		t.Errorf("CountProcessed = %d, %v; want 1", n, err)
+	}
+}
+
+// testJobQueue checks enqueue dedupe, claim order, backoff and counts
+func testJobQueue(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2026, 7, 5, 14, 0, 0, 0, time.UTC)
+
+	docs := []*scraper.Document{
+		{Title: "Doc 2", URL: "u2", Published: base.Add(time.Minute)},
+		{Title: "Doc 1", URL: "u1", Published: base},
+	}
+	if n, err := store.EnqueueJobs(ctx, docs); err != nil || n != 2 {
+		t.Fatalf("EnqueueJobs = %d, %v; want 2", n, err)
+	}
+	if n, err := store.EnqueueJobs(ctx, docs); err != nil || n != 0 {
+		t.Fatalf("EnqueueJobs (again) = %d, %v; want 0", n, err)
+	}
+	queued, err := store.FilterQueued(ctx, append(docs, &scraper.Document{Title: "Doc 9", URL: "u9"}))
+	if err != nil || len(queued) != 2 || queued[DocKey("Doc 9", "u9")] {
+		t.Fatalf("FilterQueued = %v, %v; want the two enqueued documents", queued, err)
+	}
+
+	// Oldest publication is claimed first
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil || job.Title != "Doc 1" {
+		t.Fatalf("ClaimJob = %+v, %v; want Doc 1", job, err)
+	}
+	if !job.Published.Equal(base) {
+		t.Errorf("Published = %v, want %v", job.Published, base)
+	}
+
+	// Only the worker holding the job can renew its lease
+	if ok, err := store.RenewJob(ctx, job.ID, "w1"); err != nil || !ok {
+		t.Errorf("RenewJob = %v, %v; want renewed", ok, err)
+	}
+	if ok, err := store.RenewJob(ctx, job.ID, "w2"); err != nil || ok {
+		t.Errorf("RenewJob by another worker = %v, %v; want false", ok, err)
+	}
+
+	// A failure with a future run_after keeps the job out of reach
+	if err := store.FailJob(ctx, job.ID, time.Now().Add(time.Hour), false); err != nil {
+		t.Fatalf("FailJob: %v", err)
+	}
+	next, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || next == nil || next.Title != "Doc 2" {
+		t.Fatalf("ClaimJob = %+v, %v; want Doc 2", next, err)
+	}
+	if ok, err := store.RenewJob(ctx, job.ID, "w1"); err != nil || ok {
+		t.Errorf("RenewJob of a released job = %v, %v; want false", ok, err)
+	}
+	if err := store.CompleteJob(ctx, next.ID); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	if none, err := store.ClaimJob(ctx, "w1", time.Hour); err != nil || none != nil {
+		t.Fatalf("ClaimJob = %+v, %v; want nothing due", none, err)
+	}
+
+	counts, err := store.CountJobs(ctx)
+	if err != nil {
+		t.Fatalf("CountJobs: %v", err)
+	}
+	if counts[JobPending] != 1 || counts[JobDone] != 1 {
+		t.Errorf("CountJobs = %v, want 1 pending and 1 done", counts)
+	}
+}
+
+// testFailureCounting checks that failures are counted per document,
+// scheduled with the policy's backoff, dead-lettered at MaxAttempts and
+// forgotten by ClearFailure
+func testFailureCounting(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	doc := ProcessedDocument{Title: "Doc 4", URL: "u4", Timestamp: time.Now()}
+	other := ProcessedDocument{Title: "Doc 4", URL: "u4-corrected", Timestamp: time.Now()}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		f, err := store.RecordFailure(ctx, doc, "boom", policy)
+		if err != nil {
+			t.Fatalf("RecordFailure #%d: %v", attempt, err)
+		}
+		if f.Attempts != attempt || f.DeadLettered {
+			t.Errorf("RecordFailure #%d = %+v, want attempt %d not dead-lettered", attempt, f, attempt)
+		}
+		if want := before.Add(policy.Backoff(attempt)); f.NextAttemptAt.Before(want.Add(-time.Second)) {
+			t.Errorf("NextAttemptAt #%d = %v, want about %v", attempt, f.NextAttemptAt, want)
+		}
+	}
+
+	// A document with the same title and another URL is counted apart
+	if f, err := store.RecordFailure(ctx, other, "boom", policy); err != nil || f.Attempts != 1 {
+		t.Fatalf("RecordFailure (other URL) = %+v, %v; want attempt 1", f, err)
+	}
+	if dead, err := store.ListDeadLetters(ctx); err != nil || len(dead) != 0 {
+		t.Fatalf("ListDeadLetters = %+v, %v; want none before MaxAttempts", dead, err)
+	}
+
+	f, err := store.RecordFailure(ctx, doc, "still failing", policy)
+	if err != nil || f.Attempts != 3 || !f.DeadLettered {
+		t.Fatalf("RecordFailure #3 = %+v, %v; want dead-lettered", f, err)
+	}
+	dead, err := store.ListDeadLetters(ctx)
+	if err != nil || len(dead) != 1 || dead[0].URL != doc.URL || dead[0].Attempts != 3 {
+		t.Fatalf("ListDeadLetters = %+v, %v; want only %s", dead, err, doc.URL)
+	}
+
+	// A cleared document starts counting again
+	if err := store.ClearFailure(ctx, other.Title, other.URL); err != nil {
+		t.Fatalf("ClearFailure: %v", err)
+	}
+	if f, err := store.RecordFailure(ctx, other, "boom", policy); err != nil || f.Attempts != 1 {
+		t.Errorf("RecordFailure after ClearFailure = %+v, %v; want attempt 1", f, err)
+	}
+
+	// So does a replayed dead letter
+	if ok, err := store.ReplayDeadLetter(ctx, doc.Title, doc.URL); err != nil || !ok {
+		t.Fatalf("ReplayDeadLetter = %v, %v", ok, err)
+	}
+	if f, err := store.RecordFailure(ctx, doc, "boom", policy); err != nil || f.Attempts != 1 || f.DeadLettered {
+		t.Errorf("RecordFailure after replay = %+v, %v; want attempt 1", f, err)
+	}
+}
+
+// testDeadLetterReplay checks dead-lettering and replay re-queueing
+func testDeadLetterReplay(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	doc := ProcessedDocument{Title: "Doc 3", URL: "u3", Timestamp: time.Now()}
+
+	if f, err := store.RecordFailure(ctx, doc, "boom", policy); err != nil || f.DeadLettered || f.Attempts != 1 {
+		t.Fatalf("RecordFailure #1 = %+v, %v", f, err)
+	}
+	f, err := store.RecordFailure(ctx, doc, "boom again", policy)
+	if err != nil || !f.DeadLettered || f.Attempts != 2 {
+		t.Fatalf("RecordFailure #2 = %+v, %v; want dead-lettered", f, err)
+	}
+
+	dead, err := store.ListDeadLetters(ctx)
+	if err != nil || len(dead) != 1 || dead[0].LastError != "boom again" {
+		t.Fatalf("ListDeadLetters = %+v, %v", dead, err)
+	}
+
+	if ok, err := store.ReplayDeadLetter(ctx, doc.Title, doc.URL); err != nil || !ok {
+		t.Fatalf("ReplayDeadLetter = %v, %v", ok, err)
+	}
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil || job.Title != doc.Title {
+		t.Fatalf("replayed job not claimable: %+v, %v", job, err)
+	}
+	if ok, err := store.ReplayDeadLetter(ctx, doc.Title, doc.URL); err != nil || ok {
+		t.Errorf("second ReplayDeadLetter = %v, %v; want false", ok, err)
+	}
+}
+
+// testExportImport checks that an export imports into another store
+// idempotently, filling in fields the target lacks
+func testExportImport(t *testing.T, source, target StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+
+	posted := ProcessedDocument{Title: "Doc 2", URL: "u2", Timestamp: base.Add(time.Hour),
+		PostID: "1789", PDFHash: "ab12", Summary: "Car 4 summoned.", PromptVersion: "summons@1",
+		SummaryInput: "text", InputTokens: 1450, OutputTokens: 210, RelatedDocuments: []string{"u0", "u1"}}
+	seen := ProcessedDocument{Title: "Doc 1", URL: "u1", Timestamp: base}
+	for _, doc := range []ProcessedDocument{posted, seen} {
+		if err := source.AddProcessedDocument(ctx, doc); err != nil {
+			t.Fatalf("AddProcessedDocument: %v", err)
+		}
+	}
+
+	var exported []ProcessedDocument
+	if err := source.ExportDocuments(ctx, func(doc ProcessedDocument) error {
+		exported = append(exported, doc)
+		return nil
+	}); err != nil {
+		t.Fatalf("ExportDocuments: %v", err)
+	}
+	if len(exported) != 2 || exported[0].Title != "Doc 1" || exported[1].Summary != posted.Summary {
+		t.Fatalf("ExportDocuments = %+v, want both documents oldest first", exported)
+	}
+
+	// The target already knows Doc 2, but without its post
+	if err := target.AddProcessedDocument(ctx, ProcessedDocument{Title: posted.Title, URL: posted.URL, Timestamp: posted.Timestamp}); err != nil {
+		t.Fatalf("AddProcessedDocument: %v", err)
+	}
+	if n, err := target.ImportDocuments(ctx, exported); err != nil || n != 2 {
+		t.Fatalf("ImportDocuments = %d, %v; want 2 changes", n, err)
+	}
+	if n, err := target.ImportDocuments(ctx, exported); err != nil || n != 0 {
+		t.Fatalf("ImportDocuments (again) = %d, %v; want 0 changes", n, err)
+	}
+
+	var imported []ProcessedDocument
+	if err := target.ExportDocuments(ctx, func(doc ProcessedDocument) error {
+		imported = append(imported, doc)
+		return nil
+	}); err != nil {
+		t.Fatalf("ExportDocuments: %v", err)
+	}
+	if len(imported) != 2 || imported[1].PostID != posted.PostID || imported[1].PromptVersion != posted.PromptVersion ||
+		imported[1].SummaryInput != "text" || imported[1].InputTokens != 1450 || imported[1].OutputTokens != 210 ||
+		len(imported[1].RelatedDocuments) != 2 || imported[1].RelatedDocuments[1] != "u1" ||
+		imported[0].RelatedDocuments != nil || !imported[0].Timestamp.Equal(base) {
+		t.Errorf("target after import = %+v", imported)
+	}
+}
+
+// testExportImportState checks that platform posts, hosted images, failure
+// records and unfinished jobs survive an export and a repeated import
+func testExportImportState(t *testing.T, source, target StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+
+	post := PlatformPost{Title: "Doc 2", URL: "u2", Platform: "threads", PostID: "1789", PostedAt: base}
+	if err := source.AddPlatformPost(ctx, post); err != nil {
+		t.Fatalf("AddPlatformPost: %v", err)
+	}
+	image := HostedImage{ImageID: "img1", URL: "https://picsur.example/i/img1", DeleteKey: "key1",
+		Title: "Doc 2", DocURL: "u2", UploadedAt: base}
+	if err := source.AddHostedImages(ctx, []HostedImage{image}); err != nil {
+		t.Fatalf("AddHostedImages: %v", err)
+	}
+
+	// Doc 3 is dead-lettered, Doc 4 running, Doc 5 done and Doc 6 pending
+	docs := []*scraper.Document{
+		{Title: "Doc 3", URL: "u3", Published: base},
+		{Title: "Doc 4", URL: "u4", Published: base.Add(time.Minute)},
+		{Title: "Doc 5", URL: "u5", Published: base.Add(2 * time.Minute)},
+	}
+	if _, err := source.EnqueueJobs(ctx, docs); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+	policy := RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute}
+	for i, status := range []JobStatus{JobDead, JobRunning, JobDone} {
+		job, err := source.ClaimJob(ctx, "w1", time.Hour)
+		if err != nil || job == nil || job.Title != docs[i].Title {
+			t.Fatalf("ClaimJob = %+v, %v; want %s", job, err, docs[i].Title)
+		}
+		switch status {
+		case JobDead:
+			if err := source.FailJob(ctx, job.ID, time.Now(), true); err != nil {
+				t.Fatalf("FailJob: %v", err)
+			}
+			if _, err := source.RecordFailure(ctx, ProcessedDocument{Title: job.Title, URL: job.URL, Timestamp: job.Published},
+				"download failed", policy); err != nil {
+				t.Fatalf("RecordFailure: %v", err)
+			}
+		case JobDone:
+			if err := source.CompleteJob(ctx, job.ID); err != nil {
+				t.Fatalf("CompleteJob: %v", err)
+			}
+		}
+	}
+	if _, err := source.EnqueueJobs(ctx, []*scraper.Document{{Title: "Doc 6", URL: "u6", Published: base.Add(3 * time.Minute)}}); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+
+	var posts []PlatformPost
+	var images []HostedImage
+	var failures []DocumentFailure
+	var jobs []Job
+	if err := source.ExportPlatformPosts(ctx, func(p PlatformPost) error { posts = append(posts, p); return nil }); err != nil {
+		t.Fatalf("ExportPlatformPosts: %v", err)
+	}
+	if err := source.ExportHostedImages(ctx, func(img HostedImage) error { images = append(images, img); return nil }); err != nil {
+		t.Fatalf("ExportHostedImages: %v", err)
+	}
+	if err := source.ExportFailures(ctx, func(f DocumentFailure) error { failures = append(failures, f); return nil }); err != nil {
+		t.Fatalf("ExportFailures: %v", err)
+	}
+	if err := source.ExportJobs(ctx, func(job Job) error { jobs = append(jobs, job); return nil }); err != nil {
+		t.Fatalf("ExportJobs: %v", err)
+	}
+	if len(posts) != 1 || posts[0].PostID != post.PostID || len(images) != 1 || images[0].DeleteKey != image.DeleteKey {
+		t.Fatalf("exported posts %+v and images %+v", posts, images)
+	}
+	if len(failures) != 1 || !failures[0].DeadLettered {
+		t.Fatalf("exported failures = %+v, want the dead letter", failures)
+	}
+	statuses := map[string]JobStatus{}
+	for _, job := range jobs {
+		statuses[job.Title] = job.Status
+	}
+	if len(jobs) != 3 || statuses["Doc 3"] != JobDead || statuses["Doc 4"] != JobPending || statuses["Doc 6"] != JobPending {
+		t.Fatalf("exported jobs = %+v, want Doc 3 dead and Doc 4 and Doc 6 pending", jobs)
+	}
+
+	// A second import changes nothing
+	for _, want := range []int{1, 0} {
+		if n, err := target.ImportPlatformPosts(ctx, posts); err != nil || n != want {
+			t.Fatalf("ImportPlatformPosts = %d, %v; want %d", n, err, want)
+		}
+		if n, err := target.ImportHostedImages(ctx, images); err != nil || n != want {
+			t.Fatalf("ImportHostedImages = %d, %v; want %d", n, err, want)
+		}
+		if n, err := target.ImportFailures(ctx, failures); err != nil || n != want {
+			t.Fatalf("ImportFailures = %d, %v; want %d", n, err, want)
+		}
+		if n, err := target.ImportJobs(ctx, jobs); err != nil || n != 3*want {
+			t.Fatalf("ImportJobs = %d, %v; want %d", n, err, 3*want)
+		}
+	}
+
+	if got, err := target.ListPlatformPosts(ctx, post.Title, post.URL); err != nil || len(got) != 1 || !got[0].PostedAt.Equal(base) {
+		t.Errorf("ListPlatformPosts after import = %+v, %v", got, err)
+	}
+	if got, err := target.ListHostedImages(ctx, base.Add(time.Hour), 10); err != nil || len(got) != 1 || got[0].DeleteKey != "key1" {
+		t.Errorf("ListHostedImages after import = %+v, %v", got, err)
+	}
+	if dead, err := target.ListDeadLetters(ctx); err != nil || len(dead) != 1 || dead[0].Title != "Doc 3" {
+		t.Errorf("ListDeadLetters after import = %+v, %v", dead, err)
+	}
+	counts, err := target.CountJobs(ctx)
+	if err != nil || counts[JobPending] != 2 || counts[JobDead] != 1 || counts[JobRunning] != 0 {
+		t.Errorf("CountJobs after import = %v, %v; want 2 pending and 1 dead", counts, err)
+	}
+}
+
+// testRetention checks that pruning clears page text without forgetting the
+// document and that hosted images are listed oldest first until deleted
+func testRetention(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 16, 5, 0, 0, 0, time.UTC)
+
+	old := ProcessedDocument{Title: "Doc 1", URL: "u1", Timestamp: base, PageText: "Stewards decision"}
+	recent := ProcessedDocument{Title: "Doc 9", URL: "u9", Timestamp: base.AddDate(1, 0, 0), PageText: "Summons"}
+	for _, doc := range []ProcessedDocument{old, recent} {
+		if err := store.AddProcessedDocument(ctx, doc); err != nil {
+			t.Fatalf("AddProcessedDocument: %v", err)
+		}
+	}
+
+	cutoff := base.AddDate(0, 6, 0)
+	if n, err := store.PrunePageText(ctx, cutoff); err != nil || n != 1 {
+		t.Fatalf("PrunePageText = %d, %v; want 1", n, err)
+	}
+	if n, err := store.PrunePageText(ctx, cutoff); err != nil || n != 0 {
+		t.Fatalf("PrunePageText (again) = %d, %v; want 0", n, err)
+	}
+
+	var texts []string
+	if err := store.ExportDocuments(ctx, func(doc ProcessedDocument) error {
+		texts = append(texts, doc.PageText)
+		return nil
+	}); err != nil {
+		t.Fatalf("ExportDocuments: %v", err)
+	}
+	if len(texts) != 2 || texts[0] != "" || texts[1] != recent.PageText {
+		t.Errorf("page text after prune = %q, want the old document kept without text", texts)
+	}
+
+	images := []HostedImage{
+		{ImageID: "b", URL: "https://img/b.png", DeleteKey: "kb", Title: "Doc 1", DocURL: "u1", UploadedAt: base.Add(time.Minute)},
+		{ImageID: "a", URL: "https://img/a.png", DeleteKey: "ka", Title: "Doc 1", DocURL: "u1", UploadedAt: base},
+		{ImageID: "c", URL: "https://img/c.png", DeleteKey: "kc", Title: "Doc 9", DocURL: "u9", UploadedAt: recent.Timestamp},
+	}
+	if err := store.AddHostedImages(ctx, images); err != nil {
+		t.Fatalf("AddHostedImages: %v", err)
+	}
+	if err := store.AddHostedImages(ctx, images[:1]); err != nil {
+		t.Fatalf("AddHostedImages (again): %v", err)
+	}
+
+	expired, err := store.ListHostedImages(ctx, cutoff, 10)
+	if err != nil || len(expired) != 2 || expired[0].ImageID != "a" || expired[1].DeleteKey != "kb" {
+		t.Fatalf("ListHostedImages = %+v, %v; want a then b", expired, err)
+	}
+	if limited, _ := store.ListHostedImages(ctx, cutoff, 1); len(limited) != 1 {
+		t.Errorf("ListHostedImages with limit 1 returned %d images", len(limited))
+	}
+
+	if err := store.DeleteHostedImage(ctx, "a"); err != nil {
+		t.Fatalf("DeleteHostedImage: %v", err)
+	}
+	if expired, _ := store.ListHostedImages(ctx, cutoff, 10); len(expired) != 1 || expired[0].ImageID != "b" {
+		t.Errorf("ListHostedImages after delete = %+v, want only b", expired)
+	}
+
+	// Done jobs go once their document is recorded; an unrecorded one stays
+	// so the document is not queued again
+	jobs := []*scraper.Document{
+		{Title: old.Title, URL: old.URL, Published: old.Timestamp},
+		{Title: "Doc 5", URL: "u5", Published: old.Timestamp.Add(time.Minute)},
+	}
+	if _, err := store.EnqueueJobs(ctx, jobs); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+	for range jobs {
+		job, err := store.ClaimJob(ctx, "w1", time.Hour)
+		if err != nil || job == nil {
+			t.Fatalf("ClaimJob = %+v, %v", job, err)
+		}
+		if err := store.CompleteJob(ctx, job.ID); err != nil {
+			t.Fatalf("CompleteJob: %v", err)
+		}
+	}
+	if n, err := store.PruneJobs(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PruneJobs of recent jobs = %d, %v; want 0", n, err)
+	}
+	if n, err := store.PruneJobs(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PruneJobs = %d, %v; want 1", n, err)
+	}
+	queued, err := store.FilterQueued(ctx, jobs)
+	if err != nil || len(queued) != 1 || !queued[DocKey("Doc 5", "u5")] {
+		t.Errorf("FilterQueued after prune = %v, %v; want only the unrecorded document", queued, err)
+	}
+}
+
+// testSummaryCache checks that summaries are found only under their exact
+// key and that the first cached summary wins
+func testSummaryCache(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+
+	if _, ok, err := store.GetSummary(ctx, "ab12", "vertex/gemini-2.5-flash", "1"); err != nil || ok {
+		t.Fatalf("GetSummary on empty cache = %t, %v", ok, err)
+	}
+	if err := store.PutSummary(ctx, "ab12", "vertex/gemini-2.5-flash", "1", "First"); err != nil {
+		t.Fatalf("PutSummary: %v", err)
+	}
+	if err := store.PutSummary(ctx, "ab12", "vertex/gemini-2.5-flash", "1", "Second"); err != nil {
+		t.Fatalf("PutSummary (again): %v", err)
+	}
+
+	tests := []struct {
+		hash, model, version string
+		want                 string
+		found                bool
+	}{
+		{"ab12", "vertex/gemini-2.5-flash", "1", "First", true},
+		{"ab12", "vertex/gemini-2.5-flash", "2", "", false},
+		{"ab12", "local/llama3.1", "1", "", false},
+		{"cd34", "vertex/gemini-2.5-flash", "1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := store.GetSummary(ctx, tt.hash, tt.model, tt.version)
+		if err != nil || ok != tt.found || got != tt.want {
+			t.Errorf("GetSummary(%s, %s, %s) = %q, %t, %v; want %q, %t", tt.hash, tt.model, tt.version, got, ok, err, tt.want, tt.found)
+		}
+	}
+
+	// Retention removes entries by the time they were cached
+	if n, err := store.PruneSummaries(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PruneSummaries of recent entries = %d, %v; want 0", n, err)
+	}
+	if n, err := store.PruneSummaries(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("PruneSummaries = %d, %v; want 1", n, err)
+	}
+	if _, ok, err := store.GetSummary(ctx, "ab12", "vertex/gemini-2.5-flash", "1"); err != nil || ok {
+		t.Errorf("GetSummary after prune = %t, %v; want a miss", ok, err)
+	}
+}
+
+// testSummaryReviews checks that a held summary replaces an earlier one for
+// the same document and is gone once resolved
+func testSummaryReviews(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	reviews := []SummaryReview{
+		{Title: "Doc 1", URL: "https://fia.com/1.pdf", PostID: "p1", Model: "vertex/a", PromptVersion: "default@2", Summary: "First", Reason: "too short", CreatedAt: base},
+		{Title: "Doc 2", URL: "https://fia.com/2.pdf", Model: "vertex/a", PromptVersion: "decision@2", Summary: "Other", Reason: "unknown driver", CreatedAt: base.Add(time.Hour)},
+		{Title: "Doc 1", URL: "https://fia.com/1.pdf", PostID: "p1", Model: "local/b", PromptVersion: "default@2", Summary: "Second", Reason: "banned token", CreatedAt: base.Add(2 * time.Hour)},
+	}
+	for _, r := range reviews {
+		if err := store.AddSummaryReview(ctx, r); err != nil {
+			t.Fatalf("AddSummaryReview: %v", err)
+		}
+	}
+
+	got, err := store.ListSummaryReviews(ctx)
+	if err != nil {
+		t.Fatalf("ListSummaryReviews: %v", err)
+	}
+	if len(got) != 2 || got[0].Summary != "Second" || got[0].Model != "local/b" || got[1].Reason != "unknown driver" ||
+		!got[0].CreatedAt.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("ListSummaryReviews = %+v, want Doc 1 (replaced) then Doc 2", got)
+	}
+
+	if ok, err := store.ResolveSummaryReview(ctx, "Doc 1", "https://fia.com/1.pdf"); err != nil || !ok {
+		t.Fatalf("ResolveSummaryReview = %t, %v", ok, err)
+	}
+	if ok, err := store.ResolveSummaryReview(ctx, "Doc 1", "https://fia.com/1.pdf"); err != nil || ok {
+		t.Errorf("ResolveSummaryReview (again) = %t, %v, want not found", ok, err)
+	}
+	if got, _ := store.ListSummaryReviews(ctx); len(got) != 1 || got[0].Title != "Doc 2" {
+		t.Errorf("ListSummaryReviews after resolve = %+v", got)
+	}
+}
+
+// testSummaryUsage checks that usage adds up per document and model and is
+// totalled per day and model
+func testSummaryUsage(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+
+	records := []SummaryUsage{
+		{Day: "2025-03-15", Title: "Doc 1", URL: "https://fia.com/1.pdf", Model: "vertex/a", Calls: 1, InputTokens: 1000, OutputTokens: 100, Cost: 0.5},
+		{Day: "2025-03-16", Title: "Doc 2", URL: "https://fia.com/2.pdf", Model: "vertex/a", Calls: 1, InputTokens: 2000, OutputTokens: 200, ThinkingTokens: 50, Cost: 1},
+		{Day: "2025-03-16", Title: "Doc 2", URL: "https://fia.com/2.pdf", Model: "vertex/a", Calls: 2, InputTokens: 2000, OutputTokens: 100, Cost: 0.75},
+		{Day: "2025-03-16", Title: "Doc 3", URL: "https://fia.com/3.pdf", Model: "vertex/a", Calls: 1, InputTokens: 500, OutputTokens: 50, Cost: 0.25},
+		{Day: "2025-03-16", Title: "Doc 3", URL: "https://fia.com/3.pdf", Model: "local/b", Calls: 1, InputTokens: 500, OutputTokens: 60},
+	}
+	for _, u := range records {
+		if err := store.AddSummaryUsage(ctx, u); err != nil {
+			t.Fatalf("AddSummaryUsage: %v", err)
+		}
+	}
+
+	got, err := store.DailySummaryUsage(ctx, "2025-03-16")
+	if err != nil {
+		t.Fatalf("DailySummaryUsage: %v", err)
+	}
+	want := []SummaryUsage{
+		{Day: "2025-03-16", Model: "local/b", Calls: 1, InputTokens: 500, OutputTokens: 60},
+		{Day: "2025-03-16", Model: "vertex/a", Calls: 4, InputTokens: 4500, OutputTokens: 350, ThinkingTokens: 50, Cost: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DailySummaryUsage = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("DailySummaryUsage[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got, _ := store.DailySummaryUsage(ctx, "2025-03-01"); len(got) != 3 || got[2].Day != "2025-03-15" {
+		t.Errorf("DailySummaryUsage since 2025-03-01 = %+v, want 3 totals, oldest day last", got)
+	}
+}
+
+// testShadowSummaries checks that summaries are listed by document, most
+// recently summarized first, in the order they were added
+func testShadowSummaries(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 16, 14, 0, 0, 0, time.UTC)
+
+	batches := [][]ShadowSummary{
+		{
+			{Title: "Doc 1", URL: "u1", Candidate: ShadowProduction, Model: "vertex/a", PromptVersion: "default@2", Summary: "One.", LatencyMS: 1200, InputTokens: 900, OutputTokens: 80, Cost: 0.001, CreatedAt: base},
+			{Title: "Doc 1", URL: "u1", Candidate: "vertex/b", Model: "vertex/b", PromptVersion: "default@2", Summary: "Uno.", LatencyMS: 800, InputTokens: 900, OutputTokens: 70, ThinkingTokens: 20, CreatedAt: base},
+		},
+		{
+			{Title: "Doc 2", URL: "u2", Candidate: ShadowProduction, Model: "vertex/a", Summary: "Two.", CreatedAt: base.Add(time.Hour)},
+			{Title: "Doc 2", URL: "u2", Candidate: "vertex/b", Error: "quota exhausted", CreatedAt: base.Add(time.Hour)},
+		},
+		{
+			{Title: "Doc 3", URL: "u3", Candidate: ShadowProduction, Summary: "Three.", CreatedAt: base.Add(2 * time.Hour)},
+		},
+	}
+	for _, batch := range batches {
+		if err := store.AddShadowSummaries(ctx, batch); err != nil {
+			t.Fatalf("AddShadowSummaries: %v", err)
+		}
+	}
+
+	got, err := store.ListShadowSummaries(ctx, 2)
+	if err != nil {
+		t.Fatalf("ListShadowSummaries: %v", err)
+	}
+	want := append(slices.Clone(batches[2]), batches[1]...)
+	if len(got) != len(want) {
+		t.Fatalf("ListShadowSummaries = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].CreatedAt.Equal(want[i].CreatedAt) {
+			t.Errorf("ListShadowSummaries[%d].CreatedAt = %v, want %v", i, got[i].CreatedAt, want[i].CreatedAt)
+		}
+		got[i].CreatedAt = want[i].CreatedAt
+		if got[i] != want[i] {
+			t.Errorf("ListShadowSummaries[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got, _ := store.ListShadowSummaries(ctx, 10); len(got) != 5 || got[3].Title != "Doc 1" || got[4].Candidate != "vertex/b" {
+		t.Errorf("ListShadowSummaries(10) = %+v, want all 5 with Doc 1 last", got)
+	}
+}
+
+// testRecentDocuments checks that only posted documents published in the
+// window are returned, newest first and up to the limit
+func testRecentDocuments(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 16, 5, 0, 0, 0, time.UTC)
+
+	docs := []ProcessedDocument{
+		{Title: "Doc 1", URL: "u1", Timestamp: base.AddDate(0, 0, -10), PostID: "p1"},
+		{Title: "Doc 2", URL: "u2", Timestamp: base, PostID: "p2", PageText: "Summons"},
+		{Title: "Doc 3", URL: "u3", Timestamp: base.Add(time.Hour)},
+		{Title: "Doc 4", URL: "u4", Timestamp: base.Add(2 * time.Hour), PostID: "p4", Summary: "Decision"},
+		{Title: "Doc 5", URL: "u5", Timestamp: base.Add(3 * time.Hour), PostID: "p5"},
+	}
+	for _, doc := range docs {
+		if err := store.AddProcessedDocument(ctx, doc); err != nil {
+			t.Fatalf("AddProcessedDocument: %v", err)
+		}
+	}
+
+	got, err := store.RecentDocuments(ctx, base, 10)
+	if err != nil {
+		t.Fatalf("RecentDocuments: %v", err)
+	}
+	if len(got) != 3 || got[0].Title != "Doc 5" || got[1].Summary != "Decision" || got[2].PageText != "Summons" {
+		t.Errorf("RecentDocuments = %+v, want Doc 5, 4 and 2", got)
+	}
+	if got, _ := store.RecentDocuments(ctx, base, 2); len(got) != 2 || got[1].Title != "Doc 4" {
+		t.Errorf("RecentDocuments with limit 2 = %+v, want Doc 5 and 4", got)
+	}
+}
+
+// testPlatformPosts checks that posts are listed per document in posting
+// order and that a second post on a platform keeps the first
+func testPlatformPosts(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 16, 14, 0, 0, 0, time.UTC)
+
+	posts := []PlatformPost{
+		{Title: "Doc 1", URL: "u1", Platform: "threads", PostID: "t1", PostedAt: base},
+		{Title: "Doc 2", URL: "u2", Platform: "threads", PostID: "t2", PostedAt: base},
+		{Title: "Doc 1", URL: "u1", Platform: "bluesky", PostID: "b1", PostedAt: base.Add(time.Minute)},
+		{Title: "Doc 1", URL: "u1", Platform: "threads", PostID: "t3", PostedAt: base.Add(time.Hour)},
+	}
+	for _, p := range posts {
+		if err := store.AddPlatformPost(ctx, p); err != nil {
+			t.Fatalf("AddPlatformPost: %v", err)
+		}
+	}
+
+	got, err := store.ListPlatformPosts(ctx, "Doc 1", "u1")
+	if err != nil {
+		t.Fatalf("ListPlatformPosts: %v", err)
+	}
+	want := []PlatformPost{posts[0], posts[2]}
+	if len(got) != len(want) {
+		t.Fatalf("ListPlatformPosts = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].PostedAt.Equal(want[i].PostedAt) {
+			t.Errorf("ListPlatformPosts[%d].PostedAt = %v, want %v", i, got[i].PostedAt, want[i].PostedAt)
+		}
+		got[i].PostedAt = want[i].PostedAt
+		if got[i] != want[i] {
+			t.Errorf("ListPlatformPosts[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got, err := store.ListPlatformPosts(ctx, "Doc 3", "u3"); err != nil || len(got) != 0 {
+		t.Errorf("ListPlatformPosts(unposted) = %+v, %v, want none", got, err)
+	}
+}
+
+// testBaselines checks that the latest baseline of a mode is found
+func testBaselines(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2026, 5, 24, 12, 0, 0, 0, time.UTC)
+
+	if b, err := store.LastBaseline(ctx, "force"); err != nil || b != nil {
+		t.Fatalf("LastBaseline on an empty store = %+v, %v; want nil", b, err)
+	}
+	for _, b := range []Baseline{
+		{Mode: "force", Seen: 12, CreatedAt: base},
+		{Mode: "force", Seen: 3, Posted: 1, CreatedAt: base.Add(time.Hour)},
+		{Mode: "auto", Seen: 20, CreatedAt: base.Add(2 * time.Hour)},
+	} {
+		if err := store.AddBaseline(ctx, b); err != nil {
+			t.Fatalf("AddBaseline: %v", err)
+		}
+	}
+
+	b, err := store.LastBaseline(ctx, "force")
+	if err != nil || b == nil {
+		t.Fatalf("LastBaseline = %+v, %v", b, err)
+	}
+	if b.Seen != 3 || b.Posted != 1 || !b.CreatedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("LastBaseline = %+v, want the second forced baseline", b)
+	}
+
+	// ApplyBaseline marks the documents and records the baseline together
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: base},
+		{Title: "Doc 2", URL: "u2", Published: base.Add(time.Minute)},
+	}
+	if err := store.ApplyBaseline(ctx, Baseline{Mode: "auto", Seen: 2, CreatedAt: base.Add(3 * time.Hour)}, docs); err != nil {
+		t.Fatalf("ApplyBaseline: %v", err)
+	}
+	if processed, err := store.FilterProcessed(ctx, docs); err != nil || len(processed) != 2 {
+		t.Errorf("FilterProcessed = %v, %v; want both documents", processed, err)
+	}
+	if b, err := store.LastBaseline(ctx, "auto"); err != nil || b == nil || b.Seen != 2 {
+		t.Errorf("LastBaseline(auto) = %+v, %v; want the applied baseline", b, err)
+	}
+}
+
+func testDigestJobs(t *testing.T, store StorageInterface) {
+	ctx := context.Background()
+	base := time.Date(2026, 5, 24, 12, 0, 0, 0, time.UTC)
+	docs := []*scraper.Document{
+		{Title: "Doc 1", URL: "u1", Published: base},
+		{Title: "Doc 2", URL: "u2", Published: base.Add(time.Minute)},
+	}
+	digest := &scraper.Document{Title: "Catch-up digest of 2 documents", URL: "digest:1", Published: base.Add(time.Minute)}
+
+	added, err := store.EnqueueDigest(ctx, digest, docs)
+	if err != nil || !added {
+		t.Fatalf("EnqueueDigest = %v, %v; want added", added, err)
+	}
+	if added, err := store.EnqueueDigest(ctx, digest, docs); err != nil || added {
+		t.Fatalf("EnqueueDigest again = %v, %v; want a no-op", added, err)
+	}
+
+	// The documents are processed as soon as the digest is queued
+	processed, err := store.FilterProcessed(ctx, docs)
+	if err != nil || len(processed) != 2 {
+		t.Fatalf("FilterProcessed = %v, %v; want both documents", processed, err)
+	}
+
+	job, err := store.ClaimJob(ctx, "w1", time.Hour)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimJob = %+v, %v", job, err)
+	}
+	if job.Title != digest.Title || len(job.Digest) != 2 ||
+		job.Digest[1].Title != "Doc 2" || !job.Digest[1].Published.Equal(docs[1].Published) {
+		t.Fatalf("claimed job = %+v, want the digest of both documents", job)
+	}
+
+	// Done digest jobs are pruned although the digest is no document
+	if err := store.CompleteJob(ctx, job.ID); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	if n, err := store.PruneJobs(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("PruneJobs = %d, %v; want the digest job", n, err)
+	}
+}
@@ -0,0 +1,128 @@
+package summary
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a model's circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // the model is used
+	BreakerOpen     BreakerState = "open"      // the model is skipped until the cool-down ends
+	BreakerHalfOpen BreakerState = "half_open" // one trial call decides whether to close again
+)
+
+// ModelStatus is a model's breaker state and error counts, for health and
+// metrics
+type ModelStatus struct {
+	Model     string
+	State     BreakerState
+	Failures  int       // consecutive failures
+	OpenUntil time.Time // end of the cool-down while open
+	Errors    map[ErrorKind]int
+}
+
+// breaker skips a model for a cool-down after threshold consecutive
+// failures. A quota error opens it at once. After the cool-down one call is
+// let through: success closes the breaker, failure opens it again.
+type breaker struct {
+	threshold int // 0 never opens
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial call is in flight
+	errors    map[ErrorKind]int
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, errors: make(map[ErrorKind]int)}
+}
+
+// state returns the breaker's state at now; the caller holds mu
+func (b *breaker) state(now time.Time) BreakerState {
+	switch {
+	case b.openUntil.IsZero():
+		return BreakerClosed
+	case now.Before(b.openUntil):
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+// allow reports whether the model may be called at now
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state(now) {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+	}
+	return true
+}
+
+// success closes the breaker
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.openUntil, b.trial = 0, time.Time{}, false
+}
+
+// release ends a call that recorded no outcome, such as one cancelled with
+// its context, so a half-open breaker lets the next trial through
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// failure counts an error of the given kind; errors about the document
+// rather than the model do not count towards opening. The cool-down lasts at
+// least as long as the server asked to wait.
+func (b *breaker) failure(kind ErrorKind, retryAfter time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.errors[kind]++
+	if !kind.countsAsOutage() {
+		if b.trial {
+			// The model answered, so it is available again
+			b.failures, b.openUntil, b.trial = 0, time.Time{}, false
+		}
+		return
+	}
+
+	b.failures++
+	if kind == KindQuota {
+		b.failures = max(b.failures, b.threshold)
+	}
+	if b.trial || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.openUntil = now.Add(max(b.cooldown, retryAfter))
+		b.trial = false
+	}
+}
+
+// status returns the breaker's state at now
+func (b *breaker) status(model string, now time.Time) ModelStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errors := make(map[ErrorKind]int, len(b.errors))
+	for kind, n := range b.errors {
+		errors[kind] = n
+	}
+	status := ModelStatus{Model: model, State: b.state(now), Failures: b.failures, Errors: errors}
+	if status.State == BreakerOpen {
+		status.OpenUntil = b.openUntil
+	}
+	return status
+}
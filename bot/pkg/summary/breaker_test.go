@@ -0,0 +1,156 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+	b := newBreaker(2, time.Minute)
+
+	// Document errors do not count
+	b.failure(KindSafety, 0, now)
+	b.failure(KindServer, 0, now)
+	if !b.allow(now) {
+		t.Fatal("breaker open after one outage")
+	}
+	b.failure(KindTimeout, 0, now)
+	if b.allow(now.Add(59 * time.Second)) {
+		t.Fatal("breaker closed after two outages")
+	}
+	if s := b.status("m", now); s.State != BreakerOpen || s.Errors[KindSafety] != 1 || s.Errors[KindTimeout] != 1 {
+		t.Errorf("status = %+v", s)
+	}
+
+	// After the cool-down a single trial call is let through
+	later := now.Add(time.Minute)
+	if !b.allow(later) || b.allow(later) {
+		t.Fatal("half-open breaker must allow exactly one trial")
+	}
+	b.failure(KindRateLimit, 5*time.Minute, later)
+	if s := b.status("m", later.Add(2*time.Minute)); s.State != BreakerOpen {
+		t.Errorf("failed trial: state = %s, want open for the Retry-After", s.State)
+	}
+
+	later = later.Add(5 * time.Minute)
+	if !b.allow(later) {
+		t.Fatal("no trial after the cool-down")
+	}
+	b.success()
+	if s := b.status("m", later); s.State != BreakerClosed || s.Failures != 0 {
+		t.Errorf("after success: %+v, want closed", s)
+	}
+
+	// Quota exhaustion opens at once
+	b.failure(KindQuota, 0, later)
+	if b.allow(later) {
+		t.Error("breaker closed after a quota error")
+	}
+}
+
+func TestBreakerReleasesCancelledTrial(t *testing.T) {
+	s := &Summarizer{retry: RetryPolicy{Attempts: 3, BaseDelay: time.Hour}}
+	b := newBreaker(1, time.Millisecond)
+	b.failure(KindTimeout, 0, time.Now())
+	time.Sleep(2 * time.Millisecond)
+
+	timeout := &ProviderError{Kind: KindTimeout, Err: errors.New("deadline exceeded")}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	shortly, cancelShortly := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShortly()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"cancelled call", cancelled},
+		{"cancelled backoff", shortly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !b.allow(time.Now()) {
+				t.Fatal("half-open breaker allowed no trial")
+			}
+			provider := &flakyProvider{errs: []error{timeout}}
+			var usage Usage
+			var cost float64
+			if _, err := s.summarizeWithRetry(tt.ctx, provider, b, modelEntry{}, Document{}, Request{}, &usage, &cost); err == nil {
+				t.Fatal("summarizeWithRetry succeeded with a stopped context")
+			}
+			if !b.allow(time.Now()) {
+				t.Error("trial not released after the call was stopped")
+			}
+			b.release()
+		})
+	}
+}
+
+// flakyProvider returns errs in turn, then summonsJSON
+type flakyProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *flakyProvider) AcceptsPDF() bool { return true }
+
+func (p *flakyProvider) Summarize(ctx context.Context, req Request) (Response, error) {
+	p.calls++
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return Response{}, err
+	}
+	return Response{Text: summonsJSON}, nil
+}
+
+func TestGenerateSummaryRetriesAndBreaks(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimited := &ProviderError{Kind: KindRateLimit, RetryAfter: time.Millisecond, Err: errors.New("429")}
+	quota := &ProviderError{Kind: KindQuota, Err: errors.New("quota exceeded")}
+	primary := &flakyProvider{errs: []error{rateLimited, rateLimited}}
+	fallback := &flakyProvider{}
+	models, _ := parseModels("gemini-2.5-flash,local/llama3.1:8b")
+	s := &Summarizer{
+		models:    models,
+		providers: map[string]Provider{ProviderVertex: primary, ProviderLocal: fallback},
+		prompts:   testPrompts(t),
+		retry:     RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, BreakerThreshold: 3, BreakerCooldown: time.Hour},
+	}
+
+	// Two rate limits are retried, the third call succeeds
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || summary.Model != "vertex/gemini-2.5-flash" || primary.calls != 3 {
+		t.Fatalf("GenerateSummary = %+v, %v after %d calls", summary, err, primary.calls)
+	}
+
+	// A quota error is not retried and opens the breaker
+	primary.errs = []error{quota}
+	primary.calls = 0
+	if summary, err = s.GenerateSummary(context.Background(), pdfPath, Document{}); err != nil || summary.Model != "local/llama3.1:8b" || primary.calls != 1 {
+		t.Fatalf("GenerateSummary = %+v, %v after %d calls", summary, err, primary.calls)
+	}
+	if _, err = s.GenerateSummary(context.Background(), pdfPath, Document{}); err != nil || primary.calls != 1 {
+		t.Errorf("open breaker: primary called %d times, want it skipped", primary.calls)
+	}
+	status := s.Status()
+	if status[0].State != BreakerOpen || status[0].Errors[KindRateLimit] != 0 || status[0].Errors[KindQuota] != 1 {
+		t.Errorf("Status = %+v", status)
+	}
+
+	// A cancelled context stops the retries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.GenerateSummary(ctx, pdfPath, Document{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateSummary with cancelled context = %v", err)
+	}
+}
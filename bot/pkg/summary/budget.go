@@ -0,0 +1,129 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by GenerateSummary once the daily budget is
+// spent and no budget models are configured; the document is posted without
+// a summary
+var ErrBudgetExhausted = errors.New("daily summarization budget spent")
+
+// Price is what a model charges in US dollars per million tokens. Thinking
+// tokens are billed as output.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// cost returns the price of usage
+func (p Price) cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// ParsePrices parses comma-separated "model=input/output" prices in US
+// dollars per million tokens, e.g.
+// "gemini-2.5-flash-lite=0.10/0.40,openai/gpt-4o-mini=0.15/0.60". A model is
+// written as in the model list, with or without its provider prefix.
+func ParsePrices(s string) (map[string]Price, error) {
+	prices := make(map[string]Price)
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, rates, found := strings.Cut(entry, "=")
+		input, output, found2 := strings.Cut(rates, "/")
+		if !found || !found2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid price %q: want model=input/output", entry)
+		}
+		var p Price
+		var err error
+		if p.Input, err = strconv.ParseFloat(strings.TrimSpace(input), 64); err != nil || p.Input < 0 {
+			return nil, fmt.Errorf("invalid input price in %q", entry)
+		}
+		if p.Output, err = strconv.ParseFloat(strings.TrimSpace(output), 64); err != nil || p.Output < 0 {
+			return nil, fmt.Errorf("invalid output price in %q", entry)
+		}
+		prices[strings.TrimSpace(model)] = p
+	}
+	return prices, nil
+}
+
+// Ledger records the tokens and cost of every model call, so spending can be
+// reported and the daily budget enforced. Days are UTC, formatted as
+// "2006-01-02".
+type Ledger interface {
+	// AddUsage adds one call's usage to the document's total for the model
+	AddUsage(ctx context.Context, day string, doc Document, model string, usage Usage, cost float64) error
+
+	// Spent returns the cost of all calls on day
+	Spent(ctx context.Context, day string) (float64, error)
+}
+
+// Day returns the ledger day of t
+func Day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// price returns the price of model; models without one cost nothing
+func (s *Summarizer) price(model modelEntry) Price {
+	if p, ok := s.prices[model.String()]; ok {
+		return p
+	}
+	return s.prices[model.name]
+}
+
+// recordUsage adds a call's usage to the ledger and returns its cost. The
+// call was made whether or not it can be recorded, so errors are only
+// logged.
+func (s *Summarizer) recordUsage(ctx context.Context, doc Document, model modelEntry, usage Usage) float64 {
+	cost := s.price(model).cost(usage)
+	if s.ledger == nil {
+		return cost
+	}
+	if err := s.ledger.AddUsage(ctx, Day(time.Now()), doc, model.String(), usage, cost); err != nil {
+		log.WithRequestContext(ctx).
+			WithContext("method", "recordUsage").
+			Warn("Error recording summary usage", "model", model.String(), "error", err)
+	}
+	return cost
+}
+
+// activeModels returns the models to try: the route's models while the
+// daily budget lasts, then the budget models. A ledger error keeps the
+// route's models, since the budget cannot be checked.
+func (s *Summarizer) activeModels(ctx context.Context, models []modelEntry) ([]modelEntry, error) {
+	spent, limit, err := s.Spending(ctx)
+	if err != nil {
+		log.WithRequestContext(ctx).
+			WithContext("method", "activeModels").
+			Warn("Error reading summary spending, ignoring the budget", "error", err)
+		return models, nil
+	}
+	if limit <= 0 || spent < limit {
+		return models, nil
+	}
+	if len(s.budgetModels) == 0 {
+		return nil, ErrBudgetExhausted
+	}
+	log.WithRequestContext(ctx).
+		WithContext("method", "activeModels").
+		Info("Daily budget spent, using budget models", "spent", spent, "budget", limit)
+	return s.budgetModels, nil
+}
+
+// Spending returns today's summarization cost and the daily budget; a budget
+// of 0 is unlimited
+func (s *Summarizer) Spending(ctx context.Context) (spent, budget float64, err error) {
+	if s.dailyBudget <= 0 || s.ledger == nil {
+		return 0, 0, nil
+	}
+	spent, err = s.ledger.Spent(ctx, Day(time.Now()))
+	return spent, s.dailyBudget, err
+}
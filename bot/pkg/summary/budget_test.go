@@ -0,0 +1,110 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePrices(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]Price
+		wantErr bool
+	}{
+		{"", map[string]Price{}, false},
+		{
+			"gemini-2.5-flash-lite=0.10/0.40, openai/gpt-4o-mini = 0.15/0.60",
+			map[string]Price{"gemini-2.5-flash-lite": {0.10, 0.40}, "openai/gpt-4o-mini": {0.15, 0.60}},
+			false,
+		},
+		{"local/llama3.1:8b=0/0", map[string]Price{"local/llama3.1:8b": {}}, false},
+		{"gemini-2.5-flash=0.30", nil, true},
+		{"=0.30/2.50", nil, true},
+		{"gemini-2.5-flash=cheap/2.50", nil, true},
+		{"gemini-2.5-flash=0.30/-1", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrices(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePrices(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParsePrices(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for model, price := range tt.want {
+			if got[model] != price {
+				t.Errorf("ParsePrices(%q)[%q] = %v, want %v", tt.in, model, got[model], price)
+			}
+		}
+	}
+}
+
+// fakeLedger keeps recorded usage in memory
+type fakeLedger struct {
+	calls []string // model per recorded call
+	spent map[string]float64
+}
+
+func (l *fakeLedger) AddUsage(ctx context.Context, day string, doc Document, model string, usage Usage, cost float64) error {
+	l.calls = append(l.calls, model)
+	l.spent[day] += cost
+	return nil
+}
+
+func (l *fakeLedger) Spent(ctx context.Context, day string) (float64, error) {
+	return l.spent[day], nil
+}
+
+func TestGenerateSummaryDailyBudget(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gemini := &stubProvider{pdf: true, summary: summonsJSON}
+	local := &stubProvider{summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash")
+	budgetModels, _ := parseModels("local/llama3.1:8b")
+	ledger := &fakeLedger{spent: make(map[string]float64)}
+	s := &Summarizer{
+		models:       models,
+		providers:    map[string]Provider{ProviderVertex: gemini, ProviderLocal: local},
+		prompts:      testPrompts(t),
+		ledger:       ledger,
+		prices:       map[string]Price{"gemini-2.5-flash": {Input: 1000, Output: 5000}},
+		dailyBudget:  0.3,
+		budgetModels: budgetModels,
+	}
+
+	// 100 input and 20 output tokens cost 0.2; the budget is spent after
+	// the second document
+	for i, want := range []string{"vertex/gemini-2.5-flash", "vertex/gemini-2.5-flash", "local/llama3.1:8b"} {
+		summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+		if err != nil || summary.Model != want {
+			t.Fatalf("document %d: GenerateSummary = %+v, %v, want model %s", i+1, summary, err, want)
+		}
+	}
+	if len(ledger.calls) != 3 {
+		t.Errorf("ledger recorded %v, want every call", ledger.calls)
+	}
+	if spent, budget, _ := s.Spending(context.Background()); spent < 0.39 || spent > 0.41 || budget != 0.3 {
+		t.Errorf("Spending = %v of %v, want 0.4 of 0.3", spent, budget)
+	}
+
+	// Without budget models the summary is skipped
+	s.budgetModels = nil
+	if _, err := s.GenerateSummary(context.Background(), pdfPath, Document{}); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("GenerateSummary over budget = %v, want ErrBudgetExhausted", err)
+	}
+	if len(gemini.got) != 2 || len(local.got) != 1 {
+		t.Errorf("calls = %d vertex, %d local, want 2 and 1", len(gemini.got), len(local.got))
+	}
+}
@@ -0,0 +1,152 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// ErrorKind classifies a failed model call, deciding whether it is retried
+type ErrorKind string
+
+const (
+	KindRateLimit      ErrorKind = "rate_limit"      // too many requests; retried after a delay
+	KindQuota          ErrorKind = "quota"           // quota or budget exhausted; not retried
+	KindInvalidRequest ErrorKind = "invalid_request" // bad request, unknown model or credentials; not retried
+	KindSafety         ErrorKind = "safety"          // blocked by a safety filter; not retried
+	KindTimeout        ErrorKind = "timeout"         // no answer in time; retried
+	KindServer         ErrorKind = "server"          // server or network error; retried
+)
+
+// ErrorKinds lists every kind, e.g. for metrics
+var ErrorKinds = []ErrorKind{KindRateLimit, KindQuota, KindInvalidRequest, KindSafety, KindTimeout, KindServer}
+
+// ProviderError is a failed model call with its kind. RetryAfter is the
+// delay the server asked for, if any.
+type ProviderError struct {
+	Kind       ErrorKind
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether the same model may succeed on another attempt
+func (k ErrorKind) retryable() bool {
+	return k == KindRateLimit || k == KindTimeout || k == KindServer
+}
+
+// countsAsOutage reports whether the error says something about the model's
+// availability rather than about the document, and so trips its breaker
+func (k ErrorKind) countsAsOutage() bool {
+	return k != KindInvalidRequest && k != KindSafety
+}
+
+// errorKind returns the kind of err; errors providers did not classify are
+// server errors
+func errorKind(err error) (ErrorKind, time.Duration) {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Kind, pe.RetryAfter
+	}
+	if isTimeout(err) {
+		return KindTimeout, 0
+	}
+	return KindServer, 0
+}
+
+// isTimeout reports whether err is a deadline or network timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// classifyStatus returns the kind of an HTTP error response. message is the
+// response's error message, which tells quota exhaustion from rate limiting.
+func classifyStatus(status int, message string) ErrorKind {
+	lower := strings.ToLower(message)
+	switch {
+	case status == http.StatusTooManyRequests && (strings.Contains(lower, "quota") || strings.Contains(lower, "billing")):
+		return KindQuota
+	case status == http.StatusTooManyRequests:
+		return KindRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return KindTimeout
+	case strings.Contains(lower, "safety") || strings.Contains(lower, "content_policy") || strings.Contains(lower, "content policy"):
+		return KindSafety
+	case status >= 400 && status < 500:
+		return KindInvalidRequest
+	}
+	return KindServer
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// classifyGemini wraps an error of the genai client in a ProviderError. The
+// retry delay of a rate limit comes from the google.rpc.RetryInfo detail.
+func classifyGemini(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		kind, _ := errorKind(err)
+		return &ProviderError{Kind: kind, Err: err}
+	}
+
+	pe := &ProviderError{Kind: classifyStatus(apiErr.Code, apiErr.Message+" "+apiErr.Status), Err: err}
+	if apiErr.Status == "DEADLINE_EXCEEDED" {
+		pe.Kind = KindTimeout
+	}
+	for _, detail := range apiErr.Details {
+		if t, _ := detail["@type"].(string); !strings.HasSuffix(t, "google.rpc.RetryInfo") {
+			continue
+		}
+		if delay, _ := detail["retryDelay"].(string); delay != "" {
+			if d, err := time.ParseDuration(delay); err == nil {
+				pe.RetryAfter = d
+			}
+		}
+	}
+	return pe
+}
+
+// blockedGemini returns a safety error if the prompt or answer was blocked
+func blockedGemini(resp *genai.GenerateContentResponse) error {
+	if fb := resp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+		return &ProviderError{Kind: KindSafety, Err: fmt.Errorf("prompt blocked: %s", fb.BlockReason)}
+	}
+	if len(resp.Candidates) > 0 {
+		switch reason := resp.Candidates[0].FinishReason; reason {
+		case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent, genai.FinishReasonSPII:
+			return &ProviderError{Kind: KindSafety, Err: fmt.Errorf("response blocked: %s", reason)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		want    ErrorKind
+	}{
+		{http.StatusTooManyRequests, "Rate limit reached for requests", KindRateLimit},
+		{http.StatusTooManyRequests, "You exceeded your current quota, please check your plan and billing details", KindQuota},
+		{http.StatusBadRequest, "Your request was rejected by our safety system", KindSafety},
+		{http.StatusBadRequest, "model not found", KindInvalidRequest},
+		{http.StatusUnauthorized, "invalid api key", KindInvalidRequest},
+		{http.StatusGatewayTimeout, "upstream timed out", KindTimeout},
+		{http.StatusServiceUnavailable, "overloaded", KindServer},
+	}
+	for _, tt := range tests {
+		if got := classifyStatus(tt.status, tt.message); got != tt.want {
+			t.Errorf("classifyStatus(%d, %q) = %s, want %s", tt.status, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyGemini(t *testing.T) {
+	rateLimited := genai.APIError{
+		Code:    429,
+		Status:  "RESOURCE_EXHAUSTED",
+		Message: "Resource exhausted. Please try again later.",
+		Details: []map[string]any{{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "37s"}},
+	}
+	tests := []struct {
+		err        error
+		kind       ErrorKind
+		retryAfter time.Duration
+	}{
+		{fmt.Errorf("error generating summary: %w", rateLimited), KindRateLimit, 37 * time.Second},
+		{genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED", Message: "Quota exceeded for metric generate_content_requests"}, KindQuota, 0},
+		{genai.APIError{Code: 404, Status: "NOT_FOUND", Message: "Publisher model not found"}, KindInvalidRequest, 0},
+		{genai.APIError{Code: 504, Status: "DEADLINE_EXCEEDED"}, KindTimeout, 0},
+		{context.DeadlineExceeded, KindTimeout, 0},
+		{errors.New("connection reset by peer"), KindServer, 0},
+	}
+	for _, tt := range tests {
+		kind, retryAfter := errorKind(classifyGemini(tt.err))
+		if kind != tt.kind || retryAfter != tt.retryAfter {
+			t.Errorf("classifyGemini(%v) = %s, %s; want %s, %s", tt.err, kind, retryAfter, tt.kind, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EvalCase is a sample document with the key facts its summary should
+// state. A case is a PDF with a JSON file of the same name next to it:
+//
+//	{"title": "Doc 12 - Decision - Car 4", "type": "decision", "series": "f1",
+//	 "facts": ["Norris", "5-second|5 second", "Turn 1"]}
+//
+// A fact is found if the summary contains it, ignoring case and accents;
+// "|" separates alternative wordings.
+type EvalCase struct {
+	Name     string // file name without extension
+	PDF      string // path of the PDF
+	Document Document
+	Facts    []string
+}
+
+// evalCaseFile is the JSON file of an EvalCase
+type evalCaseFile struct {
+	Title  string   `json:"title"`
+	Type   string   `json:"type"`
+	Series string   `json:"series"`
+	Facts  []string `json:"facts"`
+}
+
+// LoadEvalCases reads the cases in dir, in file name order. Every PDF needs
+// its JSON file.
+func LoadEvalCases(dir string) ([]EvalCase, error) {
+	pdfs, err := filepath.Glob(filepath.Join(dir, "*.pdf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", dir, err)
+	}
+	if len(pdfs) == 0 {
+		return nil, fmt.Errorf("no PDFs in %s", dir)
+	}
+
+	cases := make([]EvalCase, 0, len(pdfs))
+	for _, pdf := range pdfs {
+		name := strings.TrimSuffix(filepath.Base(pdf), ".pdf")
+		data, err := os.ReadFile(strings.TrimSuffix(pdf, ".pdf") + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("error reading expected facts of %s: %w", name, err)
+		}
+		var f evalCaseFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("error parsing expected facts of %s: %w", name, err)
+		}
+		if f.Title == "" {
+			f.Title = name
+		}
+		cases = append(cases, EvalCase{
+			Name:     name,
+			PDF:      pdf,
+			Document: Document{Title: f.Title, Type: f.Type, Series: f.Series},
+			Facts:    f.Facts,
+		})
+	}
+	return cases, nil
+}
+
+// EvalScore is how a summary of an EvalCase did
+type EvalScore struct {
+	Case     string
+	Model    string // the model that answered, empty on error
+	Summary  string
+	Error    string // the summarizer's error; the summary is not scored
+	Rejected string // why the guardrails rejected the summary, if they did
+
+	FactsFound int
+	FactsTotal int
+	Missing    []string
+
+	Words      int
+	LengthOK   bool     // within the guardrails' word bounds
+	Violations []string // banned tokens and emojis in the summary
+
+	Usage    Usage
+	Duration time.Duration
+}
+
+// Coverage returns the share of the case's facts the summary states, 1 for a
+// case without facts
+func (sc EvalScore) Coverage() float64 {
+	if sc.FactsTotal == 0 {
+		return 1
+	}
+	return float64(sc.FactsFound) / float64(sc.FactsTotal)
+}
+
+// Evaluate summarizes the case and scores the summary. Summaries the
+// guardrails reject are scored too; only other errors leave it unscored.
+func (s *Summarizer) Evaluate(ctx context.Context, c EvalCase) EvalScore {
+	score := EvalScore{Case: c.Name, FactsTotal: len(c.Facts)}
+
+	start := time.Now()
+	res, err := s.GenerateSummary(ctx, c.PDF, c.Document)
+	score.Duration = time.Since(start)
+
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		res, score.Rejected = rejected.Result, rejected.Reason
+	} else if err != nil {
+		score.Error = err.Error()
+		return score
+	}
+	score.Model, score.Summary, score.Usage = res.Model, res.Text, res.Usage
+
+	summary := foldText(res.Text)
+	for _, fact := range c.Facts {
+		if containsFact(summary, fact) {
+			score.FactsFound++
+		} else {
+			score.Missing = append(score.Missing, fact)
+		}
+	}
+
+	g := s.guardrails
+	score.Words = len(strings.Fields(res.Text))
+	score.LengthOK = (g.MinWords == 0 || score.Words >= g.MinWords) && (g.MaxWords == 0 || score.Words <= g.MaxWords)
+	score.Violations = g.violations(res.Text)
+	return score
+}
+
+// containsFact reports whether the folded summary states one of the fact's
+// "|"-separated wordings
+func containsFact(summary, fact string) bool {
+	for wording := range strings.SplitSeq(fact, "|") {
+		if wording = strings.TrimSpace(wording); wording != "" && strings.Contains(summary, foldText(wording)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package summary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("decision.pdf", minimalPDF)
+	write("decision.json", `{"title": "Doc 12 - Decision - Car 1", "type": "decision", "facts": ["verstappen", "5-second|5 second", "Turn 1"]}`)
+
+	cases, err := LoadEvalCases(dir)
+	if err != nil || len(cases) != 1 || cases[0].Name != "decision" || cases[0].Document.Type != "decision" {
+		t.Fatalf("LoadEvalCases = %+v, %v", cases, err)
+	}
+
+	// The stand-in server answers like a local model, so this runs offline
+	var requests []chatRequest
+	srv := fakeChatServer(t, &requests)
+	s, err := New(Config{
+		Models:     "local/good",
+		LocalURL:   srv.URL + "/v1",
+		Guardrails: Guardrails{MinWords: 10, Banned: []string{"-second"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	score := s.Evaluate(context.Background(), cases[0])
+	if score.Error != "" || score.Model != "local/good" || !strings.Contains(score.Rejected, "words") {
+		t.Fatalf("Evaluate = %+v, want a rejected but scored summary", score)
+	}
+	if score.FactsFound != 2 || score.FactsTotal != 3 || strings.Join(score.Missing, ",") != "Turn 1" {
+		t.Errorf("facts = %d/%d missing %v, want 2/3 missing Turn 1", score.FactsFound, score.FactsTotal, score.Missing)
+	}
+	if score.Words != 5 || score.LengthOK || len(score.Violations) != 1 {
+		t.Errorf("words = %d, length ok %t, violations %v", score.Words, score.LengthOK, score.Violations)
+	}
+
+	// A missing expectations file is an error
+	write("other.pdf", minimalPDF)
+	if _, err := LoadEvalCases(dir); err == nil {
+		t.Error("LoadEvalCases accepted a PDF without expected facts")
+	}
+}
@@ -0,0 +1,179 @@
+package summary
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/auth/credentials"
+	"google.golang.org/genai"
+)
+
+// defaultVertexLocation applies when Config.VertexLocation is not set; the
+// global endpoint serves every Gemini model
+const defaultVertexLocation = "global"
+
+// cloudPlatformScope is the OAuth scope Vertex AI calls need
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// geminiProvider summarizes PDFs with Gemini, on Vertex AI or on the Gemini
+// API of Google AI Studio
+type geminiProvider struct {
+	client *genai.Client
+}
+
+// newGeminiProvider creates a Gemini client; name is the backend's name for
+// logs and errors
+func newGeminiProvider(name string, cc *genai.ClientConfig) (*geminiProvider, error) {
+	ctxLog := log.WithContext("method", "newGeminiProvider")
+
+	ctxLog.Info("Creating " + name + " client")
+	client, err := genai.NewClient(context.Background(), cc)
+	if err != nil {
+		ctxLog.Error("Error creating "+name+" client", "error", err)
+		return nil, fmt.Errorf("error creating %s client: %w", name, err)
+	}
+	return &geminiProvider{client: client}, nil
+}
+
+// vertexConfig returns the Vertex AI client configuration of cfg: express
+// mode with an API key, or a project and location authenticated with a
+// service account key file or, without one, Application Default Credentials
+func vertexConfig(cfg Config) (*genai.ClientConfig, error) {
+	// With the AI Studio backend the API key is AI Studio's
+	apiKey := cfg.APIKey
+	if cfg.Backend == ProviderAIStudio {
+		apiKey = ""
+	}
+	switch {
+	case cfg.VertexProject != "" && apiKey != "":
+		return nil, fmt.Errorf("vertex models need either GEMINI_API_KEY or VERTEX_PROJECT, not both")
+	case cfg.VertexProject != "":
+		cc := &genai.ClientConfig{
+			Backend:  genai.BackendVertexAI,
+			Project:  cfg.VertexProject,
+			Location: cmp.Or(cfg.VertexLocation, defaultVertexLocation),
+		}
+		if cfg.VertexCredentialsFile != "" {
+			creds, err := credentials.NewCredentialsFromFile(credentials.ServiceAccount, cfg.VertexCredentialsFile, &credentials.DetectOptions{
+				Scopes: []string{cloudPlatformScope},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error reading service account credentials %s: %w", cfg.VertexCredentialsFile, err)
+			}
+			cc.Credentials = creds
+		}
+		return cc, nil
+	case apiKey != "":
+		return &genai.ClientConfig{Backend: genai.BackendVertexAI, APIKey: apiKey}, nil
+	}
+	if cfg.Backend == ProviderAIStudio {
+		return nil, fmt.Errorf("vertex models need VERTEX_PROJECT with the aistudio backend")
+	}
+	return nil, fmt.Errorf("vertex models need GEMINI_API_KEY or VERTEX_PROJECT")
+}
+
+// CheckModel looks the model up, which fails if it does not exist or the
+// credentials cannot use it
+func (g *geminiProvider) CheckModel(ctx context.Context, model string) error {
+	if _, err := g.client.Models.Get(ctx, model, nil); err != nil {
+		return fmt.Errorf("error looking up model %s: %w", model, err)
+	}
+	return nil
+}
+
+// AcceptsPDF is true: Gemini reads PDFs natively
+func (g *geminiProvider) AcceptsPDF() bool {
+	return true
+}
+
+// Summarize sends the document text, or the PDF inline, and asks req.Model
+// for a summary
+func (g *geminiProvider) Summarize(ctx context.Context, req Request) (Response, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "Summarize").
+		WithContext("model", req.Model)
+
+	ctxLog.Debug("Creating model config")
+	config := createModelConfig(req)
+
+	document := genai.NewPartFromText(req.Text)
+	if req.PDF != nil {
+		ctxLog.Debug("Creating chat session with inline PDF")
+		// Send PDF as inline data (Vertex AI does not support the Files API)
+		document = &genai.Part{
+			InlineData: &genai.Blob{
+				Data:     req.PDF,
+				MIMEType: "application/pdf",
+			},
+		}
+	} else {
+		ctxLog.Debug("Creating chat session with document text")
+	}
+	history := []*genai.Content{
+		{
+			Role:  genai.RoleUser,
+			Parts: []*genai.Part{document},
+		},
+	}
+
+	chat, err := g.client.Chats.Create(ctx, req.Model, config, history)
+	if err != nil {
+		ctxLog.Error("Error creating chat session", "error", err)
+		return Response{}, classifyGemini(fmt.Errorf("error creating chat session with model %s: %w", req.Model, err))
+	}
+
+	ctxLog.Debug("Sending message to model")
+	resp, err := chat.Send(ctx, genai.NewPartFromText(req.Prompt))
+	if err != nil {
+		ctxLog.Error("Error generating summary", "error", err)
+		return Response{}, classifyGemini(fmt.Errorf("error generating summary with model %s: %w", req.Model, err))
+	}
+
+	var usage Usage
+	if m := resp.UsageMetadata; m != nil {
+		usage = Usage{
+			InputTokens:    int(m.PromptTokenCount),
+			OutputTokens:   int(m.CandidatesTokenCount + m.ThoughtsTokenCount),
+			ThinkingTokens: int(m.ThoughtsTokenCount),
+		}
+	}
+
+	if err := blockedGemini(resp); err != nil {
+		ctxLog.Warn("Summary blocked", "error", err)
+		return Response{Usage: usage}, err
+	}
+
+	// Text joins the text parts of the first candidate, leaving out thoughts
+	text := strings.TrimSpace(resp.Text())
+	if text == "" {
+		ctxLog.Error("No summary generated", "candidates", len(resp.Candidates))
+		return Response{Usage: usage}, fmt.Errorf("no summary generated by model %s", req.Model)
+	}
+	return Response{Text: text, Usage: usage}, nil
+}
+
+// createModelConfig creates the model configuration from the request options
+func createModelConfig(req Request) *genai.GenerateContentConfig {
+	temperature := float32(req.Temperature)
+
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(req.System, genai.RoleUser),
+		Temperature:       &temperature,
+		MaxOutputTokens:   int32(req.MaxTokens),
+	}
+
+	if req.Schema != nil {
+		config.ResponseMIMEType = "application/json"
+		config.ResponseJsonSchema = req.Schema
+	}
+
+	if req.ThinkingLevel != "" {
+		config.ThinkingConfig = &genai.ThinkingConfig{
+			ThinkingLevel: genai.ThinkingLevel(strings.ToUpper(req.ThinkingLevel)),
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,183 @@
+package summary
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Guardrails are the checks a summary must pass before it is posted. A
+// summary that fails them is discarded and the next model tried.
+type Guardrails struct {
+	// MinWords and MaxWords bound the summary's length; 0 disables a bound
+	MinWords int
+	MaxWords int
+
+	// Banned lists substrings the summary must not contain, e.g. "#";
+	// matching ignores case and surrounding spaces. Emojis are always
+	// rejected.
+	Banned []string
+}
+
+// RejectedError is returned by GenerateSummary when every model that
+// answered produced a summary failing the guardrails. Result is the last
+// rejected summary, kept for review.
+type RejectedError struct {
+	Result Result
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("summary by %s rejected: %s", e.Result.Model, e.Reason)
+}
+
+// rejection is a guardrail failure, as opposed to a model error
+type rejection struct {
+	reason string
+}
+
+func (r *rejection) Error() string {
+	return "summary rejected: " + r.reason
+}
+
+func reject(format string, args ...any) error {
+	return &rejection{reason: fmt.Sprintf(format, args...)}
+}
+
+// numberPattern matches whole numbers in text
+var numberPattern = regexp.MustCompile(`\d+`)
+
+// check validates d against the guardrails. Drivers, car numbers, the
+// penalty's figures and the numbers and names in the summary text must
+// appear in pageText, the text of the PDF; the facts are not checked when
+// the PDF has no text layer (pageText is empty).
+func (g Guardrails) check(d Details, pageText string) error {
+	words := len(strings.Fields(d.Summary))
+	if g.MinWords > 0 && words < g.MinWords {
+		return reject("%d words, want at least %d", words, g.MinWords)
+	}
+	if g.MaxWords > 0 && words > g.MaxWords {
+		return reject("%d words, want at most %d", words, g.MaxWords)
+	}
+
+	if err := g.checkTokens(d.Summary); err != nil {
+		return err
+	}
+
+	if pageText == "" {
+		return nil
+	}
+	text := foldText(pageText)
+	for _, driver := range d.Drivers {
+		// Documents write names as "Max Verstappen" or "VERSTAPPEN Max";
+		// the last name is enough
+		fields := strings.Fields(driver)
+		if name := foldText(fields[len(fields)-1]); !strings.Contains(text, name) {
+			return reject("driver %q not found in document", driver)
+		}
+	}
+	numbers := make(map[string]bool)
+	for _, n := range numberPattern.FindAllString(pageText, -1) {
+		numbers[trimZeros(n)] = true
+	}
+	for _, n := range d.CarNumbers {
+		if !numbers[strconv.Itoa(n)] {
+			return reject("car number %d not found in document", n)
+		}
+	}
+	for _, n := range numberPattern.FindAllString(d.Penalty, -1) {
+		if !numbers[trimZeros(n)] {
+			return reject("penalty %q not found in document", d.Penalty)
+		}
+	}
+
+	// The summary is what gets posted, so its own figures and names are
+	// checked too: the details can be right while the text says otherwise
+	for _, n := range numberPattern.FindAllString(d.Summary, -1) {
+		if !numbers[trimZeros(n)] {
+			return reject("number %s in summary not found in document", n)
+		}
+	}
+	for _, name := range names(d.Summary) {
+		if !strings.Contains(text, foldText(name)) {
+			return reject("name %q in summary not found in document", name)
+		}
+	}
+	return nil
+}
+
+// namePattern matches a capitalised word, e.g. "Pérez" but not "FIA"
+var namePattern = regexp.MustCompile(`^\p{Lu}\p{Ll}+`)
+
+// names lists the capitalised words of text that don't start a sentence:
+// the surnames, teams and places it mentions
+func names(text string) []string {
+	var found []string
+	sentenceStart := true
+	for _, field := range strings.Fields(text) {
+		word := strings.TrimLeftFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if name := namePattern.FindString(word); name != "" && !sentenceStart {
+			found = append(found, name)
+		}
+		sentenceStart = strings.HasSuffix(strings.TrimRight(field, `"')”’`), ".") ||
+			strings.ContainsAny(field[len(field)-1:], "!?:")
+	}
+	return found
+}
+
+// checkTokens rejects text containing a banned token or an emoji
+func (g Guardrails) checkTokens(text string) error {
+	if violations := g.violations(text); len(violations) > 0 {
+		return reject("contains %s", violations[0])
+	}
+	return nil
+}
+
+// violations lists the banned tokens and emojis in text
+func (g Guardrails) violations(text string) []string {
+	var violations []string
+	lower := strings.ToLower(text)
+	for _, token := range g.Banned {
+		token = strings.TrimSpace(token)
+		if token != "" && strings.Contains(lower, strings.ToLower(token)) {
+			violations = append(violations, fmt.Sprintf("banned token %q", token))
+		}
+	}
+	for _, r := range text {
+		if isEmoji(r) {
+			violations = append(violations, fmt.Sprintf("emoji %q", r))
+		}
+	}
+	return violations
+}
+
+// trimZeros strips leading zeros, so car "04" matches car 4
+func trimZeros(n string) string {
+	if n = strings.TrimLeft(n, "0"); n == "" {
+		return "0"
+	}
+	return n
+}
+
+// foldText lowercases s and strips diacritics, so "Pérez" matches "PEREZ"
+func foldText(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// isEmoji reports whether r is in one of the emoji blocks
+func isEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF) || // pictographs, emoticons, transport, supplemental symbols
+		(r >= 0x2600 && r <= 0x27BF) || // miscellaneous symbols, dingbats
+		(r >= 0x1F1E6 && r <= 0x1F1FF) || // regional indicators (flags)
+		r == 0xFE0F // variation selector
+}
@@ -0,0 +1,80 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGuardrailsCheck(t *testing.T) {
+	const pageText = "Decision\fCar 04 - Sergio PEREZ\nOscar Piastri\nTime penalty of 10 seconds. Fine of EUR 5,000."
+	summary := strings.Repeat("word ", 40)
+	guard := Guardrails{MinWords: 30, MaxWords: 60, Banned: []string{"#", "https://"}}
+
+	tests := []struct {
+		name    string
+		details Details
+		text    string
+		wantErr string
+	}{
+		{name: "valid", details: Details{Summary: summary, Drivers: []string{"Sergio Pérez", "Oscar Piastri"}, CarNumbers: []int{4}, Penalty: "10-second penalty and €5,000 fine"}, text: pageText},
+		{name: "too short", details: Details{Summary: "Pérez penalised."}, text: pageText, wantErr: "at least 30"},
+		{name: "too long", details: Details{Summary: strings.Repeat("word ", 61)}, text: pageText, wantErr: "at most 60"},
+		{name: "hashtag", details: Details{Summary: summary + "#F1"}, text: pageText, wantErr: `banned token "#"`},
+		{name: "emoji", details: Details{Summary: summary + "🏁"}, text: pageText, wantErr: "emoji"},
+		{name: "unknown driver", details: Details{Summary: summary, Drivers: []string{"Lando Norris"}}, text: pageText, wantErr: "Lando Norris"},
+		{name: "unknown car", details: Details{Summary: summary, CarNumbers: []int{44}}, text: pageText, wantErr: "car number 44"},
+		{name: "wrong penalty", details: Details{Summary: summary, Penalty: "3-place grid penalty"}, text: pageText, wantErr: "penalty"},
+		{name: "summary names", details: Details{Summary: summary + ". The stewards penalised Sergio Pérez and Oscar Piastri. Pérez pays EUR 5,000."}, text: pageText},
+		{name: "car in summary", details: Details{Summary: summary + ". Car 44 gets a 10-second penalty."}, text: pageText, wantErr: "number 44 in summary"},
+		{name: "penalty in summary", details: Details{Summary: summary + ". Car 4 gets a 3-second penalty."}, text: pageText, wantErr: "number 3 in summary"},
+		{name: "driver in summary", details: Details{Summary: summary + ". Car 4 of Lewis Hamilton is penalised.", Drivers: []string{"Sergio Pérez"}, CarNumbers: []int{4}}, text: pageText, wantErr: `name "Lewis" in summary`},
+		{name: "no text layer", details: Details{Summary: summary + ". Car 44 of Lando Norris.", Drivers: []string{"Lando Norris"}, CarNumbers: []int{44}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.check(tt.details, tt.text)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("check = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("check = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateSummaryRejectsFailingSummaries(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wrongCar := &stubProvider{pdf: true, summary: strings.Replace(summonsJSON, `[1]`, `[16]`, 1)}
+	unavailable := &stubProvider{err: errors.New("connection refused")}
+	models, _ := parseModels("gemini-2.5-flash,local/llama3.1:8b")
+	s := &Summarizer{
+		models:     models,
+		providers:  map[string]Provider{ProviderVertex: wrongCar, ProviderLocal: unavailable},
+		prompts:    testPrompts(t),
+		guardrails: Guardrails{Banned: []string{"#"}},
+	}
+
+	_, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("GenerateSummary error = %v, want a RejectedError", err)
+	}
+	if rejected.Result.Model != "vertex/gemini-2.5-flash" || rejected.Result.Text != "Summons issued." || !strings.Contains(rejected.Reason, "car number 16") {
+		t.Errorf("rejected = %+v", rejected)
+	}
+	if len(unavailable.got) != 1 {
+		t.Errorf("next model called %d times, want 1", len(unavailable.got))
+	}
+}
@@ -0,0 +1,202 @@
+package summary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// openAIProvider summarizes document text through the chat completions API
+// of an OpenAI-compatible endpoint. Local Ollama and llama.cpp servers expose
+// the same API.
+type openAIProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newOpenAIProvider(baseURL, apiKey string) *openAIProvider {
+	return &openAIProvider{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model           string        `json:"model"`
+	Messages        []chatMessage `json:"messages"`
+	Temperature     *float64      `json:"temperature,omitempty"`
+	MaxTokens       int           `json:"max_tokens"`
+	ReasoningEffort string        `json:"reasoning_effort,omitempty"`
+	ResponseFormat  *chatFormat   `json:"response_format,omitempty"`
+}
+
+// chatFormat constrains the response to a JSON schema
+type chatFormat struct {
+	Type       string `json:"type"` // "json_schema"
+	JSONSchema struct {
+		Name   string         `json:"name"`
+		Strict bool           `json:"strict"`
+		Schema map[string]any `json:"schema"`
+	} `json:"json_schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		Details          struct {
+			ReasoningTokens int `json:"reasoning_tokens"`
+		} `json:"completion_tokens_details"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// AcceptsPDF is false: compatible servers only take text
+func (o *openAIProvider) AcceptsPDF() bool {
+	return false
+}
+
+// Summarize sends the document text to req.Model
+func (o *openAIProvider) Summarize(ctx context.Context, req Request) (Response, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "Summarize").
+		WithContext("model", req.Model)
+
+	body := chatRequest{
+		Model: req.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: req.System},
+			{Role: "user", Content: req.Prompt + "\n\n" + req.Text},
+		},
+		MaxTokens: req.MaxTokens,
+	}
+	if req.ThinkingLevel != "" {
+		// Reasoning models reject a custom temperature
+		body.ReasoningEffort = req.ThinkingLevel
+	} else {
+		temperature := req.Temperature
+		body.Temperature = &temperature
+	}
+	if req.Schema != nil {
+		format := &chatFormat{Type: "json_schema"}
+		format.JSONSchema.Name = "document_summary"
+		format.JSONSchema.Strict = true
+		format.JSONSchema.Schema = req.Schema
+		body.ResponseFormat = format
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Response{}, fmt.Errorf("error encoding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	ctxLog.Debug("Sending chat completion request")
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		ctxLog.Error("Error generating summary", "error", err)
+		kind, _ := errorKind(err)
+		return Response{}, &ProviderError{Kind: kind, Err: fmt.Errorf("error generating summary with model %s: %w", req.Model, err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		kind, _ := errorKind(err)
+		return Response{}, &ProviderError{Kind: kind, Err: fmt.Errorf("error reading response from model %s: %w", req.Model, err)}
+	}
+
+	var chat chatResponse
+	decodeErr := json.Unmarshal(raw, &chat)
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(raw))
+		if decodeErr == nil && chat.Error != nil {
+			msg = chat.Error.Message
+		}
+		ctxLog.Error("Error generating summary", "status", resp.StatusCode, "error", msg)
+		return Response{}, &ProviderError{
+			Kind:       classifyStatus(resp.StatusCode, msg),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Err:        fmt.Errorf("error generating summary with model %s: status %d: %s", req.Model, resp.StatusCode, msg),
+		}
+	}
+	if decodeErr != nil {
+		return Response{}, fmt.Errorf("error decoding response from model %s: %w", req.Model, decodeErr)
+	}
+
+	usage := Usage{
+		InputTokens:    chat.Usage.PromptTokens,
+		OutputTokens:   chat.Usage.CompletionTokens,
+		ThinkingTokens: chat.Usage.Details.ReasoningTokens,
+	}
+	if len(chat.Choices) > 0 && chat.Choices[0].FinishReason == "content_filter" {
+		ctxLog.Warn("Summary blocked by content filter")
+		return Response{Usage: usage}, &ProviderError{Kind: KindSafety, Err: fmt.Errorf("response of model %s blocked by content filter", req.Model)}
+	}
+	if len(chat.Choices) == 0 || strings.TrimSpace(chat.Choices[0].Message.Content) == "" {
+		ctxLog.Error("No summary generated", "choices", len(chat.Choices))
+		return Response{Usage: usage}, fmt.Errorf("no summary generated by model %s", req.Model)
+	}
+	return Response{Text: strings.TrimSpace(chat.Choices[0].Message.Content), Usage: usage}, nil
+}
+
+// CheckModel looks the model up in the endpoint's model list
+func (o *openAIProvider) CheckModel(ctx context.Context, model string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("error listing models: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("error listing models: status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&list); err != nil {
+		return fmt.Errorf("error decoding model list: %w", err)
+	}
+	for _, m := range list.Data {
+		if m.ID == model {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %s not found", model)
+}
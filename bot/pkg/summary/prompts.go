@@ -0,0 +1,182 @@
+package summary
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// defaultPrompts are the built-in templates; files in the prompts directory
+// override them by name
+//
+//go:embed prompts/*.tmpl
+var defaultPrompts embed.FS
+
+// Document describes the document being summarized. Type and Series select
+// the prompt template; all fields are available to it.
+type Document struct {
+	Title  string
+	URL    string
+	Type   string // scraper.DocumentType, e.g. "decision"
+	Series string // e.g. "f1"; empty when unknown
+
+	// Text is the PDF's text as extracted by utils.ExtractText, if the
+	// caller already has it; empty extracts it from the PDF
+	Text string
+}
+
+// Prompts holds the system prompt templates, one per document type with a
+// default. A template named "<type>.tmpl" serves that type; one in a
+// "<series>/" subdirectory serves it only for that series. Each file may
+// start with a "version: N" line followed by "---"; without one the version
+// is derived from the file's content, so an edit always changes it.
+type Prompts struct {
+	dir string
+
+	mu        sync.RWMutex
+	templates map[string]*promptTemplate // keyed by "type" or "series/type"
+}
+
+type promptTemplate struct {
+	name    string
+	version string
+	tmpl    *template.Template
+}
+
+// defaultPromptName is the template used when no type-specific one exists
+const defaultPromptName = "default"
+
+// LoadPrompts loads the built-in templates overridden by those in dir (empty
+// for the built-in ones only)
+func LoadPrompts(dir string) (*Prompts, error) {
+	p := &Prompts{dir: dir}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload re-reads the templates. On error the previous templates stay in use.
+func (p *Prompts) Reload() error {
+	templates := make(map[string]*promptTemplate)
+
+	builtIn, err := fs.Sub(defaultPrompts, "prompts")
+	if err != nil {
+		return fmt.Errorf("error reading built-in prompts: %w", err)
+	}
+	if err := loadTemplates(builtIn, templates); err != nil {
+		return err
+	}
+	if p.dir != "" {
+		if _, err := os.Stat(p.dir); err != nil {
+			return fmt.Errorf("error reading prompts directory: %w", err)
+		}
+		if err := loadTemplates(os.DirFS(p.dir), templates); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	p.templates = templates
+	p.mu.Unlock()
+
+	log.WithContext("method", "Reload").Info("Prompt templates loaded", "dir", p.dir, "templates", len(templates))
+	return nil
+}
+
+// loadTemplates adds the *.tmpl files at the root of fsys and in its
+// series subdirectories to templates
+func loadTemplates(fsys fs.FS, templates map[string]*promptTemplate) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.Count(name, "/") > 0 {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".tmpl") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("error reading prompt %s: %w", name, err)
+		}
+		t, err := parsePrompt(strings.TrimSuffix(name, ".tmpl"), data)
+		if err != nil {
+			return err
+		}
+		templates[t.name] = t
+		return nil
+	})
+}
+
+// parsePrompt parses a template file with an optional version header
+func parsePrompt(name string, data []byte) (*promptTemplate, error) {
+	body := string(data)
+	version := ""
+	if header, rest, found := strings.Cut(body, "\n---\n"); found && strings.HasPrefix(header, "version:") {
+		version = strings.TrimSpace(strings.TrimPrefix(header, "version:"))
+		body = rest
+	}
+	if version == "" {
+		sum := sha256.Sum256(data)
+		version = "sha-" + hex.EncodeToString(sum[:4])
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(strings.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing prompt %s: %w", name, err)
+	}
+	return &promptTemplate{name: name, version: version, tmpl: tmpl}, nil
+}
+
+// Render returns the system prompt for doc and its version, "name@version".
+// The most specific template wins: series and type, type, series default,
+// default.
+func (p *Prompts) Render(doc Document) (string, string, error) {
+	p.mu.RLock()
+	var t *promptTemplate
+	for _, name := range promptCandidates(doc) {
+		if t = p.templates[name]; t != nil {
+			break
+		}
+	}
+	p.mu.RUnlock()
+	if t == nil {
+		return "", "", errors.New("no default prompt template")
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, doc); err != nil {
+		return "", "", fmt.Errorf("error rendering prompt %s: %w", t.name, err)
+	}
+	return buf.String(), t.name + "@" + t.version, nil
+}
+
+// promptCandidates lists the template names for doc, most specific first
+func promptCandidates(doc Document) []string {
+	var names []string
+	if doc.Series != "" && doc.Type != "" {
+		names = append(names, path.Join(doc.Series, doc.Type))
+	}
+	if doc.Type != "" {
+		names = append(names, doc.Type)
+	}
+	if doc.Series != "" {
+		names = append(names, path.Join(doc.Series, defaultPromptName))
+	}
+	return append(names, defaultPromptName)
+}
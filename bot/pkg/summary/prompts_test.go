@@ -0,0 +1,94 @@
+package summary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPrompts loads the built-in prompt templates
+func testPrompts(t *testing.T) *Prompts {
+	t.Helper()
+	prompts, err := LoadPrompts("")
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+	return prompts
+}
+
+func TestPromptsBuiltIn(t *testing.T) {
+	prompts := testPrompts(t)
+
+	tests := []struct {
+		doc         Document
+		wantVersion string
+		wantText    string
+	}{
+		{Document{Title: "Doc 13 - Decision - Car 4", Type: "decision", Series: "f1"}, "decision@2", `titled "Doc 13 - Decision - Car 4"`},
+		{Document{Title: "Doc 8 - Technical Delegate's Report", Type: "technical"}, "technical@2", "technical directive"},
+		{Document{Title: "Race Director's Event Notes", Type: "other", Series: "f2"}, "default@2", "First, identify the document type"},
+		{Document{}, "default@2", "40–60 word summary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantVersion, func(t *testing.T) {
+			text, version, err := prompts.Render(tt.doc)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if version != tt.wantVersion || !strings.Contains(text, tt.wantText) {
+				t.Errorf("Render(%+v) = %q, %q; want version %q containing %q", tt.doc, text, version, tt.wantVersion, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestPromptsOverrideAndReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("technical.tmpl", "version: 7\n---\nSummarize the directive {{.Title}}.\n")
+	write("f2/decision.tmpl", "Summarize this Formula 2 decision.\n")
+
+	prompts, err := LoadPrompts(dir)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+
+	text, version, _ := prompts.Render(Document{Title: "TD 12", Type: "technical", Series: "f1"})
+	if text != "Summarize the directive TD 12." || version != "technical@7" {
+		t.Errorf("technical = %q, %q; want the override with its header version", text, version)
+	}
+	_, f1Version, _ := prompts.Render(Document{Type: "decision", Series: "f1"})
+	_, f2Version, _ := prompts.Render(Document{Type: "decision", Series: "f2"})
+	if f1Version != "decision@2" || !strings.HasPrefix(f2Version, "f2/decision@sha-") {
+		t.Errorf("decision versions = %q (f1), %q (f2); want the built-in and a content-hashed series override", f1Version, f2Version)
+	}
+
+	// An edit without a version header changes the derived version
+	write("f2/decision.tmpl", "Summarize this Formula 2 stewards decision.\n")
+	if err := prompts.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, v, _ := prompts.Render(Document{Type: "decision", Series: "f2"}); v == f2Version {
+		t.Errorf("version after edit = %q, want it to change", v)
+	}
+
+	// A broken template is rejected and the loaded ones stay in use
+	write("technical.tmpl", "version: 8\n---\n{{.Title\n")
+	if err := prompts.Reload(); err == nil {
+		t.Error("Reload accepted a broken template")
+	}
+	if _, v, _ := prompts.Render(Document{Type: "technical"}); v != "technical@7" {
+		t.Errorf("version after failed reload = %q, want technical@7", v)
+	}
+}
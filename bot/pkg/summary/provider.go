@@ -0,0 +1,120 @@
+package summary
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Provider names used as model list prefixes (see Config.Models)
+const (
+	ProviderVertex   = "vertex"   // Gemini on Vertex AI
+	ProviderAIStudio = "aistudio" // Gemini API of Google AI Studio
+	ProviderOpenAI   = "openai"   // any OpenAI-compatible endpoint
+	ProviderLocal    = "local"    // local Ollama or llama.cpp server
+)
+
+// Provider is a summarization backend. One provider serves every model of
+// its kind in the model list.
+type Provider interface {
+	// Summarize returns req.Model's response to the request
+	Summarize(ctx context.Context, req Request) (Response, error)
+
+	// AcceptsPDF reports whether the provider can read a PDF itself. PDFs are
+	// only sent for scanned documents without a usable text layer, which
+	// text-only providers cannot summarize.
+	AcceptsPDF() bool
+}
+
+// Request is a single summarization request. Exactly one of PDF and Text is
+// set: the document's text when it has a usable text layer, otherwise the
+// PDF.
+type Request struct {
+	Model  string
+	System string
+	Prompt string
+	PDF    []byte
+	Text   string
+
+	// ThinkingLevel is "low", "medium" or "high" for thinking models and
+	// empty for others
+	ThinkingLevel string
+	Temperature   float64
+	MaxTokens     int
+
+	// Schema, if set, is the JSON schema the response must conform to
+	Schema map[string]any
+}
+
+// Response is a model's answer with the tokens it took
+type Response struct {
+	Text  string
+	Usage Usage
+}
+
+// Usage counts the tokens of one or more model calls. OutputTokens includes
+// ThinkingTokens, which are billed as output.
+type Usage struct {
+	InputTokens    int
+	OutputTokens   int
+	ThinkingTokens int
+}
+
+// Add returns the sum of u and other
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:    u.InputTokens + other.InputTokens,
+		OutputTokens:   u.OutputTokens + other.OutputTokens,
+		ThinkingTokens: u.ThinkingTokens + other.ThinkingTokens,
+	}
+}
+
+// isProvider reports whether name is a known provider prefix
+func isProvider(name string) bool {
+	switch name {
+	case ProviderVertex, ProviderAIStudio, ProviderOpenAI, ProviderLocal:
+		return true
+	}
+	return false
+}
+
+// isGemini reports whether the provider serves Gemini models
+func isGemini(name string) bool {
+	return name == ProviderVertex || name == ProviderAIStudio
+}
+
+// newProvider creates the named provider from cfg
+func newProvider(name string, cfg Config) (Provider, error) {
+	switch name {
+	case ProviderVertex:
+		cc, err := vertexConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newGeminiProvider("Vertex AI", cc)
+	case ProviderAIStudio:
+		// With the AI Studio backend GEMINI_API_KEY is the AI Studio key
+		apiKey := cfg.AIStudioAPIKey
+		if cfg.Backend == ProviderAIStudio {
+			apiKey = cmp.Or(apiKey, cfg.APIKey)
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("aistudio models need AISTUDIO_API_KEY")
+		}
+		return newGeminiProvider("AI Studio", &genai.ClientConfig{APIKey: apiKey, Backend: genai.BackendGeminiAPI})
+	case ProviderOpenAI:
+		if cfg.OpenAIBaseURL == "" {
+			return nil, fmt.Errorf("openai models need OPENAI_BASE_URL")
+		}
+		return newOpenAIProvider(strings.TrimSuffix(cfg.OpenAIBaseURL, "/"), cfg.OpenAIAPIKey), nil
+	case ProviderLocal:
+		if cfg.LocalURL == "" {
+			return nil, fmt.Errorf("local models need LOCAL_LLM_URL")
+		}
+		return newOpenAIProvider(strings.TrimSuffix(cfg.LocalURL, "/"), ""), nil
+	}
+	return nil, fmt.Errorf("unknown provider %q", name)
+}
@@ -0,0 +1,93 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// modelChecker is implemented by providers that can check a model is
+// available without summarizing anything
+type modelChecker interface {
+	CheckModel(ctx context.Context, model string) error
+}
+
+// ModelCheck is the result of checking that a model is reachable
+type ModelCheck struct {
+	Model string // as in ModelStatus
+	Err   error  // nil when reachable
+}
+
+// Readiness is whether the summarizer can summarize documents: some model
+// of the model list, or of the budget models, passed the last check and its
+// circuit breaker is not open
+type Readiness struct {
+	Ready   bool
+	Reason  string    // why it is not ready
+	Checked time.Time // when CheckModels last ran; zero if it never did
+	Models  []ModelCheck
+}
+
+// CheckModels checks that every model is reachable, in Status order.
+// Models of providers that cannot check them count as reachable. The error
+// lists the failures when no model of the model list or budget models is
+// reachable.
+func (s *Summarizer) CheckModels(ctx context.Context) ([]ModelCheck, error) {
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "CheckModels")
+
+	models := s.allModels()
+	checks := make([]ModelCheck, 0, len(models))
+	for _, model := range models {
+		var err error
+		if checker, ok := s.providers[model.provider].(modelChecker); ok {
+			err = checker.CheckModel(ctx, model.name)
+		}
+		if err != nil {
+			ctxLog.Warn("Model unreachable", "model", model.cacheKey(), "error", err)
+		}
+		checks = append(checks, ModelCheck{Model: model.cacheKey(), Err: err})
+	}
+
+	s.readyMu.Lock()
+	s.checked, s.checks = time.Now(), checks
+	s.readyMu.Unlock()
+
+	if r := s.Readiness(); !r.Ready {
+		return checks, fmt.Errorf("summarizer not ready: %s", r.Reason)
+	}
+	ctxLog.Info("Models checked", "models", len(checks))
+	return checks, nil
+}
+
+// Readiness returns whether the summarizer can summarize documents
+func (s *Summarizer) Readiness() Readiness {
+	s.readyMu.Lock()
+	r := Readiness{Checked: s.checked, Models: s.checks}
+	s.readyMu.Unlock()
+
+	if r.Checked.IsZero() {
+		r.Reason = "models not checked"
+		return r
+	}
+
+	now := time.Now()
+	var failures []string
+	for _, model := range appendNew(s.models, s.budgetModels...) {
+		i := slices.IndexFunc(r.Models, func(c ModelCheck) bool { return c.Model == model.cacheKey() })
+		switch {
+		case i < 0:
+			continue
+		case r.Models[i].Err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", model.cacheKey(), r.Models[i].Err))
+		case s.breaker(model).status(model.cacheKey(), now).State == BreakerOpen:
+			failures = append(failures, fmt.Sprintf("%s: circuit breaker open", model.cacheKey()))
+		default:
+			r.Ready = true
+			return r
+		}
+	}
+	r.Reason = "no model reachable (" + strings.Join(failures, "; ") + ")"
+	return r
+}
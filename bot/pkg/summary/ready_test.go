@@ -0,0 +1,118 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkingProvider is a stubProvider that can check its models
+type checkingProvider struct {
+	stubProvider
+	missing map[string]bool
+}
+
+func (p *checkingProvider) CheckModel(ctx context.Context, model string) error {
+	if p.missing[model] {
+		return errors.New("model not found")
+	}
+	return nil
+}
+
+func TestCheckModels(t *testing.T) {
+	models, _ := parseModels("gemini-2.5-flash,local/llama3.1:8b")
+	s := &Summarizer{
+		models: models,
+		providers: map[string]Provider{
+			ProviderVertex: &checkingProvider{missing: map[string]bool{"gemini-2.5-flash": true}},
+			ProviderLocal:  &checkingProvider{},
+		},
+		retry: RetryPolicy{BreakerThreshold: 1, BreakerCooldown: time.Hour},
+	}
+	if r := s.Readiness(); r.Ready || r.Reason != "models not checked" {
+		t.Errorf("Readiness before the check = %+v", r)
+	}
+
+	checks, err := s.CheckModels(context.Background())
+	if err != nil {
+		t.Fatalf("CheckModels: %v", err)
+	}
+	if len(checks) != 2 || checks[0].Err == nil || checks[1].Err != nil {
+		t.Errorf("checks = %+v, want the Vertex model unreachable", checks)
+	}
+	if r := s.Readiness(); !r.Ready {
+		t.Errorf("Readiness = %+v, want ready with the local model", r)
+	}
+
+	// With the local model's breaker open nothing is left
+	s.breaker(models[1]).failure(KindServer, 0, time.Now())
+	r := s.Readiness()
+	if r.Ready || !strings.Contains(r.Reason, "vertex/gemini-2.5-flash: model not found") || !strings.Contains(r.Reason, "local/llama3.1:8b: circuit breaker open") {
+		t.Errorf("Readiness = %+v, want not ready with both reasons", r)
+	}
+	if _, err := s.CheckModels(context.Background()); err == nil {
+		t.Error("CheckModels succeeded without a usable model")
+	}
+}
+
+func TestOpenAICheckModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object": "list", "data": [{"id": "llama3.1:8b"}, {"id": "qwen3:14b"}]}`))
+	}))
+	defer srv.Close()
+
+	provider := newOpenAIProvider(srv.URL+"/v1", "secret")
+	if err := provider.CheckModel(context.Background(), "qwen3:14b"); err != nil {
+		t.Errorf("CheckModel(listed) = %v", err)
+	}
+	if err := provider.CheckModel(context.Background(), "mistral"); err == nil {
+		t.Error("CheckModel accepted a model that is not listed")
+	}
+	if err := newOpenAIProvider(srv.URL+"/v1", "wrong").CheckModel(context.Background(), "qwen3:14b"); err == nil {
+		t.Error("CheckModel succeeded with a rejected key")
+	}
+}
+
+func TestVertexConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantErr      bool
+		wantKey      string
+		wantLocation string
+	}{
+		{name: "api key", cfg: Config{APIKey: "key"}, wantKey: "key"},
+		{name: "project", cfg: Config{VertexProject: "fia-bot"}, wantLocation: "global"},
+		{name: "project and location", cfg: Config{VertexProject: "fia-bot", VertexLocation: "europe-west4"}, wantLocation: "europe-west4"},
+		{name: "key and project", cfg: Config{APIKey: "key", VertexProject: "fia-bot"}, wantErr: true},
+		{name: "AI Studio key", cfg: Config{Backend: ProviderAIStudio, APIKey: "key", VertexProject: "fia-bot"}, wantLocation: "global"},
+		{name: "nothing", cfg: Config{}, wantErr: true},
+		{name: "AI Studio key only", cfg: Config{Backend: ProviderAIStudio, APIKey: "key"}, wantErr: true},
+		{name: "missing credentials", cfg: Config{VertexProject: "fia-bot", VertexCredentialsFile: "/nonexistent.json"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc, err := vertexConfig(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("vertexConfig = %+v, want error", cc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("vertexConfig: %v", err)
+			}
+			if cc.APIKey != tt.wantKey || cc.Location != tt.wantLocation {
+				t.Errorf("vertexConfig = key %q location %q, want %q and %q", cc.APIKey, cc.Location, tt.wantKey, tt.wantLocation)
+			}
+		})
+	}
+}
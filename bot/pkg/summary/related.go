@@ -0,0 +1,190 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"bot/pkg/scraper"
+)
+
+// RelatedDocument is an earlier posted document that may be given to the
+// model as context, e.g. the summons preceding a decision
+type RelatedDocument struct {
+	Title     string
+	URL       string
+	Published time.Time
+	Summary   string
+	Text      string // empty once past retention
+}
+
+// History finds the earlier documents a new one may refer to
+type History interface {
+	// RecentDocuments returns documents published since since, newest first
+	RecentDocuments(ctx context.Context, since time.Time) ([]RelatedDocument, error)
+}
+
+// Related-document defaults for Config.RelatedWindow and Config.MaxRelated
+const (
+	defaultRelatedWindow = 4 * 24 * time.Hour // an event weekend
+	defaultMaxRelated    = 3
+)
+
+// relatedTextBudget caps, in estimated tokens, the text of a related
+// document that has no summary
+const relatedTextBudget = 500
+
+// minRelatedScore is the score a document needs to count as related: a
+// citation or shared incident time on its own, or a shared car and turn
+const minRelatedScore = 2
+
+var (
+	// carPattern matches car references such as "Car 4" or "Cars 16 and 55"
+	carPattern = regexp.MustCompile(`(?i)\bcars?\s+(?:no\.?\s*)?(\d{1,2})(?:\s*(?:,|and|&)\s*(\d{1,2}))*\b`)
+	// citationPattern matches references to other documents, e.g.
+	// "Document 23" or "Doc 23"
+	citationPattern = regexp.MustCompile(`(?i)\bdoc(?:ument)?s?\.?\s+(?:no\.?\s*)?(\d{1,3})\b`)
+	// turnPattern matches incident locations such as "Turn 1"
+	turnPattern = regexp.MustCompile(`(?i)\bturns?\s+(\d{1,2})\b`)
+	// incidentTimePattern matches the time of an incident as given in
+	// summonses and decisions, e.g. "Time 14:03"
+	incidentTimePattern = regexp.MustCompile(`(?i)\btime\s*:?\s*(\d{1,2}:\d{2})\b`)
+)
+
+// references are what a document says about the incident it concerns
+type references struct {
+	number    int // the document's own number, 0 if none
+	cars      []string
+	citations []string
+	turns     []string
+	times     []string
+}
+
+// findReferences extracts the references of a document from its title and
+// text
+func findReferences(title, text string) references {
+	all := title + "\n" + text
+	refs := references{
+		citations: submatches(citationPattern, text),
+		turns:     submatches(turnPattern, all),
+		times:     submatches(incidentTimePattern, all),
+	}
+	if n, ok := scraper.DocumentNumber(title); ok {
+		refs.number = n
+	}
+	for _, m := range carPattern.FindAllString(all, -1) {
+		for _, n := range numberPattern.FindAllString(m, -1) {
+			refs.cars = appendUnique(refs.cars, trimZeros(n))
+		}
+	}
+	return refs
+}
+
+// submatches returns the distinct first groups of pattern's matches
+func submatches(pattern *regexp.Regexp, s string) []string {
+	var out []string
+	for _, m := range pattern.FindAllStringSubmatch(s, -1) {
+		out = appendUnique(out, trimZeros(m[1]))
+	}
+	return out
+}
+
+func appendUnique(list []string, s string) []string {
+	if slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
+
+// score rates how likely an earlier document with references other concerns
+// the same incident as the new one: 3 for a citation of its number, 2 for a
+// shared incident time and 1 each for a shared car and turn
+func (r references) score(other references) int {
+	score := 0
+	if other.number > 0 && slices.Contains(r.citations, fmt.Sprint(other.number)) {
+		score += 3
+	}
+	if shares(r.times, other.times) {
+		score += 2
+	}
+	if shares(r.cars, other.cars) {
+		score++
+	}
+	if shares(r.turns, other.turns) {
+		score++
+	}
+	return score
+}
+
+// shares reports whether a and b have an element in common
+func shares(a, b []string) bool {
+	return slices.ContainsFunc(a, func(s string) bool { return slices.Contains(b, s) })
+}
+
+// relatedDocuments returns up to maxRelated earlier documents referring to
+// the same incident as doc, best match first. History errors are logged:
+// the summary can do without context.
+func (s *Summarizer) relatedDocuments(ctx context.Context, doc Document, pageText string) []RelatedDocument {
+	if s.history == nil || s.maxRelated <= 0 {
+		return nil
+	}
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "relatedDocuments")
+
+	candidates, err := s.history.RecentDocuments(ctx, time.Now().Add(-s.relatedWindow))
+	if err != nil {
+		ctxLog.Warn("Error reading earlier documents, summarizing without context", "error", err)
+		return nil
+	}
+
+	refs := findReferences(doc.Title, pageText)
+	type match struct {
+		doc   RelatedDocument
+		score int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if c.URL == doc.URL {
+			continue
+		}
+		text := c.Text
+		if text == "" {
+			text = c.Summary
+		}
+		if score := refs.score(findReferences(c.Title, text)); score >= minRelatedScore {
+			matches = append(matches, match{doc: c, score: score})
+		}
+	}
+	// Candidates are newest first, so equal scores keep the most recent
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	var related []RelatedDocument
+	for _, m := range matches[:min(len(matches), s.maxRelated)] {
+		related = append(related, m.doc)
+	}
+	if len(related) > 0 {
+		ctxLog.Debug("Found related documents", "count", len(related), "best_score", matches[0].score)
+	}
+	return related
+}
+
+// relatedContext renders related documents for the prompt, with their
+// summary or, lacking one, the start of their text
+func relatedContext(related []RelatedDocument) string {
+	if len(related) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nEarlier documents about the same incident, for context only. Summarize the attached document, not these; refer to them where it helps, e.g. \"following the summons...\".")
+	for _, r := range related {
+		content := r.Summary
+		if content == "" {
+			content, _ = trimToBudget(r.Text, relatedTextBudget)
+		}
+		fmt.Fprintf(&b, "\n\n--- %s (published %s)\n%s", r.Title, r.Published.UTC().Format("2006-01-02 15:04 MST"), content)
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package summary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferencesScore(t *testing.T) {
+	decision := findReferences("Doc 31 - Decision - Car 4 - Causing a collision",
+		"Following the summons in Document 27. Car 4 - Lando Norris. Time 14:03. Session FP2. Fact: Collided with Car 16 at Turn 1.")
+	if decision.number != 31 || strings.Join(decision.cars, ",") != "4,16" || strings.Join(decision.citations, ",") != "27" ||
+		strings.Join(decision.turns, ",") != "1" || strings.Join(decision.times, ",") != "14:03" {
+		t.Fatalf("findReferences = %+v", decision)
+	}
+
+	tests := []struct {
+		name  string
+		title string
+		text  string
+		want  int
+	}{
+		{"cited summons", "Doc 27 - Summons - Car 4", "Car 4 - Lando Norris. Time 14:03. Turn 1.", 3 + 2 + 1 + 1},
+		{"uncited, same incident time", "Doc 25 - Summons - Car 16", "Car 16. Time 14:03.", 2 + 1},
+		{"same car and turn", "Doc 12 - Infringement - Car 4", "Car 04 exceeded track limits at Turn 1.", 1 + 1},
+		{"same car only", "Doc 5 - Classification", "Cars 4 and 81 were noted.", 1},
+		{"unrelated", "Doc 2 - Entry List", "Car 44, Car 63.", 0},
+	}
+	for _, tt := range tests {
+		if got := decision.score(findReferences(tt.title, tt.text)); got != tt.want {
+			t.Errorf("%s: score = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeHistory returns fixed earlier documents
+type fakeHistory struct {
+	docs []RelatedDocument
+}
+
+func (h *fakeHistory) RecentDocuments(ctx context.Context, since time.Time) ([]RelatedDocument, error) {
+	return h.docs, nil
+}
+
+func TestGenerateSummaryAddsRelatedContext(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	published := time.Date(2025, 3, 15, 14, 30, 0, 0, time.UTC)
+	history := &fakeHistory{docs: []RelatedDocument{
+		{Title: "Doc 9 - Summons - Car 1", URL: "u9", Published: published, Summary: "Verstappen summoned for a Turn 1 incident in FP2."},
+		{Title: "Doc 8 - Entry List", URL: "u8", Published: published, Text: "Car 44 Lewis Hamilton"},
+		{Title: "Doc 7 - Summons - Car 1", URL: "u7", Published: published, Text: "Car 1 Max Verstappen. Report from the Race Director, Turn 1."},
+	}}
+	provider := &stubProvider{pdf: true, summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash")
+	s := &Summarizer{
+		models:        models,
+		providers:     map[string]Provider{ProviderVertex: provider},
+		prompts:       testPrompts(t),
+		history:       history,
+		relatedWindow: defaultRelatedWindow,
+		maxRelated:    1,
+	}
+
+	// Both summonses concern car 1 at Turn 1 and the entry list nothing;
+	// with room for one, the newer summons wins
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{Title: "Doc 10 - Decision - Car 1 - Turn 1 incident", URL: "u10"})
+	if err != nil {
+		t.Fatalf("GenerateSummary: %v", err)
+	}
+	if len(summary.Related) != 1 || summary.Related[0] != "u9" {
+		t.Errorf("Related = %v, want the newest matching summons", summary.Related)
+	}
+	prompt := provider.got[0].Prompt
+	if !strings.Contains(prompt, "--- Doc 9 - Summons - Car 1 (published 2025-03-15 14:30 UTC)\nVerstappen summoned") ||
+		strings.Contains(prompt, "Doc 7") || strings.Contains(prompt, "Entry List") {
+		t.Errorf("prompt = %q, want only Doc 9 as context", prompt)
+	}
+
+	// Without related documents the prompt is unchanged
+	s.history = &fakeHistory{}
+	provider.got = nil
+	if _, err := s.GenerateSummary(context.Background(), pdfPath, Document{Title: "Doc 11 - Decision - Car 1", URL: "u11"}); err != nil {
+		t.Fatalf("GenerateSummary: %v", err)
+	}
+	if provider.got[0].Prompt != userPrompt {
+		t.Errorf("prompt = %q, want the plain user prompt", provider.got[0].Prompt)
+	}
+}
+
+func TestGenerateSummaryCachesRelated(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	published := time.Date(2025, 3, 15, 14, 30, 0, 0, time.UTC)
+	history := &fakeHistory{docs: []RelatedDocument{
+		{Title: "Doc 9 - Summons - Car 1", URL: "u9", Published: published, Summary: "Verstappen summoned for a Turn 1 incident in FP2."},
+	}}
+	provider := &stubProvider{pdf: true, summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash")
+	s := &Summarizer{
+		models:        models,
+		providers:     map[string]Provider{ProviderVertex: provider},
+		prompts:       testPrompts(t),
+		cache:         memoryCache{},
+		history:       history,
+		relatedWindow: defaultRelatedWindow,
+		maxRelated:    3,
+	}
+	doc := Document{Title: "Doc 10 - Decision - Car 1 - Turn 1 incident", URL: "u10"}
+
+	// A retry with the same related documents is served from the cache
+	for range 2 {
+		summary, err := s.GenerateSummary(context.Background(), pdfPath, doc)
+		if err != nil || len(summary.Related) != 1 || summary.Related[0] != "u9" {
+			t.Fatalf("GenerateSummary = %+v, %v; want Doc 9 related", summary, err)
+		}
+	}
+	if len(provider.got) != 1 {
+		t.Fatalf("provider called %d times, want 1", len(provider.got))
+	}
+
+	// Another related set was not part of the cached summary's prompt, so
+	// the summary is written again and reports the documents it saw
+	history.docs = append(history.docs, RelatedDocument{Title: "Doc 7 - Summons - Car 1", URL: "u7", Published: published, Text: "Car 1 Max Verstappen. Turn 1."})
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, doc)
+	if err != nil || len(summary.Related) != 2 || summary.Input == InputCache {
+		t.Fatalf("GenerateSummary = %+v, %v; want a new summary with both summonses", summary, err)
+	}
+	if len(provider.got) != 2 {
+		t.Errorf("provider called %d times, want 2", len(provider.got))
+	}
+}
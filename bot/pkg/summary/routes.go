@@ -0,0 +1,160 @@
+package summary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrSkipped is returned by GenerateSummary when the document's route posts
+// it without a summary
+var ErrSkipped = errors.New("summary skipped by route")
+
+// Route chooses the model chain and generation options for the documents it
+// matches. Routes are read from a JSON array (see LoadRoutes); the first
+// route matching a document wins and documents no route matches use the
+// model list with default options. Empty conditions match every document.
+type Route struct {
+	Name string `json:"name"`
+
+	// Conditions
+	Types         []string `json:"types"`          // document types, e.g. "decision"
+	Series        []string `json:"series"`         // e.g. "f1"
+	TitleContains []string `json:"title_contains"` // any of these, ignoring case, e.g. "entry list"
+	MinPages      int      `json:"min_pages"`
+	MaxPages      int      `json:"max_pages"` // 0 for no limit
+
+	// Skip posts matching documents without a summary
+	Skip bool `json:"skip"`
+
+	// Models is a model list as in Config.Models; empty uses Config.Models
+	Models string `json:"models"`
+
+	// Temperature and MaxTokens override the defaults (0.7 and 8192).
+	// ThinkingLevel ("low", "medium" or "high") applies to the route's
+	// ":thinking" models, which use "medium" otherwise.
+	Temperature   *float64 `json:"temperature"`
+	MaxTokens     int      `json:"max_tokens"`
+	ThinkingLevel string   `json:"thinking_level"`
+}
+
+// Generation defaults for models without route options
+const (
+	defaultTemperature   = 0.7
+	defaultMaxTokens     = 8192
+	defaultThinkingLevel = "medium"
+)
+
+// thinkingLevels are the levels a route may set
+var thinkingLevels = []string{"low", "medium", "high"}
+
+// route is a Route with its model list parsed
+type route struct {
+	Route
+	models []modelEntry
+}
+
+// LoadRoutes reads and validates the routes in the JSON file at path
+func LoadRoutes(path string) ([]Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading routes: %w", err)
+	}
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("error parsing routes %s: %w", path, err)
+	}
+	for i, r := range routes {
+		if _, err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid route %d (%q): %w", i+1, r.Name, err)
+		}
+	}
+	return routes, nil
+}
+
+// validate checks the route and returns its parsed model list (nil for the
+// default one)
+func (r Route) validate() ([]modelEntry, error) {
+	for _, t := range r.Types {
+		if !slices.Contains(documentTypes, t) {
+			return nil, fmt.Errorf("unknown document type %q", t)
+		}
+	}
+	if r.MinPages < 0 || r.MaxPages < 0 || (r.MaxPages > 0 && r.MaxPages < r.MinPages) {
+		return nil, fmt.Errorf("invalid page range %d-%d", r.MinPages, r.MaxPages)
+	}
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
+		return nil, fmt.Errorf("temperature %v outside 0-2", *r.Temperature)
+	}
+	if r.MaxTokens < 0 {
+		return nil, fmt.Errorf("negative max_tokens %d", r.MaxTokens)
+	}
+	if r.ThinkingLevel != "" && !slices.Contains(thinkingLevels, r.ThinkingLevel) {
+		return nil, fmt.Errorf("unknown thinking level %q (want low, medium or high)", r.ThinkingLevel)
+	}
+	if r.Skip || strings.TrimSpace(r.Models) == "" {
+		return nil, nil
+	}
+	return parseModels(r.Models)
+}
+
+// matches reports whether the route applies to doc with the given page
+// count; page conditions never match an unknown count (0)
+func (r Route) matches(doc Document, pages int) bool {
+	if len(r.Types) > 0 && !slices.Contains(r.Types, doc.Type) {
+		return false
+	}
+	if len(r.Series) > 0 && !slices.Contains(r.Series, doc.Series) {
+		return false
+	}
+	if len(r.TitleContains) > 0 {
+		title := strings.ToLower(doc.Title)
+		if !slices.ContainsFunc(r.TitleContains, func(s string) bool {
+			return strings.Contains(title, strings.ToLower(s))
+		}) {
+			return false
+		}
+	}
+	if (r.MinPages > 0 || r.MaxPages > 0) && pages == 0 {
+		return false
+	}
+	if pages < r.MinPages || (r.MaxPages > 0 && pages > r.MaxPages) {
+		return false
+	}
+	return true
+}
+
+// route returns the first route matching doc, or the default route
+func (s *Summarizer) route(doc Document, pages int) route {
+	for _, r := range s.routes {
+		if r.matches(doc, pages) {
+			return r
+		}
+	}
+	return route{Route: Route{Name: "default"}, models: s.models}
+}
+
+// request returns the request for model under the route's options
+func (r route) request(model modelEntry) Request {
+	req := Request{
+		Model:       model.name,
+		Temperature: defaultTemperature,
+		MaxTokens:   defaultMaxTokens,
+	}
+	if r.Temperature != nil {
+		req.Temperature = *r.Temperature
+	}
+	if r.MaxTokens > 0 {
+		req.MaxTokens = r.MaxTokens
+	}
+	if model.useThinking {
+		req.ThinkingLevel = defaultThinkingLevel
+		if r.ThinkingLevel != "" {
+			req.ThinkingLevel = r.ThinkingLevel
+		}
+	}
+	return req
+}
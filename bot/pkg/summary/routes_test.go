@@ -0,0 +1,121 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"valid", `[
+			{"name": "entry lists", "title_contains": ["entry list"], "skip": true},
+			{"name": "decisions", "types": ["decision"], "models": "gemini-3.1-flash-lite:thinking", "thinking_level": "high", "temperature": 0.2},
+			{"name": "long", "min_pages": 10, "max_tokens": 4096}
+		]`, ""},
+		{"unknown type", `[{"types": ["entry_list"]}]`, "unknown document type"},
+		{"bad pages", `[{"min_pages": 5, "max_pages": 2}]`, "invalid page range"},
+		{"bad temperature", `[{"temperature": 3}]`, "temperature"},
+		{"bad thinking", `[{"thinking_level": "max"}]`, "thinking level"},
+		{"bad models", `[{"models": "gemini-2.5-flash:fast"}]`, "unknown suffix"},
+		{"not JSON", `{"name": "x"}`, "error parsing routes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "routes.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			routes, err := LoadRoutes(path)
+			if tt.wantErr == "" {
+				if err != nil || len(routes) != 3 || *routes[1].Temperature != 0.2 {
+					t.Errorf("LoadRoutes = %+v, %v", routes, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadRoutes error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteMatches(t *testing.T) {
+	decision := Document{Title: "Doc 12 - Decision - Car 1", Type: "decision", Series: "f1"}
+	tests := []struct {
+		name  string
+		route Route
+		doc   Document
+		pages int
+		want  bool
+	}{
+		{"empty matches all", Route{}, decision, 0, true},
+		{"type", Route{Types: []string{"decision", "summons"}}, decision, 1, true},
+		{"other type", Route{Types: []string{"classification"}}, decision, 1, false},
+		{"series", Route{Series: []string{"f2"}}, decision, 1, false},
+		{"title ignores case", Route{TitleContains: []string{"entry list"}}, Document{Title: "Doc 3 - Entry List"}, 2, true},
+		{"title", Route{TitleContains: []string{"entry list"}}, decision, 1, false},
+		{"min pages", Route{MinPages: 10}, decision, 12, true},
+		{"below min pages", Route{MinPages: 10}, decision, 3, false},
+		{"above max pages", Route{MaxPages: 2}, decision, 3, false},
+		{"unknown pages", Route{MaxPages: 2}, decision, 0, false},
+		{"all conditions", Route{Types: []string{"decision"}, Series: []string{"f1"}, MaxPages: 2}, decision, 1, true},
+	}
+	for _, tt := range tests {
+		if got := tt.route.matches(tt.doc, tt.pages); got != tt.want {
+			t.Errorf("%s: matches = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateSummaryRoutes(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vertex := &stubProvider{pdf: true, summary: summonsJSON}
+	local := &stubProvider{summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash-lite")
+	thinking, _ := parseModels("gemini-3.1-flash-lite:thinking,local/llama3.1:8b")
+	temperature := 0.2
+	s := &Summarizer{
+		models:    models,
+		providers: map[string]Provider{ProviderVertex: vertex, ProviderLocal: local},
+		prompts:   testPrompts(t),
+		routes: []route{
+			{Route: Route{Name: "entry lists", TitleContains: []string{"entry list"}, Skip: true}},
+			{Route: Route{Name: "decisions", Types: []string{"decision"}, ThinkingLevel: "high", Temperature: &temperature, MaxTokens: 1024}, models: thinking},
+		},
+	}
+
+	if _, err := s.GenerateSummary(context.Background(), pdfPath, Document{Title: "Doc 3 - Entry List"}); !errors.Is(err, ErrSkipped) {
+		t.Errorf("entry list: GenerateSummary error = %v, want ErrSkipped", err)
+	}
+	if len(vertex.got) != 0 {
+		t.Errorf("entry list: %d model calls, want none", len(vertex.got))
+	}
+
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{Title: "Doc 12 - Decision", Type: "decision"})
+	if err != nil || summary.Route != "decisions" || summary.Model != "vertex/gemini-3.1-flash-lite" {
+		t.Fatalf("decision: GenerateSummary = %+v, %v", summary, err)
+	}
+	if req := vertex.got[0]; req.ThinkingLevel != "high" || req.Temperature != 0.2 || req.MaxTokens != 1024 {
+		t.Errorf("decision request = %+v, want the route's options", req)
+	}
+
+	summary, err = s.GenerateSummary(context.Background(), pdfPath, Document{Title: "Doc 20 - Classification", Type: "classification"})
+	if err != nil || summary.Route != "default" || summary.Model != "vertex/gemini-2.5-flash-lite" {
+		t.Fatalf("classification: GenerateSummary = %+v, %v", summary, err)
+	}
+	if req := vertex.got[1]; req.ThinkingLevel != "" || req.Temperature != defaultTemperature || req.MaxTokens != defaultMaxTokens {
+		t.Errorf("default request = %+v, want the default options", req)
+	}
+}
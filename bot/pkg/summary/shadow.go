@@ -0,0 +1,135 @@
+package summary
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShadowCandidate is a model list and prompts run on live documents
+// alongside production, for comparison only: its summaries are recorded but
+// never posted
+type ShadowCandidate struct {
+	Models     string // as Config.Models
+	PromptsDir string // empty for the production prompts
+}
+
+// Name identifies the candidate, "models" or "models@prompts dir"
+func (c ShadowCandidate) Name() string {
+	if c.PromptsDir == "" {
+		return c.Models
+	}
+	return c.Models + "@" + c.PromptsDir
+}
+
+// ParseShadowCandidates parses ";"-separated candidates, each a model list
+// optionally followed by "@" and a prompts directory, e.g.
+// "gemini-3-flash:thinking;gemini-2.5-flash-lite@/app/prompts-next". Models
+// without a provider prefix are validated as models of backend (as
+// Config.Backend, empty for Vertex AI).
+func ParseShadowCandidates(s, backend string) ([]ShadowCandidate, error) {
+	backend = cmp.Or(backend, ProviderVertex)
+	var candidates []ShadowCandidate
+	for spec := range strings.SplitSeq(s, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		c := ShadowCandidate{Models: spec}
+		if i := strings.LastIndex(spec, "@"); i >= 0 {
+			c.Models, c.PromptsDir = strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		}
+		if _, err := parseModelsFor(c.Models, backend); err != nil {
+			return nil, fmt.Errorf("invalid shadow candidate %q: %w", spec, err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// ShadowResult is a candidate's summary of a document. Err is the
+// summarizer's error; a summary the guardrails rejected is kept in Result
+// with a *RejectedError.
+type ShadowResult struct {
+	Candidate string
+	Result    Result
+	Err       error
+	Duration  time.Duration
+}
+
+// shadow is a candidate with its own summarizer
+type shadow struct {
+	name       string
+	summarizer *Summarizer
+}
+
+// newShadow returns the summarizer of candidate c, sharing the production
+// summarizer's providers, options, history and ledger. Shadows have their
+// own circuit breakers and no cache, routes or translations.
+func (s *Summarizer) newShadow(c ShadowCandidate) (shadow, error) {
+	models, err := parseModelsFor(c.Models, s.backend)
+	if err != nil {
+		return shadow{}, err
+	}
+	prompts := s.prompts
+	if c.PromptsDir != "" {
+		if prompts, err = LoadPrompts(c.PromptsDir); err != nil {
+			return shadow{}, fmt.Errorf("error loading prompts of shadow candidate %s: %w", c.Name(), err)
+		}
+	}
+	return shadow{
+		name: c.Name(),
+		summarizer: &Summarizer{
+			backend:       s.backend,
+			models:        models,
+			providers:     s.providers,
+			prompts:       prompts,
+			guardrails:    s.guardrails,
+			textBudget:    s.textBudget,
+			retry:         s.retry,
+			history:       s.history,
+			relatedWindow: s.relatedWindow,
+			maxRelated:    s.maxRelated,
+			ledger:        s.ledger,
+			prices:        s.prices,
+		},
+	}, nil
+}
+
+// HasShadows reports whether shadow candidates are configured
+func (s *Summarizer) HasShadows() bool {
+	return len(s.shadows) > 0
+}
+
+// Shadow summarizes the PDF with every shadow candidate in turn. Their calls
+// count towards the daily budget; once it is spent no candidates run.
+func (s *Summarizer) Shadow(ctx context.Context, pdfPath string, doc Document) []ShadowResult {
+	if len(s.shadows) == 0 {
+		return nil
+	}
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "Shadow")
+
+	if spent, limit, err := s.Spending(ctx); err == nil && limit > 0 && spent >= limit {
+		ctxLog.Info("Daily budget spent, skipping shadow candidates", "spent", spent, "budget", limit)
+		return nil
+	}
+
+	results := make([]ShadowResult, 0, len(s.shadows))
+	for _, sh := range s.shadows {
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		res, err := sh.summarizer.GenerateSummary(ctx, pdfPath, doc)
+		var rejected *RejectedError
+		if errors.As(err, &rejected) {
+			res = rejected.Result
+		}
+		results = append(results, ShadowResult{Candidate: sh.name, Result: res, Err: err, Duration: time.Since(start)})
+		ctxLog.Debug("Shadow summary generated", "candidate", sh.name, "model", res.Model, "error", err)
+	}
+	return results
+}
@@ -0,0 +1,73 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseShadowCandidates(t *testing.T) {
+	got, err := ParseShadowCandidates(" gemini-3-flash:thinking ; local/llama3.1:8b@/app/prompts-next;", "")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ParseShadowCandidates = %+v, %v", got, err)
+	}
+	if got[0] != (ShadowCandidate{Models: "gemini-3-flash:thinking"}) || got[1] != (ShadowCandidate{Models: "local/llama3.1:8b", PromptsDir: "/app/prompts-next"}) {
+		t.Errorf("ParseShadowCandidates = %+v", got)
+	}
+	if got[1].Name() != "local/llama3.1:8b@/app/prompts-next" {
+		t.Errorf("Name = %q", got[1].Name())
+	}
+	if _, err := ParseShadowCandidates("gemini-2.5-flash:fast", ProviderAIStudio); err == nil {
+		t.Error("ParseShadowCandidates accepted an invalid model list")
+	}
+}
+
+func TestShadow(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	production := &stubProvider{pdf: true, summary: summonsJSON}
+	candidate := &stubProvider{err: errors.New("model not found")}
+	models, _ := parseModels("gemini-2.5-flash")
+	s := &Summarizer{
+		backend:   ProviderVertex,
+		models:    models,
+		providers: map[string]Provider{ProviderVertex: production, ProviderLocal: candidate},
+		prompts:   testPrompts(t),
+		cache:     memoryCache{},
+	}
+	for _, c := range []ShadowCandidate{{Models: "gemini-3-flash:thinking"}, {Models: "local/llama3.1:8b"}} {
+		sh, err := s.newShadow(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.shadows = append(s.shadows, sh)
+	}
+
+	results := s.Shadow(context.Background(), pdfPath, Document{Title: "Doc 4 - Summons"})
+	if len(results) != 2 {
+		t.Fatalf("Shadow = %+v, want a result per candidate", results)
+	}
+	if r := results[0]; r.Err != nil || r.Candidate != "gemini-3-flash:thinking" || r.Result.Model != "vertex/gemini-3-flash" || r.Result.Usage.InputTokens != 100 {
+		t.Errorf("first candidate = %+v", r)
+	}
+	if r := results[1]; r.Err == nil || r.Candidate != "local/llama3.1:8b" {
+		t.Errorf("second candidate = %+v, want its error", r)
+	}
+	if req := production.got[0]; req.Model != "gemini-3-flash" || req.ThinkingLevel != "medium" {
+		t.Errorf("shadow request = %+v, want the candidate's model", req)
+	}
+
+	// Shadows neither use nor fill the production cache
+	if len(s.cache.(memoryCache)) != 0 {
+		t.Errorf("cache = %v, want it untouched", s.cache)
+	}
+	// nor share its circuit breakers
+	if len(s.Status()) != 1 {
+		t.Errorf("Status = %+v, want production models only", s.Status())
+	}
+}
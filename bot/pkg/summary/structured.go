@@ -0,0 +1,135 @@
+package summary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"bot/pkg/scraper"
+)
+
+// Details is the structured response every model is asked for. Summary is
+// the text that gets posted; the rest describes the document for later
+// stages.
+type Details struct {
+	Summary      string   `json:"summary"`
+	DocumentType string   `json:"document_type"` // one of documentTypes
+	Drivers      []string `json:"drivers"`
+	Teams        []string `json:"teams"`
+	CarNumbers   []int    `json:"car_numbers"`
+	Penalty      string   `json:"penalty"` // empty when there is none
+	Session      string   `json:"session"` // e.g. "Qualifying"; empty when unknown
+	Confidence   float64  `json:"confidence"`
+}
+
+// documentTypes are the types a model may detect, as named by
+// scraper.DocumentType
+var documentTypes = []string{
+	scraper.TypeSummons,
+	scraper.TypeDecision,
+	scraper.TypeTechnical,
+	scraper.TypeClassification,
+	scraper.TypeOther,
+}
+
+// maxCarNumber is the highest race number any series allows
+const maxCarNumber = 99
+
+// responseSchema is the JSON schema of Details. Every field is required and
+// none may be added, as OpenAI's strict mode demands; a field that does not
+// apply is an empty string or list.
+var responseSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"summary": map[string]any{
+			"type":        "string",
+			"description": "The 40-60 word summary to post",
+		},
+		"document_type": map[string]any{
+			"type": "string",
+			"enum": documentTypes,
+		},
+		"drivers": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Full names of the drivers involved",
+		},
+		"teams": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Names of the teams involved",
+		},
+		"car_numbers": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "integer"},
+			"description": "Race numbers of the cars involved",
+		},
+		"penalty": map[string]any{
+			"type":        "string",
+			"description": "The penalty imposed, e.g. \"5-second time penalty\"; empty if none",
+		},
+		"session": map[string]any{
+			"type":        "string",
+			"description": "The session concerned, e.g. \"Sprint Qualifying\"; empty if none",
+		},
+		"confidence": map[string]any{
+			"type":        "number",
+			"description": "Confidence in the extracted details, from 0 to 1",
+		},
+	},
+	"required":             []string{"summary", "document_type", "drivers", "teams", "car_numbers", "penalty", "session", "confidence"},
+	"additionalProperties": false,
+}
+
+// parseDetails decodes and validates a model's JSON response. Models without
+// schema support sometimes wrap the JSON in a Markdown code fence, which is
+// removed first.
+func parseDetails(raw string) (Details, error) {
+	raw = strings.TrimSpace(raw)
+	if body, found := strings.CutPrefix(raw, "```"); found {
+		body = strings.TrimPrefix(body, "json")
+		if body, found = strings.CutSuffix(strings.TrimSpace(body), "```"); found {
+			raw = strings.TrimSpace(body)
+		}
+	}
+
+	var d Details
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		return Details{}, fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	d.Summary = strings.TrimSpace(d.Summary)
+	d.Penalty = strings.TrimSpace(d.Penalty)
+	d.Session = strings.TrimSpace(d.Session)
+	d.Drivers = compactNames(d.Drivers)
+	d.Teams = compactNames(d.Teams)
+
+	if d.Summary == "" {
+		return Details{}, errors.New("invalid response: empty summary")
+	}
+	if !slices.Contains(documentTypes, d.DocumentType) {
+		return Details{}, fmt.Errorf("invalid response: unknown document type %q", d.DocumentType)
+	}
+	if d.Confidence < 0 || d.Confidence > 1 {
+		return Details{}, fmt.Errorf("invalid response: confidence %v outside 0-1", d.Confidence)
+	}
+	for _, n := range d.CarNumbers {
+		if n < 0 || n > maxCarNumber {
+			return Details{}, fmt.Errorf("invalid response: car number %d", n)
+		}
+	}
+	return d, nil
+}
+
+// compactNames trims names and drops empty ones
+func compactNames(names []string) []string {
+	var out []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
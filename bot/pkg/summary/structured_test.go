@@ -0,0 +1,52 @@
+package summary
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Details
+		wantErr string
+	}{
+		{
+			name: "valid",
+			raw:  `{"summary": " Norris is summoned. ", "document_type": "summons", "drivers": ["Lando Norris", " "], "teams": ["McLaren"], "car_numbers": [4], "penalty": "", "session": "Qualifying", "confidence": 0.8}`,
+			want: Details{Summary: "Norris is summoned.", DocumentType: "summons", Drivers: []string{"Lando Norris"}, Teams: []string{"McLaren"}, CarNumbers: []int{4}, Session: "Qualifying", Confidence: 0.8},
+		},
+		{
+			name: "code fence",
+			raw:  "```json\n{\"summary\": \"Results.\", \"document_type\": \"classification\", \"confidence\": 1}\n```",
+			want: Details{Summary: "Results.", DocumentType: "classification", Confidence: 1},
+		},
+		{name: "prose", raw: "Norris is summoned.", wantErr: "invalid JSON"},
+		{name: "empty summary", raw: `{"summary": " ", "document_type": "other", "confidence": 0.5}`, wantErr: "empty summary"},
+		{name: "unknown type", raw: `{"summary": "x", "document_type": "memo", "confidence": 0.5}`, wantErr: "unknown document type"},
+		{name: "confidence", raw: `{"summary": "x", "document_type": "other", "confidence": 80}`, wantErr: "confidence"},
+		{name: "car number", raw: `{"summary": "x", "document_type": "other", "car_numbers": [144], "confidence": 0.5}`, wantErr: "car number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDetails(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseDetails error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDetails: %v", err)
+			}
+			if got.Summary != tt.want.Summary || got.DocumentType != tt.want.DocumentType || got.Penalty != tt.want.Penalty ||
+				got.Session != tt.want.Session || got.Confidence != tt.want.Confidence ||
+				!slices.Equal(got.Drivers, tt.want.Drivers) || !slices.Equal(got.Teams, tt.want.Teams) ||
+				!slices.Equal(got.CarNumbers, tt.want.CarNumbers) {
+				t.Errorf("parseDetails = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
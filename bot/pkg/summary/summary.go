@@ -1,53 +1,267 @@
 package summary
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"bot/pkg/logger"
-
-	"google.golang.org/genai"
+	"bot/pkg/utils"
 )
 
 // Package logger
 var log = logger.Package("summary")
 
+// Summarizer generates document summaries by trying each configured model in
+// order until one succeeds. Models may belong to different providers.
 type Summarizer struct {
-	client *genai.Client
-	models []modelEntry
+	backend    string // provider of models without a prefix
+	models     []modelEntry
+	providers  map[string]Provider
+	prompts    *Prompts
+	cache      Cache
+	guardrails Guardrails
+	textBudget int
+	retry      RetryPolicy
+
+	routes []route
+
+	history       History
+	relatedWindow time.Duration
+	maxRelated    int
+
+	languages []string // translation languages, e.g. "es"
+
+	shadows []shadow // candidates run for comparison only
+
+	ledger       Ledger
+	prices       map[string]Price
+	dailyBudget  float64
+	budgetModels []modelEntry // used once dailyBudget is spent
+
+	breakersMu sync.Mutex
+	breakers   map[string]*breaker // keyed by modelEntry.cacheKey
+
+	readyMu sync.Mutex
+	checked time.Time    // when CheckModels last ran
+	checks  []ModelCheck // its results
+}
+
+// Result is a generated summary with the model and prompt that produced it
+type Result struct {
+	Text          string   // Details.Summary, the text to post
+	Details       Details  // everything the model extracted
+	Model         string   // "provider/name"
+	PromptVersion string   // "template@version", see Prompts.Render
+	Route         string   // name of the route that chose the models
+	Input         string   // InputText, InputPDF or InputCache
+	Usage         Usage    // tokens of every model call for the document
+	Cost          float64  // US dollars of every model call for the document
+	Related       []string // URLs of the earlier documents given as context
+
+	// Translations of Text into the configured languages, in their order;
+	// languages that failed are missing
+	Translations []Translation
+}
+
+// What the models were given to summarize
+const (
+	InputText  = "text"  // the PDF's text layer, trimmed to the token budget
+	InputPDF   = "pdf"   // the PDF itself, for scanned documents
+	InputCache = "cache" // nothing: the summary came from the cache
+)
+
+// defaultTextTokenBudget applies when Config.TextTokenBudget is not set
+const defaultTextTokenBudget = 8000
+
+// Cache stores generated summaries keyed by PDF hash, model and prompt
+// version (extended by the related documents, see summaryCacheVersion), so
+// a document that is processed again (a retry after a failed
+// post, a re-listed duplicate) keeps its summary without another model call.
+// The summary stored is the model's JSON response.
+type Cache interface {
+	GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error)
+	PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error
 }
 
 type Config struct {
+	// Backend is the provider of models without a prefix, ProviderVertex
+	// (the default) or ProviderAIStudio
+	Backend string
+
+	// APIKey authenticates Vertex AI in express mode. When AI Studio is the
+	// Backend it authenticates AI Studio instead, unless AIStudioAPIKey is
+	// set.
 	APIKey string
-	// Models is a comma-separated list of Gemini model names in order of
-	// preference. A ":thinking" suffix enables thinking for that model,
-	// e.g. "gemini-3.1-flash-lite:thinking,gemini-2.5-flash-lite".
+	// VertexProject and VertexLocation (default "global") select the Vertex
+	// AI project instead of an API key. The project authenticates with the
+	// service account key in VertexCredentialsFile, or with Application
+	// Default Credentials when it is empty.
+	VertexProject         string
+	VertexLocation        string
+	VertexCredentialsFile string
+	// AIStudioAPIKey authenticates the Gemini API of Google AI Studio
+	AIStudioAPIKey string
+	// OpenAIBaseURL and OpenAIAPIKey select any OpenAI-compatible endpoint
+	OpenAIBaseURL string
+	OpenAIAPIKey  string
+	// LocalURL is the OpenAI-compatible API of a local Ollama or llama.cpp
+	// server
+	LocalURL string
+
+	// Models is a comma-separated list of model names in order of
+	// preference. A "provider/" prefix (vertex, aistudio, openai, local)
+	// selects the provider, Backend by default. A ":thinking" suffix
+	// enables thinking for that model, e.g.
+	// "gemini-3.1-flash-lite:thinking,aistudio/gemini-2.5-flash-lite,local/llama3.1:8b".
 	Models string
+
+	// PromptsDir holds prompt templates overriding the built-in ones (see
+	// Prompts); empty uses the built-in templates only
+	PromptsDir string
+
+	// RoutesFile, if set, is a JSON file of routes choosing models and
+	// options by document (see Route); empty uses Models for everything
+	RoutesFile string
+
+	// History, if set, provides earlier documents referring to the same
+	// incident, which are added to the prompt as context. RelatedWindow is
+	// how far back to look (default 4 days) and MaxRelated how many to add
+	// at most (default 3).
+	History       History
+	RelatedWindow time.Duration
+	MaxRelated    int
+
+	// Languages are codes of the languages to translate summaries into
+	// (see ParseLanguages), e.g. "es"; the route's models translate them
+	Languages []string
+
+	// Shadows are candidates run on every summarized document for
+	// comparison (see Shadow); their summaries are never posted
+	Shadows []ShadowCandidate
+
+	// Cache, if set, reuses summaries of identical PDFs
+	Cache Cache
+
+	// Guardrails are checked on every generated summary
+	Guardrails Guardrails
+
+	// TextTokenBudget caps the document text sent to a model, in estimated
+	// tokens; longer text is cut (default 8000)
+	TextTokenBudget int
+
+	// Retry controls retries and circuit breakers per model
+	Retry RetryPolicy
+
+	// Ledger, if set, records every call's tokens and cost per document and
+	// model
+	Ledger Ledger
+
+	// Prices are keyed by model as written in the model list, with or
+	// without provider prefix (see ParsePrices); models without a price
+	// cost nothing
+	Prices map[string]Price
+
+	// DailyBudget caps a UTC day's cost in US dollars (0 disables it; it
+	// needs Ledger). Once spent, BudgetModels are tried instead of Models,
+	// or documents are posted without a summary if there are none.
+	DailyBudget  float64
+	BudgetModels string
+}
+
+// RetryPolicy controls how often a model is retried and when its circuit
+// breaker skips it
+type RetryPolicy struct {
+	// Attempts is the most calls per model and document (default 3)
+	Attempts int
+	// BaseDelay is the first backoff, doubled per retry (default 2s); a
+	// Retry-After from the server takes precedence
+	BaseDelay time.Duration
+	// BreakerThreshold consecutive failures open a model's breaker (0
+	// never opens it)
+	BreakerThreshold int
+	// BreakerCooldown is how long an open breaker skips the model
+	BreakerCooldown time.Duration
+}
+
+// maxRetryWait is the longest a retry waits for the same model; a longer
+// Retry-After moves on to the next model
+const maxRetryWait = time.Minute
+
+// backoff returns the delay before retry attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := cmp.Or(p.BaseDelay, 2*time.Second)
+	for range attempt - 1 {
+		delay *= 2
+	}
+	return min(delay, maxRetryWait)
 }
 
 type modelEntry struct {
+	provider    string
 	name        string
 	useThinking bool
 }
 
-// parseModels parses a comma-separated model list (see Config.Models).
+// String returns the entry as written in the model list, without suffix
+func (m modelEntry) String() string {
+	return m.provider + "/" + m.name
+}
+
+// cacheKey identifies the model in the summary cache; thinking changes the
+// output, so it is part of the key
+func (m modelEntry) cacheKey() string {
+	if m.useThinking {
+		return m.String() + ":thinking"
+	}
+	return m.String()
+}
+
+// parseModels parses a comma-separated model list (see Config.Models) of
+// models on Vertex AI by default
 func parseModels(s string) ([]modelEntry, error) {
+	return parseModelsFor(s, ProviderVertex)
+}
+
+// parseModelsFor parses a comma-separated model list whose models without a
+// prefix are served by backend
+func parseModelsFor(s, backend string) ([]modelEntry, error) {
 	var models []modelEntry
 	for entry := range strings.SplitSeq(s, ",") {
 		entry = strings.TrimSpace(entry)
 		if entry == "" {
 			continue
 		}
-		name, suffix, found := strings.Cut(entry, ":")
-		model := modelEntry{name: name}
-		if found {
-			if suffix != "thinking" {
+
+		model := modelEntry{provider: backend, name: entry}
+		if prefix, rest, found := strings.Cut(entry, "/"); found && isProvider(prefix) {
+			model.provider, model.name = prefix, rest
+		}
+
+		if name, found := strings.CutSuffix(model.name, ":thinking"); found {
+			model.name, model.useThinking = name, true
+		}
+
+		// Gemini model names never contain a colon, so anything after one is
+		// a mistyped suffix. Other providers use colons for tags
+		// ("llama3.1:8b").
+		if isGemini(model.provider) {
+			if _, suffix, found := strings.Cut(model.name, ":"); found {
 				return nil, fmt.Errorf("invalid model entry %q: unknown suffix %q (only \":thinking\" is supported)", entry, suffix)
 			}
-			model.useThinking = true
+		}
+		if model.name == "" {
+			return nil, fmt.Errorf("invalid model entry %q: missing model name", entry)
 		}
 		models = append(models, model)
 	}
@@ -57,32 +271,127 @@ func parseModels(s string) ([]modelEntry, error) {
 	return models, nil
 }
 
-// New creates a new instance of Summarizer
+// New creates a new instance of Summarizer with a client for each provider
+// in the model list
 func New(cfg Config) (*Summarizer, error) {
 	ctxLog := log.WithContext("method", "New")
 
-	models, err := parseModels(cfg.Models)
+	backend := cmp.Or(cfg.Backend, ProviderVertex)
+	if !isGemini(backend) {
+		return nil, fmt.Errorf("invalid backend %q: want %s or %s", cfg.Backend, ProviderVertex, ProviderAIStudio)
+	}
+
+	models, err := parseModelsFor(cfg.Models, backend)
 	if err != nil {
-		ctxLog.Error("Invalid Gemini model list", "models", cfg.Models, "error", err)
-		return nil, fmt.Errorf("invalid Gemini model list %q: %w", cfg.Models, err)
+		ctxLog.Error("Invalid model list", "models", cfg.Models, "error", err)
+		return nil, fmt.Errorf("invalid model list %q: %w", cfg.Models, err)
+	}
+
+	var budgetModels []modelEntry
+	if strings.TrimSpace(cfg.BudgetModels) != "" {
+		if budgetModels, err = parseModelsFor(cfg.BudgetModels, backend); err != nil {
+			ctxLog.Error("Invalid budget model list", "models", cfg.BudgetModels, "error", err)
+			return nil, fmt.Errorf("invalid budget model list %q: %w", cfg.BudgetModels, err)
+		}
+	}
+
+	var routes []route
+	if cfg.RoutesFile != "" {
+		loaded, err := LoadRoutes(cfg.RoutesFile)
+		if err != nil {
+			ctxLog.Error("Error loading routes", "file", cfg.RoutesFile, "error", err)
+			return nil, err
+		}
+		for _, r := range loaded {
+			// LoadRoutes validated the route
+			chain := models
+			if !r.Skip && strings.TrimSpace(r.Models) != "" {
+				chain, _ = parseModelsFor(r.Models, backend)
+			}
+			routes = append(routes, route{Route: r, models: chain})
+		}
 	}
 
-	ctx := context.Background()
-	ctxLog.Info("Creating Vertex AI client")
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  cfg.APIKey,
-		Backend: genai.BackendVertexAI,
-	})
+	for _, lang := range cfg.Languages {
+		if _, ok := languageNames[lang]; !ok {
+			return nil, fmt.Errorf("unsupported language %q", lang)
+		}
+	}
+
+	prompts, err := LoadPrompts(cfg.PromptsDir)
 	if err != nil {
-		ctxLog.Error("Error creating Vertex AI client", "error", err)
-		return nil, fmt.Errorf("error creating Vertex AI client: %w", err)
+		ctxLog.Error("Error loading prompt templates", "dir", cfg.PromptsDir, "error", err)
+		return nil, fmt.Errorf("error loading prompt templates: %w", err)
+	}
+
+	providers := make(map[string]Provider)
+	all := slices.Concat(models, budgetModels)
+	for _, r := range routes {
+		all = append(all, r.models...)
+	}
+	for _, c := range cfg.Shadows {
+		shadowModels, err := parseModelsFor(c.Models, backend)
+		if err != nil {
+			ctxLog.Error("Invalid shadow candidate", "candidate", c.Name(), "error", err)
+			return nil, fmt.Errorf("invalid shadow candidate %q: %w", c.Name(), err)
+		}
+		all = append(all, shadowModels...)
+	}
+	for _, model := range all {
+		if _, ok := providers[model.provider]; ok {
+			continue
+		}
+		provider, err := newProvider(model.provider, cfg)
+		if err != nil {
+			ctxLog.Error("Error creating summarization provider", "provider", model.provider, "error", err)
+			return nil, err
+		}
+		providers[model.provider] = provider
+	}
+
+	s := &Summarizer{
+		backend:   backend,
+		models:    models,
+		providers: providers,
+		prompts:   prompts,
+		routes:    routes,
+		cache:     cfg.Cache,
+
+		history:       cfg.History,
+		relatedWindow: cmp.Or(cfg.RelatedWindow, defaultRelatedWindow),
+		maxRelated:    cmp.Or(cfg.MaxRelated, defaultMaxRelated),
+		languages:     cfg.Languages,
+
+		guardrails: cfg.Guardrails,
+		textBudget: cmp.Or(cfg.TextTokenBudget, defaultTextTokenBudget),
+		retry: RetryPolicy{
+			Attempts:         cmp.Or(cfg.Retry.Attempts, 3),
+			BaseDelay:        cmp.Or(cfg.Retry.BaseDelay, 2*time.Second),
+			BreakerThreshold: cfg.Retry.BreakerThreshold,
+			BreakerCooldown:  cfg.Retry.BreakerCooldown,
+		},
+		ledger:       cfg.Ledger,
+		prices:       cfg.Prices,
+		dailyBudget:  cfg.DailyBudget,
+		budgetModels: budgetModels,
+	}
+	for _, c := range cfg.Shadows {
+		sh, err := s.newShadow(c)
+		if err != nil {
+			ctxLog.Error("Error creating shadow candidate", "candidate", c.Name(), "error", err)
+			return nil, err
+		}
+		s.shadows = append(s.shadows, sh)
 	}
 
-	ctxLog.Info("Summarizer initialized successfully", "models", cfg.Models)
-	return &Summarizer{
-		client: client,
-		models: models,
-	}, nil
+	ctxLog.Info("Summarizer initialized successfully", "models", cfg.Models, "shadows", len(s.shadows))
+	return s, nil
+}
+
+// ReloadPrompts re-reads the prompt templates; on error the current ones stay
+// in use
+func (s *Summarizer) ReloadPrompts() error {
+	return s.prompts.Reload()
 }
 
 // Close closes the client
@@ -92,8 +401,15 @@ func (s *Summarizer) Close() {
 }
 
 // GenerateSummary generates a summary for the given PDF file
-// with fallback to alternative models if the primary model fails
-func (s *Summarizer) GenerateSummary(ctx context.Context, pdfPath string) (string, error) {
+// with fallback to alternative models if the primary model fails or its
+// summary fails the guardrails. The prompt template is chosen by doc's type
+// and series. Models get the PDF's text when it has a usable text layer and
+// the PDF itself only when it does not. If no model produced an acceptable
+// summary and at least one was rejected, the error is a *RejectedError. Once
+// the daily budget is spent the budget models are tried instead; without any
+// the error is ErrBudgetExhausted. An accepted summary is then translated
+// into the configured languages.
+func (s *Summarizer) GenerateSummary(ctx context.Context, pdfPath string, doc Document) (Result, error) {
 	ctxLog := log.WithRequestContext(ctx).
 		WithContext("method", "GenerateSummary").
 		WithContext("pdfPath", pdfPath)
@@ -102,110 +418,373 @@ func (s *Summarizer) GenerateSummary(ctx context.Context, pdfPath string) (strin
 	pdfData, err := os.ReadFile(pdfPath)
 	if err != nil {
 		ctxLog.Error("Error reading PDF file", "error", err)
-		return "", fmt.Errorf("error reading PDF file: %w", err)
+		return Result{}, fmt.Errorf("error reading PDF file: %w", err)
 	}
 	ctxLog.Debug("PDF file read successfully", "bytes", len(pdfData))
 
+	system, promptVersion, err := s.prompts.Render(doc)
+	if err != nil {
+		ctxLog.Error("Error rendering prompt", "type", doc.Type, "series", doc.Series, "error", err)
+		return Result{}, err
+	}
+	ctxLog.Debug("Prompt selected", "prompt_version", promptVersion)
+
+	// The text layer is much cheaper to send than the PDF and also serves
+	// the fact checks. Scanned documents have none (pageText stays empty).
+	input := InputPDF
+	pageText, pages, err := documentText(ctx, pdfPath, doc.Text)
+	var text string
+	if err == nil {
+		input = InputText
+		var truncated bool
+		text, truncated = trimToBudget(pageText, s.textBudget)
+		ctxLog.Debug("Using document text", "estimated_tokens", estimateTokens(text), "truncated", truncated)
+	} else {
+		pageText = ""
+		ctxLog.Info("Document has no usable text, sending the PDF", "reason", err)
+	}
+
+	rt := s.route(doc, pages)
+	if rt.Skip {
+		ctxLog.Info("Route skips summary", "route", rt.Name, "pages", pages)
+		return Result{}, ErrSkipped
+	}
+	ctxLog.Debug("Route selected", "route", rt.Name, "pages", pages)
+
+	// Related documents are found from the text layer and title; a scan is
+	// matched by its title alone
+	related := s.relatedDocuments(ctx, doc, pageText)
+	var relatedURLs []string
+	for _, r := range related {
+		relatedURLs = append(relatedURLs, r.URL)
+	}
+
+	sum := sha256.Sum256(pdfData)
+	pdfHash := hex.EncodeToString(sum[:])
+	cacheVersion := summaryCacheVersion(promptVersion, relatedURLs)
+	if res, ok := s.cachedSummary(ctx, pdfHash, promptVersion, cacheVersion, rt.models); ok {
+		ctxLog.Info("Using cached AI summary", "model", res.Model, "prompt_version", promptVersion, "length", len(res.Text))
+		res.Route, res.Related = rt.Name, relatedURLs
+		res.Translations, res.Usage, res.Cost = s.translate(ctx, doc, rt, pdfHash, res.Text)
+		return res, nil
+	}
+	prompt := userPrompt + relatedContext(related)
+
+	models, err := s.activeModels(ctx, rt.models)
+	if err != nil {
+		ctxLog.Warn("Daily budget spent, skipping summary")
+		return Result{}, err
+	}
+
 	// Try each model in order of priority
 	var lastError error
-	for _, model := range s.models {
-		ctxLog.Debug("Attempting to generate summary", "model", model.name)
-		summary, err := s.tryGenerateSummaryWithModel(ctx, model, pdfData)
+	var rejected *RejectedError
+	var usage Usage
+	var cost float64
+	for _, model := range models {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		provider := s.providers[model.provider]
+		req := rt.request(model)
+		req.System, req.Prompt, req.Schema = system, prompt, responseSchema
+		if input == InputText {
+			req.Text = text
+		} else if provider.AcceptsPDF() {
+			req.PDF = pdfData
+		} else {
+			lastError = fmt.Errorf("model %s cannot read PDFs and the document has no usable text", model)
+			ctxLog.Warn("Skipping text-only model", "model", model.String())
+			continue
+		}
+
+		b := s.breaker(model)
+		if !b.allow(time.Now()) {
+			lastError = fmt.Errorf("model %s skipped: circuit breaker open", model)
+			ctxLog.Debug("Skipping model with open circuit breaker", "model", model.String())
+			continue
+		}
+
+		ctxLog.Debug("Attempting to generate summary", "model", model.String(), "input", input)
+		resp, err := s.summarizeWithRetry(ctx, provider, b, model, doc, req, &usage, &cost)
+		var details Details
+		if err == nil {
+			// A response that does not match the schema counts as a failure
+			// of this model
+			details, err = parseDetails(resp.Text)
+		}
+		if err == nil {
+			if err = s.guardrails.check(details, pageText); err != nil {
+				var r *rejection
+				if errors.As(err, &r) {
+					rejected = &RejectedError{
+						Result: Result{Text: details.Summary, Details: details, Model: model.String(), PromptVersion: promptVersion, Route: rt.Name, Input: input, Related: relatedURLs},
+						Reason: r.reason,
+					}
+				}
+			}
+		}
 		if err == nil {
 			// Success with this model
-			ctxLog.Info("AI summary generated successfully", "model", model.name, "length", len(summary))
-			return summary, nil
+			ctxLog.Info("AI summary generated successfully",
+				"model", model.String(),
+				"prompt_version", promptVersion,
+				"route", rt.Name,
+				"related", len(related),
+				"length", len(details.Summary),
+				"document_type", details.DocumentType,
+				"confidence", details.Confidence,
+				"input", input,
+				"input_tokens", usage.InputTokens,
+				"output_tokens", usage.OutputTokens,
+				"thinking_tokens", usage.ThinkingTokens,
+				"cost", cost)
+			if s.cache != nil {
+				if err := s.cache.PutSummary(ctx, pdfHash, model.cacheKey(), cacheVersion, resp.Text); err != nil {
+					ctxLog.Warn("Error caching summary", "error", err)
+				}
+			}
+			translations, translationUsage, translationCost := s.translate(ctx, doc, rt, pdfHash, details.Summary)
+			return Result{
+				Text:          details.Summary,
+				Details:       details,
+				Model:         model.String(),
+				PromptVersion: promptVersion,
+				Route:         rt.Name,
+				Input:         input,
+				Related:       relatedURLs,
+				Usage:         usage.Add(translationUsage),
+				Cost:          cost + translationCost,
+				Translations:  translations,
+			}, nil
 		}
 
 		lastError = err
-		ctxLog.Warn("Failed to generate summary with model", "model", model.name, "error", err)
+		ctxLog.Warn("Failed to generate summary with model", "model", model.String(), "error", err)
+	}
 
-		// Add a small delay before trying the next model
-		time.Sleep(500 * time.Millisecond)
+	if rejected != nil {
+		rejected.Result.Usage, rejected.Result.Cost = usage, cost
+		ctxLog.Warn("No summary passed the guardrails", "model", rejected.Result.Model, "reason", rejected.Reason)
+		return Result{}, rejected
 	}
 
 	ctxLog.Error("All models failed to generate summary", "lastError", lastError)
-	return "", fmt.Errorf("all models failed to generate summary, last error: %w", lastError)
+	return Result{}, fmt.Errorf("all models failed to generate summary, last error: %w", lastError)
 }
 
-// tryGenerateSummaryWithModel attempts to generate a summary with the specified model
-func (s *Summarizer) tryGenerateSummaryWithModel(ctx context.Context, model modelEntry, pdfData []byte) (string, error) {
+// summarizeWithRetry calls the model, retrying errors of a retryable kind
+// with exponential backoff or after the delay the server asked for. A delay
+// longer than maxRetryWait moves on to the next model instead. The call's
+// outcome is recorded on the model's breaker and in the ledger, and its
+// tokens and cost added to usage and cost. A call stopped by ctx records no
+// outcome and releases the breaker's trial.
+func (s *Summarizer) summarizeWithRetry(ctx context.Context, provider Provider, b *breaker, model modelEntry, doc Document, req Request, usage *Usage, cost *float64) (Response, error) {
 	ctxLog := log.WithRequestContext(ctx).
-		WithContext("method", "tryGenerateSummaryWithModel").
-		WithContext("model", model.name)
-
-	ctxLog.Debug("Creating model config")
-	config := createModelConfig(model)
-
-	ctxLog.Debug("Creating chat session with inline PDF")
-	// Send PDF as inline data (Vertex AI does not support the Files API)
-	history := []*genai.Content{
-		{
-			Role: genai.RoleUser,
-			Parts: []*genai.Part{
-				{
-					InlineData: &genai.Blob{
-						Data:     pdfData,
-						MIMEType: "application/pdf",
-					},
-				},
-			},
-		},
-	}
+		WithContext("method", "summarizeWithRetry").
+		WithContext("model", model.String())
+
+	attempts := max(s.retry.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		resp, err := provider.Summarize(ctx, req)
+		*usage = usage.Add(resp.Usage)
+		*cost += s.recordUsage(ctx, doc, model, resp.Usage)
+		if err == nil {
+			b.success()
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			b.release()
+			return Response{}, ctx.Err()
+		}
 
-	chat, err := s.client.Chats.Create(ctx, model.name, config, history)
-	if err != nil {
-		ctxLog.Error("Error creating chat session", "error", err)
-		return "", fmt.Errorf("error creating chat session with model %s: %w", model.name, err)
-	}
+		kind, retryAfter := errorKind(err)
+		delay := retryAfter
+		if delay == 0 {
+			delay = s.retry.backoff(attempt)
+		}
+		if !kind.retryable() || attempt >= attempts || delay > maxRetryWait {
+			b.failure(kind, retryAfter, time.Now())
+			return Response{}, err
+		}
 
-	ctxLog.Debug("Sending message to model")
-	resp, err := chat.Send(ctx, genai.NewPartFromText("Please provide a summary of this document"))
-	if err != nil {
-		ctxLog.Error("Error generating summary", "error", err)
-		return "", fmt.Errorf("error generating summary with model %s: %w", model.name, err)
+		ctxLog.Warn("Retrying model", "kind", kind, "attempt", attempt, "delay", delay, "error", err)
+		if err := sleepContext(ctx, delay); err != nil {
+			b.release()
+			return Response{}, err
+		}
 	}
+}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		ctxLog.Error("No summary generated", "candidates", len(resp.Candidates))
-		return "", fmt.Errorf("no summary generated by model %s", model.name)
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
 
-	// Extract text from the response
-	part := resp.Candidates[0].Content.Parts[0]
-	if part.Text != "" {
-		return part.Text, nil
+// breaker returns the circuit breaker of model
+func (s *Summarizer) breaker(model modelEntry) *breaker {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	if s.breakers == nil {
+		s.breakers = make(map[string]*breaker)
+	}
+	b, ok := s.breakers[model.cacheKey()]
+	if !ok {
+		b = newBreaker(s.retry.BreakerThreshold, s.retry.BreakerCooldown)
+		s.breakers[model.cacheKey()] = b
 	}
+	return b
+}
 
-	return fmt.Sprintf("%v", part), nil
+// allModels returns the model list followed by the route and budget models
+// not in it
+func (s *Summarizer) allModels() []modelEntry {
+	models := s.models
+	for _, r := range s.routes {
+		models = appendNew(models, r.models...)
+	}
+	return appendNew(models, s.budgetModels...)
 }
 
-// createModelConfig creates the model configuration with optimal settings
-func createModelConfig(model modelEntry) *genai.GenerateContentConfig {
-	temperature := float32(0.7)
-	maxTokens := int32(8192)
+// appendNew appends the entries not yet in models to a copy of it
+func appendNew(models []modelEntry, entries ...modelEntry) []modelEntry {
+	for _, model := range entries {
+		if !slices.Contains(models, model) {
+			models = append(slices.Clip(models), model)
+		}
+	}
+	return models
+}
 
-	systemInstruction := `You are a concise Formula 1 news bot posting to Threads. Based on the attached FIA document, generate a 40–60 word summary.
+// Status returns the breaker state and error counts of each model, in
+// model list order followed by the route and budget models
+func (s *Summarizer) Status() []ModelStatus {
+	now := time.Now()
+	models := s.allModels()
+	statuses := make([]ModelStatus, 0, len(models))
+	for _, model := range models {
+		statuses = append(statuses, s.breaker(model).status(model.cacheKey(), now))
+	}
+	return statuses
+}
 
-First, identify the document type:
-- **Stewards Decision**: Summarize the specific penalty, reprimand, or finding. Include the driver/team involved, the infringement, and the outcome. If the stewards investigated but took no further action, state that clearly.
-- **Classification / Timing Sheet**: Summarize who topped the session, their time, notable gaps, and total laps completed by the field etc.
-- **Technical Directive / Regulation Update**: Summarize the rule change or clarification and which teams or components it affects.
-- **Other**: Summarize the key factual content.
+// summaryCacheVersion is the version a summary is cached under: its prompt
+// version and, when related documents were added to the prompt, a hash of
+// their URLs. A summary is thus only reused with the related documents it was
+// written with, which a cache hit reports. Summaries without related
+// documents are cached under the prompt version alone.
+func summaryCacheVersion(promptVersion string, relatedURLs []string) string {
+	if len(relatedURLs) == 0 {
+		return promptVersion
+	}
+	sum := sha256.Sum256([]byte(strings.Join(slices.Sorted(slices.Values(relatedURLs)), "\n")))
+	return promptVersion + "+related:" + hex.EncodeToString(sum[:8])
+}
 
-Keep the tone neutral and factual. Do not include the document type in the summary. Do not add commentary, speculation, or explanation beyond the summary. Do not use hashtags or emojis.`
+// cachedSummary returns a cached summary of the PDF under cacheVersion (see
+// summaryCacheVersion) by the most preferred of models that has one, budget
+// models included. Cache errors and entries that no longer parse count as
+// misses: a summary can always be generated again.
+func (s *Summarizer) cachedSummary(ctx context.Context, pdfHash, promptVersion, cacheVersion string, models []modelEntry) (Result, bool) {
+	if s.cache == nil {
+		return Result{}, false
+	}
 
-	config := &genai.GenerateContentConfig{
-		SystemInstruction: genai.NewContentFromText(systemInstruction, genai.RoleUser),
-		Temperature:       &temperature,
-		MaxOutputTokens:   maxTokens,
+	for _, model := range appendNew(models, s.budgetModels...) {
+		summary, ok, err := s.cache.GetSummary(ctx, pdfHash, model.cacheKey(), cacheVersion)
+		if err != nil {
+			log.WithRequestContext(ctx).
+				WithContext("method", "cachedSummary").
+				Warn("Error reading summary cache", "error", err)
+			return Result{}, false
+		}
+		if !ok {
+			continue
+		}
+		details, err := parseDetails(summary)
+		if err != nil {
+			log.WithRequestContext(ctx).
+				WithContext("method", "cachedSummary").
+				Warn("Ignoring invalid cached summary", "model", model.String(), "error", err)
+			continue
+		}
+		return Result{Text: details.Summary, Details: details, Model: model.String(), PromptVersion: promptVersion, Input: InputCache}, true
 	}
+	return Result{}, false
+}
 
-	if model.useThinking {
-		config.ThinkingConfig = &genai.ThinkingConfig{
-			ThinkingLevel: genai.ThinkingLevelMedium,
+// minUsableText is the fewest non-space characters a text layer needs to be
+// summarized; scans often carry little more than a stamped header
+const minUsableText = 200
+
+// documentText returns the PDF's text, extracting it unless the caller
+// already did (text is non-empty), and counts its pages. Scanned documents
+// without a usable text layer return an error along with the page count; a
+// PDF that cannot be read has 0 pages.
+func documentText(ctx context.Context, pdfPath, text string) (string, int, error) {
+	if text == "" {
+		var err error
+		if text, err = utils.ExtractText(ctx, pdfPath); err != nil {
+			return "", 0, err
+		}
+	}
+	// Pages are separated by form feeds
+	pages := strings.Count(text, "\f") + 1
+	chars := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			chars++
 		}
 	}
+	if chars < minUsableText {
+		return "", pages, fmt.Errorf("document has no usable text layer (%d characters)", chars)
+	}
+	return text, pages, nil
+}
+
+// charsPerToken is the rough size of a token in English text
+const charsPerToken = 4
+
+// estimateTokens estimates the tokens of text
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
 
-	return config
+// trimToBudget cuts text to about budget tokens, at a line break where
+// possible, and reports whether it was cut. The first pages of long timing
+// sheets carry what a summary needs. A summarizer from New always has a
+// positive budget (config rejects a SUMMARY_TEXT_TOKEN_BUDGET below 1 and an
+// unset TextTokenBudget takes the default); a budget of 0 only occurs in
+// summarizers built directly, and keeps all text.
+func trimToBudget(text string, budget int) (string, bool) {
+	limit := budget * charsPerToken
+	if budget <= 0 || utf8.RuneCountInString(text) <= limit {
+		return text, false
+	}
+	runes := []rune(text)
+	cut := string(runes[:limit])
+	if i := strings.LastIndexAny(cut, "\n\f"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return cut + "\n[Document truncated]", true
 }
+
+// userPrompt asks for the summary of the attached document. The fields are
+// spelled out as well for servers that ignore the response schema.
+const userPrompt = `Please provide a summary of this document as a JSON object with these fields:
+- "summary": the summary to post
+- "document_type": one of "summons", "decision", "technical", "classification" or "other"
+- "drivers", "teams": the full names of the drivers and teams involved
+- "car_numbers": the race numbers of the cars involved
+- "penalty": the penalty imposed, or "" if none
+- "session": the session concerned, e.g. "Race", or "" if none
+- "confidence": how confident you are in these details, from 0 to 1
+Use empty lists for anything the document does not mention. Respond with the JSON object only.`
@@ -1,6 +1,17 @@
 package summary
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestParseModels(t *testing.T) {
 	models, err := parseModels("gemini-3.1-flash-lite-preview:thinking, gemini-3.1-flash-lite:thinking,gemini-2.5-flash-lite")
@@ -24,3 +35,368 @@ func TestParseModels(t *testing.T) {
 		t.Error("unknown suffix should error")
 	}
 }
+
+func TestParseModelsProviders(t *testing.T) {
+	tests := []struct {
+		entry    string
+		provider string
+		name     string
+		thinking bool
+		wantErr  bool
+	}{
+		{"gemini-2.5-flash-lite", ProviderVertex, "gemini-2.5-flash-lite", false, false},
+		{"aistudio/gemini-2.5-flash:thinking", ProviderAIStudio, "gemini-2.5-flash", true, false},
+		{"openai/gpt-4.1-mini", ProviderOpenAI, "gpt-4.1-mini", false, false},
+		{"openai/meta-llama/llama-3.3-70b", ProviderOpenAI, "meta-llama/llama-3.3-70b", false, false},
+		{"local/llama3.1:8b", ProviderLocal, "llama3.1:8b", false, false},
+		{"local/qwen3:8b:thinking", ProviderLocal, "qwen3:8b", true, false},
+		{"publishers/google/gemini-2.5-flash", ProviderVertex, "publishers/google/gemini-2.5-flash", false, false},
+		{"aistudio/gemini-2.5-flash:fast", "", "", false, true},
+		{"local/", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			models, err := parseModels(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseModels(%q) = %+v, want error", tt.entry, models)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseModels(%q): %v", tt.entry, err)
+			}
+			got := models[0]
+			if got.provider != tt.provider || got.name != tt.name || got.useThinking != tt.thinking {
+				t.Errorf("parseModels(%q) = %+v, want %s/%s thinking=%t", tt.entry, got, tt.provider, tt.name, tt.thinking)
+			}
+		})
+	}
+}
+
+func TestNewRequiresProviderCredentials(t *testing.T) {
+	if _, err := New(Config{Models: "gemini-2.5-flash-lite"}); err == nil || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("New without a Vertex key = %v, want a GEMINI_API_KEY error", err)
+	}
+	if _, err := New(Config{Models: "aistudio/gemini-2.5-flash"}); err == nil || !strings.Contains(err.Error(), "AISTUDIO_API_KEY") {
+		t.Errorf("New without an AI Studio key = %v, want an AISTUDIO_API_KEY error", err)
+	}
+	if _, err := New(Config{Models: "local/llama3.1", LocalURL: "http://localhost:11434/v1"}); err != nil {
+		t.Errorf("New with a local model = %v, want no credentials needed", err)
+	}
+
+	// With the AI Studio backend unprefixed models use GEMINI_API_KEY there
+	s, err := New(Config{Models: "gemini-2.5-flash-lite", Backend: ProviderAIStudio, APIKey: "key"})
+	if err != nil {
+		t.Fatalf("New with the AI Studio backend: %v", err)
+	}
+	if _, ok := s.providers[ProviderAIStudio]; !ok || s.models[0].provider != ProviderAIStudio {
+		t.Errorf("models %v use providers %v, want AI Studio", s.models, s.providers)
+	}
+	if _, err := New(Config{Models: "gemini-2.5-flash-lite", Backend: ProviderLocal}); err == nil {
+		t.Error("New accepted a backend that does not serve Gemini")
+	}
+}
+
+// fakeChatServer answers chat completions for model "good" and fails the rest
+func fakeChatServer(t *testing.T, requests *[]chatRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*requests = append(*requests, req)
+		if req.Model != "good" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"message": "model not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"summary\": \"Verstappen gets a 5-second penalty.\", \"document_type\": \"decision\", \"drivers\": [\"Max Verstappen\"], \"teams\": [], \"car_numbers\": [1], \"penalty\": \"5-second time penalty\", \"session\": \"Race\", \"confidence\": 0.9}"}}], "usage": {"prompt_tokens": 120, "completion_tokens": 45}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	var requests []chatRequest
+	srv := fakeChatServer(t, &requests)
+	provider := newOpenAIProvider(srv.URL+"/v1", "secret")
+
+	resp, err := provider.Summarize(context.Background(), Request{
+		Model: "good", System: "system", Prompt: "Summarize", Text: "Decision text", ThinkingLevel: "high", MaxTokens: 2048, Schema: responseSchema,
+	})
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if details, err := parseDetails(resp.Text); err != nil || details.Penalty != "5-second time penalty" {
+		t.Fatalf("parseDetails(%q) = %+v, %v", resp.Text, details, err)
+	}
+	if resp.Usage != (Usage{InputTokens: 120, OutputTokens: 45}) {
+		t.Errorf("usage = %+v, want the server's token counts", resp.Usage)
+	}
+	req := requests[0]
+	if req.Messages[0].Content != "system" || !strings.Contains(req.Messages[1].Content, "Decision text") {
+		t.Errorf("request messages = %+v, want the system prompt and document text", req.Messages)
+	}
+	if req.ReasoningEffort != "high" || req.Temperature != nil || req.MaxTokens != 2048 {
+		t.Errorf("thinking request = %+v, want the request's reasoning_effort and max_tokens and no temperature", req)
+	}
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.JSONSchema.Schema["required"] == nil {
+		t.Errorf("response_format = %+v, want the JSON schema", req.ResponseFormat)
+	}
+
+	_, err = provider.Summarize(context.Background(), Request{Model: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("Summarize with unknown model = %v, want the server's error message", err)
+	}
+}
+
+// pdfWithText builds a one-page PDF with a line of text per argument;
+// MuPDF rebuilds the missing cross-reference table
+func pdfWithText(lines ...string) string {
+	var content strings.Builder
+	content.WriteString("BT /F1 8 Tf 10 580 Td 10 TL")
+	for _, line := range lines {
+		fmt.Fprintf(&content, " (%s) Tj T*", line)
+	}
+	content.WriteString(" ET")
+
+	return fmt.Sprintf(`%%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 600 600] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >> endobj
+4 0 obj << /Length %d >> stream
+%s
+endstream endobj
+5 0 obj << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >> endobj
+trailer << /Root 1 0 R >>
+%%%%EOF
+`, content.Len(), content.String())
+}
+
+// minimalPDF is a decision with a text layer long enough to be summarized
+// from text
+var minimalPDF = pdfWithText(
+	"FIA Formula One World Championship - Decision - Car 1 Max Verstappen",
+	"The Stewards, having received a report from the Race Director, have considered the following matter",
+	"and determine the infringement warrants a 5 second penalty. The driver and team representative were heard.",
+)
+
+// scannedPDF has no usable text layer, like a scanned document
+var scannedPDF = pdfWithText("Page 1 of 1")
+
+func TestGenerateSummaryFallsBackAcrossModels(t *testing.T) {
+	var requests []chatRequest
+	srv := fakeChatServer(t, &requests)
+
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{Models: "local/missing,local/good", LocalURL: srv.URL + "/v1/"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || summary.Text != "Verstappen gets a 5-second penalty." || summary.Model != "local/good" ||
+		summary.Details.DocumentType != "decision" || len(summary.Details.CarNumbers) != 1 {
+		t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+	}
+	if len(requests) != 2 || !strings.Contains(requests[1].Messages[1].Content, "Car 1 Max Verstappen") {
+		t.Errorf("requests = %+v, want a failed attempt then one with the PDF text", requests)
+	}
+}
+
+// summonsJSON is a valid model response
+const summonsJSON = `{"summary": "Summons issued.", "document_type": "summons", "drivers": ["Max Verstappen"], "teams": ["Red Bull Racing"], "car_numbers": [1], "penalty": "", "session": "Qualifying", "confidence": 0.8}`
+
+// stubProvider returns a fixed result and records the requests it gets
+type stubProvider struct {
+	pdf     bool
+	summary string
+	err     error
+	got     []Request
+}
+
+func (p *stubProvider) AcceptsPDF() bool { return p.pdf }
+
+func (p *stubProvider) Summarize(ctx context.Context, req Request) (Response, error) {
+	p.got = append(p.got, req)
+	return Response{Text: p.summary, Usage: Usage{InputTokens: 100, OutputTokens: 20}}, p.err
+}
+
+func TestGenerateSummaryMixesProviders(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gemini := &stubProvider{pdf: true, err: errors.New("quota exhausted")}
+	local := &stubProvider{summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash:thinking,local/llama3.1:8b")
+	s := &Summarizer{models: models, providers: map[string]Provider{ProviderVertex: gemini, ProviderLocal: local}, prompts: testPrompts(t)}
+
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || summary.Text != "Summons issued." || summary.Input != InputText {
+		t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+	}
+	if summary.Usage != (Usage{InputTokens: 200, OutputTokens: 40}) {
+		t.Errorf("usage = %+v, want both calls counted", summary.Usage)
+	}
+	if len(gemini.got) != 1 || gemini.got[0].PDF != nil || !strings.Contains(gemini.got[0].Text, "Verstappen") || gemini.got[0].ThinkingLevel != "medium" {
+		t.Errorf("vertex request = %+v, want the text with thinking", gemini.got)
+	}
+	if len(local.got) != 1 || local.got[0].Model != "llama3.1:8b" || local.got[0].PDF != nil {
+		t.Errorf("local request = %+v, want text only for llama3.1:8b", local.got)
+	}
+}
+
+func TestGenerateSummarySendsScansAsPDF(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(pdfPath, []byte(scannedPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	local := &stubProvider{summary: summonsJSON}
+	gemini := &stubProvider{pdf: true, summary: strings.Replace(summonsJSON, `["Max Verstappen"]`, `[]`, 1)}
+	models, _ := parseModels("local/llama3.1:8b,gemini-2.5-flash")
+	s := &Summarizer{models: models, providers: map[string]Provider{ProviderVertex: gemini, ProviderLocal: local}, prompts: testPrompts(t)}
+
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || summary.Input != InputPDF || summary.Model != "vertex/gemini-2.5-flash" {
+		t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+	}
+	if len(local.got) != 0 {
+		t.Errorf("text-only model called %d times for a scan", len(local.got))
+	}
+	if len(gemini.got) != 1 || gemini.got[0].PDF == nil || gemini.got[0].Text != "" {
+		t.Errorf("vertex request = %+v, want the PDF", gemini.got)
+	}
+}
+
+func TestGenerateSummaryUsesCallerText(t *testing.T) {
+	// The scan has no text layer of its own, so the text can only come from
+	// the caller
+	pdfPath := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(pdfPath, []byte(scannedPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	local := &stubProvider{summary: summonsJSON}
+	models, _ := parseModels("local/llama3.1:8b")
+	s := &Summarizer{models: models, providers: map[string]Provider{ProviderLocal: local}, prompts: testPrompts(t)}
+
+	text := strings.Repeat("Car 1 - Max Verstappen (Red Bull Racing) is summoned to the stewards.\n", 10)
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{Text: text})
+	if err != nil || summary.Input != InputText {
+		t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+	}
+	if len(local.got) != 1 || local.got[0].Text != text {
+		t.Errorf("local request = %+v, want the caller's text", local.got)
+	}
+}
+
+func TestTrimToBudget(t *testing.T) {
+	text := strings.Repeat("Lap 12 Car 44 1:32.456\n", 100) // 2300 characters
+	tests := []struct {
+		budget    int
+		truncated bool
+	}{
+		{budget: 1000, truncated: false},
+		{budget: 100, truncated: true},
+	}
+	for _, tt := range tests {
+		got, truncated := trimToBudget(text, tt.budget)
+		if truncated != tt.truncated {
+			t.Errorf("trimToBudget(%d) truncated = %t, want %t", tt.budget, truncated, tt.truncated)
+		}
+		if !truncated {
+			if got != text {
+				t.Errorf("trimToBudget(%d) changed text within budget", tt.budget)
+			}
+			continue
+		}
+		if estimateTokens(got) > tt.budget+10 || !strings.HasSuffix(got, "1:32.456\n[Document truncated]") {
+			t.Errorf("trimToBudget(%d) = %d tokens ending %q, want a cut at a line break", tt.budget, estimateTokens(got), got[len(got)-40:])
+		}
+	}
+}
+
+// memoryCache is a Cache backed by a map
+type memoryCache map[string]string
+
+func (c memoryCache) GetSummary(ctx context.Context, pdfHash, model, promptVersion string) (string, bool, error) {
+	summary, ok := c[pdfHash+"|"+model+"|"+promptVersion]
+	return summary, ok, nil
+}
+
+func (c memoryCache) PutSummary(ctx context.Context, pdfHash, model, promptVersion, summary string) error {
+	c[pdfHash+"|"+model+"|"+promptVersion] = summary
+	return nil
+}
+
+func TestGenerateSummaryUsesCache(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &stubProvider{pdf: true, summary: summonsJSON}
+	models, _ := parseModels("gemini-2.5-flash:thinking")
+	cache := memoryCache{}
+	s := &Summarizer{models: models, providers: map[string]Provider{ProviderVertex: provider}, prompts: testPrompts(t), cache: cache}
+
+	for range 2 {
+		summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+		if err != nil || summary.Text != "Summons issued." || summary.PromptVersion != "default@2" {
+			t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+		}
+	}
+	if len(provider.got) != 1 {
+		t.Errorf("provider called %d times, want 1 (second call served from cache)", len(provider.got))
+	}
+	if len(cache) != 1 {
+		t.Fatalf("cache = %v, want one entry", cache)
+	}
+	for key := range cache {
+		if !strings.HasSuffix(key, "|vertex/gemini-2.5-flash:thinking|default@2") {
+			t.Errorf("cache key = %q, want model with thinking and prompt version", key)
+		}
+	}
+
+	// A new prompt version misses the cache
+	for key, summary := range cache {
+		delete(cache, key)
+		cache[strings.TrimSuffix(key, "2")+"1"] = summary
+	}
+	if _, err := s.GenerateSummary(context.Background(), pdfPath, Document{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.got) != 2 {
+		t.Errorf("provider called %d times, want a new call for a stale prompt version", len(provider.got))
+	}
+}
+
+func TestGenerateSummaryRejectsInvalidResponses(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prose := &stubProvider{pdf: true, summary: "Here is a summary: summons issued."}
+	local := &stubProvider{summary: "```json\n" + summonsJSON + "\n```"}
+	models, _ := parseModels("gemini-2.5-flash,local/llama3.1:8b")
+	s := &Summarizer{models: models, providers: map[string]Provider{ProviderVertex: prose, ProviderLocal: local}, prompts: testPrompts(t)}
+
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || summary.Model != "local/llama3.1:8b" || summary.Details.Teams[0] != "Red Bull Racing" {
+		t.Fatalf("GenerateSummary = %+v, %v, want the fenced JSON of the second model", summary, err)
+	}
+	if prose.got[0].Schema == nil {
+		t.Error("request has no response schema")
+	}
+}
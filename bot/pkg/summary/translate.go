@@ -0,0 +1,173 @@
+package summary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Translation is a summary translated into another language
+type Translation struct {
+	Language string // code, e.g. "es"
+	Text     string
+}
+
+// languageNames are the languages summaries can be translated into, by code
+var languageNames = map[string]string{
+	"de": "German",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+}
+
+// ParseLanguages parses a comma-separated list of language codes, e.g.
+// "es,it,pt,nl"
+func ParseLanguages(s string) ([]string, error) {
+	var languages []string
+	for code := range strings.SplitSeq(s, ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || slices.Contains(languages, code) {
+			continue
+		}
+		if _, ok := languageNames[code]; !ok {
+			return nil, fmt.Errorf("unsupported language %q", code)
+		}
+		languages = append(languages, code)
+	}
+	return languages, nil
+}
+
+// translationSystem instructs the model translating a summary; %[1]s is the
+// language's name
+const translationSystem = `You translate short summaries of FIA Formula 1 documents into %[1]s for social media.
+Keep the names of drivers, teams and places, car numbers, times and penalties exactly as they are, and use the terms %[1]s-speaking motorsport fans know.
+Respond with the translation only, as plain text without quotes, hashtags, emojis or markdown.`
+
+// translationPrompt asks for the translation of the summary sent as text
+const translationPrompt = "Translate this summary into %s:"
+
+// translate translates the summary text of doc into every configured
+// language with the route's models. Translations are cached by PDF hash,
+// model, language and text; a language no model could translate is left
+// out. The usage and cost of the calls are returned.
+func (s *Summarizer) translate(ctx context.Context, doc Document, rt route, pdfHash, text string) ([]Translation, Usage, float64) {
+	var usage Usage
+	var cost float64
+	if len(s.languages) == 0 || text == "" {
+		return nil, usage, cost
+	}
+	ctxLog := log.WithRequestContext(ctx).WithContext("method", "translate")
+
+	models, err := s.activeModels(ctx, rt.models)
+	if err != nil {
+		ctxLog.Warn("Daily budget spent, skipping translations")
+		return nil, usage, cost
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	textHash := hex.EncodeToString(sum[:4])
+
+	var translations []Translation
+	for _, lang := range s.languages {
+		if ctx.Err() != nil {
+			break
+		}
+		version := "translation-" + lang + "@" + textHash
+		if translated, ok := s.cachedTranslation(ctx, pdfHash, version, models); ok {
+			translations = append(translations, Translation{Language: lang, Text: translated})
+			continue
+		}
+
+		translated, err := s.translateWith(ctx, doc, rt, models, lang, text, pdfHash, version, &usage, &cost)
+		if err != nil {
+			ctxLog.Warn("Error translating summary, posting without it", "language", lang, "error", err)
+			continue
+		}
+		translations = append(translations, Translation{Language: lang, Text: translated})
+	}
+	ctxLog.Debug("Summary translated", "languages", len(translations), "input_tokens", usage.InputTokens, "output_tokens", usage.OutputTokens)
+	return translations, usage, cost
+}
+
+// translateWith tries each model in order until one translates text into
+// lang with a result passing the guardrails' token checks and keeping every
+// number of the original
+func (s *Summarizer) translateWith(ctx context.Context, doc Document, rt route, models []modelEntry, lang, text, pdfHash, version string, usage *Usage, cost *float64) (string, error) {
+	name := languageNames[lang]
+	lastError := errors.New("no models")
+	for _, model := range models {
+		b := s.breaker(model)
+		if !b.allow(time.Now()) {
+			lastError = fmt.Errorf("model %s skipped: circuit breaker open", model)
+			continue
+		}
+		req := rt.request(model)
+		req.System = fmt.Sprintf(translationSystem, name)
+		req.Prompt = fmt.Sprintf(translationPrompt, name)
+		req.Text = text
+
+		resp, err := s.summarizeWithRetry(ctx, s.providers[model.provider], b, model, doc, req, usage, cost)
+		translated := strings.Trim(strings.TrimSpace(resp.Text), `"`)
+		if err == nil {
+			err = s.checkTranslation(translated, text)
+		}
+		if err != nil {
+			lastError = err
+			continue
+		}
+
+		if s.cache != nil {
+			if err := s.cache.PutSummary(ctx, pdfHash, model.cacheKey(), version, translated); err != nil {
+				log.WithRequestContext(ctx).
+					WithContext("method", "translateWith").
+					Warn("Error caching translation", "error", err)
+			}
+		}
+		return translated, nil
+	}
+	return "", lastError
+}
+
+// checkTranslation validates a translation of original
+func (s *Summarizer) checkTranslation(translated, original string) error {
+	if translated == "" {
+		return reject("empty translation")
+	}
+	if err := s.guardrails.checkTokens(translated); err != nil {
+		return err
+	}
+	for _, n := range numberPattern.FindAllString(original, -1) {
+		if !slices.Contains(numberPattern.FindAllString(translated, -1), n) {
+			return reject("number %s missing from translation", n)
+		}
+	}
+	return nil
+}
+
+// cachedTranslation returns a cached translation by any of models. Cache
+// errors count as misses.
+func (s *Summarizer) cachedTranslation(ctx context.Context, pdfHash, version string, models []modelEntry) (string, bool) {
+	if s.cache == nil {
+		return "", false
+	}
+	for _, model := range models {
+		translated, ok, err := s.cache.GetSummary(ctx, pdfHash, model.cacheKey(), version)
+		if err != nil {
+			log.WithRequestContext(ctx).
+				WithContext("method", "cachedTranslation").
+				Warn("Error reading translation cache", "error", err)
+			return "", false
+		}
+		if ok {
+			return translated, true
+		}
+	}
+	return "", false
+}
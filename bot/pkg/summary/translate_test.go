@@ -0,0 +1,106 @@
+package summary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLanguages(t *testing.T) {
+	got, err := ParseLanguages(" es, IT,pt,es,,nl ")
+	if err != nil || strings.Join(got, ",") != "es,it,pt,nl" {
+		t.Errorf("ParseLanguages = %v, %v", got, err)
+	}
+	if _, err := ParseLanguages("es,xx"); err == nil {
+		t.Error("ParseLanguages accepted an unsupported language")
+	}
+}
+
+// translatingProvider summarizes in English and translates by the language
+// named in the system prompt
+type translatingProvider struct {
+	translations map[string]string // keyed by language name
+	got          []Request
+}
+
+func (p *translatingProvider) AcceptsPDF() bool { return true }
+
+func (p *translatingProvider) Summarize(ctx context.Context, req Request) (Response, error) {
+	p.got = append(p.got, req)
+	usage := Usage{InputTokens: 100, OutputTokens: 20}
+	if req.Schema != nil {
+		return Response{Text: summonsJSON, Usage: usage}, nil
+	}
+	for name, text := range p.translations {
+		if strings.Contains(req.System, name) {
+			return Response{Text: text, Usage: usage}, nil
+		}
+	}
+	return Response{Usage: usage}, nil
+}
+
+func TestGenerateSummaryTranslates(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(pdfPath, []byte(minimalPDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &translatingProvider{translations: map[string]string{
+		"Spanish": `"Citación emitida."`,
+		"Italian": "Convocazione emessa 🏎",
+	}}
+	models, _ := parseModels("gemini-2.5-flash")
+	s := &Summarizer{
+		models:    models,
+		providers: map[string]Provider{ProviderVertex: provider},
+		prompts:   testPrompts(t),
+		cache:     memoryCache{},
+		languages: []string{"es", "it"},
+	}
+
+	summary, err := s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil {
+		t.Fatalf("GenerateSummary: %v", err)
+	}
+	// The Italian translation has an emoji and is left out
+	if len(summary.Translations) != 1 || summary.Translations[0] != (Translation{Language: "es", Text: "Citación emitida."}) {
+		t.Errorf("Translations = %+v, want the Spanish one only", summary.Translations)
+	}
+	if len(provider.got) != 3 || summary.Usage != (Usage{InputTokens: 300, OutputTokens: 60}) {
+		t.Errorf("%d calls, usage %+v, want the summary and both translations counted", len(provider.got), summary.Usage)
+	}
+	if req := provider.got[1]; req.Text != "Summons issued." || req.Schema != nil {
+		t.Errorf("translation request = %+v, want the summary as plain text", req)
+	}
+
+	// The summary and the Spanish translation come from the cache
+	provider.got = nil
+	summary, err = s.GenerateSummary(context.Background(), pdfPath, Document{})
+	if err != nil || len(summary.Translations) != 1 {
+		t.Fatalf("GenerateSummary = %+v, %v", summary, err)
+	}
+	if len(provider.got) != 1 || !strings.Contains(provider.got[0].System, "Italian") {
+		t.Errorf("requests = %+v, want only the Italian translation retried", provider.got)
+	}
+}
+
+func TestCheckTranslation(t *testing.T) {
+	s := &Summarizer{guardrails: Guardrails{Banned: []string{"#"}}}
+	original := "Car 16 given a 5-second penalty for Turn 1."
+	tests := []struct {
+		translated string
+		wantErr    bool
+	}{
+		{"Coche 16 recibe 5 segundos de penalización por la curva 1.", false},
+		{"Coche 16 recibe una penalización por la curva 1.", true},
+		{"Coche 16 recibe 5 segundos #F1 en la curva 1.", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := s.checkTranslation(tt.translated, original); (err != nil) != tt.wantErr {
+			t.Errorf("checkTranslation(%q) = %v, want error %t", tt.translated, err, tt.wantErr)
+		}
+	}
+}
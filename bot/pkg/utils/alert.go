@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AlertClient posts operator alerts to a webhook (Slack-compatible
+// {"text": ...} payload). With no webhook configured alerts are only logged.
+type AlertClient struct {
+	WebhookURL string
+}
+
+// NewAlertClient creates a new AlertClient
+func NewAlertClient(webhookURL string) *AlertClient {
+	ctxLog := log.WithContext("method", "NewAlertClient")
+	if webhookURL == "" {
+		ctxLog.Info("No alert webhook configured, alerts will only be logged")
+	}
+
+	return &AlertClient{
+		WebhookURL: webhookURL,
+	}
+}
+
+// alertPayload is the webhook request body
+type alertPayload struct {
+	Text string `json:"text"`
+}
+
+// Send logs the alert and delivers it to the webhook, if configured
+func (c *AlertClient) Send(ctx context.Context, text string) error {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "Send")
+
+	ctxLog.Warn("Alert raised", "alert", text)
+	if c.WebhookURL == "" {
+		return nil
+	}
+
+	jsonData, err := json.Marshal(alertPayload{Text: text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.WebhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		ctxLog.Error("Failed to send alert", "error", err)
+		return fmt.Errorf("failed to send alert: %v", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			ctxLog.Error("Failed to close response body", "error", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		ctxLog.Error("Alert webhook returned error", "statusCode", resp.StatusCode)
+		return fmt.Errorf("alert webhook returned status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
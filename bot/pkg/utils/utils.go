@@ -3,11 +3,14 @@ package utils
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -38,6 +41,14 @@ type picsurResponse struct {
 	} `json:"data"`
 }
 
+// UploadedImage is an image hosted on Picsur. DeleteKey removes it again
+// (see DeleteImage).
+type UploadedImage struct {
+	ID        string
+	URL       string
+	DeleteKey string
+}
+
 func New(apiKey, baseURL string) *Client {
 	ctxLog := log.WithContext("method", "New")
 	ctxLog.Info("Creating new Picsur client", "baseURL", baseURL)
@@ -49,15 +60,15 @@ func New(apiKey, baseURL string) *Client {
 }
 
 // UploadImage uploads an already PNG-encoded image to Picsur and returns its
-// public URL.
-func (c *Client) UploadImage(ctx context.Context, pngData []byte) (string, error) {
+// public URL and delete key.
+func (c *Client) UploadImage(ctx context.Context, pngData []byte) (UploadedImage, error) {
 	ctxLog := log.WithRequestContext(ctx).
 		WithContext("method", "UploadImage")
 
 	// Ensure we have a base URL
 	if c.BaseURL == "" {
 		ctxLog.Error("Picsur base URL not configured")
-		return "", fmt.Errorf("picsur base URL not configured")
+		return UploadedImage{}, fmt.Errorf("picsur base URL not configured")
 	}
 
 	// Prepare multipart form data
@@ -69,16 +80,16 @@ func (c *Client) UploadImage(ctx context.Context, pngData []byte) (string, error
 	part, err := writer.CreateFormFile("image", "image.png")
 	if err != nil {
 		ctxLog.Error("Failed to create form file", "error", err)
-		return "", fmt.Errorf("failed to create form file: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to create form file: %v", err)
 	}
 	if _, err := part.Write(pngData); err != nil {
 		ctxLog.Error("Failed to copy image data", "error", err)
-		return "", fmt.Errorf("failed to copy image data: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to copy image data: %v", err)
 	}
 
 	if err := writer.Close(); err != nil {
 		ctxLog.Error("Failed to close multipart writer", "error", err)
-		return "", fmt.Errorf("failed to close multipart writer: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to close multipart writer: %v", err)
 	}
 
 	// Create request
@@ -87,7 +98,7 @@ func (c *Client) UploadImage(ctx context.Context, pngData []byte) (string, error
 	req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, body)
 	if err != nil {
 		ctxLog.Error("Failed to create request", "error", err)
-		return "", fmt.Errorf("failed to create request: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("Authorization", "Api-Key "+c.ApiKey)
@@ -99,7 +110,7 @@ func (c *Client) UploadImage(ctx context.Context, pngData []byte) (string, error
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		ctxLog.Error("Failed to send request", "error", err)
-		return "", fmt.Errorf("failed to send request: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -112,18 +123,70 @@ func (c *Client) UploadImage(ctx context.Context, pngData []byte) (string, error
 	var picsurResp picsurResponse
 	if err := json.NewDecoder(resp.Body).Decode(&picsurResp); err != nil {
 		ctxLog.Error("Failed to decode response", "error", err)
-		return "", fmt.Errorf("failed to decode response: %v", err)
+		return UploadedImage{}, fmt.Errorf("failed to decode response: %v", err)
 	}
 
 	if !picsurResp.Success {
 		ctxLog.Error("Picsur API error", "status", picsurResp.StatusCode)
-		return "", fmt.Errorf("picsur API error: status %d", picsurResp.StatusCode)
+		return UploadedImage{}, fmt.Errorf("picsur API error: status %d", picsurResp.StatusCode)
 	}
 
 	// Construct the image URL from the response ID
 	imageURL := fmt.Sprintf("%s/i/%s.png", c.BaseURL, picsurResp.Data.ID)
 	ctxLog.Debug("Image uploaded successfully", "url", imageURL)
-	return imageURL, nil
+	return UploadedImage{
+		ID:        picsurResp.Data.ID,
+		URL:       imageURL,
+		DeleteKey: picsurResp.Data.DeleteKey,
+	}, nil
+}
+
+// DeleteImage removes an image from Picsur using the delete key returned on
+// upload. An image that no longer exists counts as deleted.
+func (c *Client) DeleteImage(ctx context.Context, id, deleteKey string) error {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "DeleteImage").
+		WithContext("image_id", id)
+
+	payload, err := json.Marshal(map[string]string{"id": id, "key": deleteKey})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	deleteURL := fmt.Sprintf("%s/api/image/delete/key", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", deleteURL, bytes.NewReader(payload))
+	if err != nil {
+		ctxLog.Error("Failed to create request", "error", err)
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Api-Key "+c.ApiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		ctxLog.Error("Failed to send request", "error", err)
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var picsurResp picsurResponse
+	if err := json.NewDecoder(resp.Body).Decode(&picsurResp); err != nil {
+		ctxLog.Error("Failed to decode response", "error", err)
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	if !picsurResp.Success {
+		if picsurResp.StatusCode == http.StatusNotFound {
+			ctxLog.Debug("Image already deleted")
+			return nil
+		}
+		ctxLog.Error("Picsur API error", "status", picsurResp.StatusCode)
+		return fmt.Errorf("picsur API error: status %d", picsurResp.StatusCode)
+	}
+
+	ctxLog.Debug("Image deleted")
+	return nil
 }
 
 // EncodeURL encodes spaces in URL
@@ -131,6 +194,46 @@ func EncodeURL(input string) string {
 	return strings.ReplaceAll(input, " ", "%20")
 }
 
+// HashFile returns the hex-encoded SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("error hashing file: %v", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ExtractText returns the text of a PDF document, pages separated by form
+// feeds. Scanned pages without a text layer contribute nothing.
+func ExtractText(ctx context.Context, pdfPath string) (string, error) {
+	ctxLog := log.WithRequestContext(ctx).
+		WithContext("method", "ExtractText").
+		WithContext("pdfPath", pdfPath)
+
+	doc, err := fitz.New(pdfPath)
+	if err != nil {
+		ctxLog.Error("Failed to open PDF", "error", err)
+		return "", fmt.Errorf("failed to open PDF: %v", err)
+	}
+	defer func() { _ = doc.Close() }()
+
+	pages := make([]string, 0, doc.NumPage())
+	for i := range doc.NumPage() {
+		text, err := doc.Text(i)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text of page %d: %v", i+1, err)
+		}
+		pages = append(pages, strings.TrimSpace(text))
+	}
+	return strings.Join(pages, "\f"), nil
+}
+
 // renderDPI matches go-fitz's Image() default so output quality is unchanged.
 const renderDPI = 300
 